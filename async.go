@@ -0,0 +1,322 @@
+package stalog
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrWriterClosed is returned when writing to the closed AsyncWriter
+var ErrWriterClosed = errors.New("stalog: writer is closed")
+
+// BackPressure is the behavior of AsyncWriter when the queue is full
+type BackPressure int
+
+const (
+	// BackPressureBlock blocks Write until the queue has room
+	BackPressureBlock BackPressure = iota
+	// BackPressureDropOldest drops the oldest queued log to queue the new one
+	BackPressureDropOldest
+	// BackPressureDropNewest drops the new log
+	BackPressureDropNewest
+	// BackPressureSpill writes the new log to the fallback writer synchronously
+	BackPressureSpill
+)
+
+// AsyncWriterOptions is the options for NewAsyncWriterWithOptions
+type AsyncWriterOptions struct {
+	// BackPressure is the behavior when the queue is full
+	BackPressure BackPressure
+
+	// Fallback is the writer for BackPressureSpill (e.g. os.Stderr)
+	Fallback io.Writer
+
+	// QueueDepthHook is called with the number of the queued logs after each Write,
+	// so that the depth can be exported to the metrics
+	QueueDepthHook func(depth int)
+}
+
+// AsyncWriter writes logs to the underlying writer in a background goroutine,
+// so that slow outputs don't block handlers.
+// Logs are written in the order of Write calls, except the logs spilled to the fallback writer.
+type AsyncWriter struct {
+	out  io.Writer
+	size int
+	done chan struct{}
+	opts AsyncWriterOptions
+
+	mu sync.Mutex
+	// cond is signaled when an item is queued or taken, or the writer is closed
+	cond   *sync.Cond
+	items  []asyncItem
+	logs   int
+	closed bool
+}
+
+// asyncItem is a queued log, or a mark of Flush if flushed isn't nil
+type asyncItem struct {
+	b       []byte
+	flushed chan struct{}
+	// out is the writer of the item instead of the underlying writer (e.g. RequestLogOut for a request log)
+	out io.Writer
+}
+
+// NewAsyncWriter creates AsyncWriter which queues up to size logs
+func NewAsyncWriter(out io.Writer, size int) *AsyncWriter {
+	return NewAsyncWriterWithOptions(out, size, AsyncWriterOptions{})
+}
+
+// NewAsyncWriterWithOptions creates AsyncWriter which queues up to size logs with the options.
+// At least one log is queued.
+func NewAsyncWriterWithOptions(out io.Writer, size int, opts AsyncWriterOptions) *AsyncWriter {
+	if size < 1 {
+		size = 1
+	}
+
+	w := &AsyncWriter{
+		out:  out,
+		size: size,
+		done: make(chan struct{}),
+		opts: opts,
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+
+	return w
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	for {
+		item, ok := w.take()
+		if !ok {
+			return
+		}
+
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		out := w.out
+		if item.out != nil {
+			out = item.out
+		}
+		_, _ = out.Write(item.b)
+	}
+}
+
+// take waits for the next item. It returns false when the writer is closed and all items are taken.
+func (w *AsyncWriter) take() (asyncItem, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for len(w.items) == 0 {
+		if w.closed {
+			return asyncItem{}, false
+		}
+		w.cond.Wait()
+	}
+
+	item := w.items[0]
+	w.items[0] = asyncItem{}
+	w.items = w.items[1:]
+	if item.flushed == nil {
+		w.logs--
+	}
+	w.cond.Broadcast()
+
+	return item, true
+}
+
+// Write queues the log. The behavior while the queue is full depends on BackPressure.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	if err := w.enqueueLog(asyncItem{b: b}); err != nil {
+		return 0, err
+	}
+
+	if w.opts.QueueDepthHook != nil {
+		w.opts.QueueDepthHook(w.QueueDepth())
+	}
+
+	return len(p), nil
+}
+
+// QueueDepth returns the number of the queued logs
+func (w *AsyncWriter) QueueDepth() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.logs
+}
+
+// Flush waits until all logs queued before the call are written.
+// The mark of Flush doesn't take the room of the queue, so Flush doesn't wait for the room.
+func (w *AsyncWriter) Flush() error {
+	flushed := make(chan struct{})
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.items = append(w.items, asyncItem{flushed: flushed})
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-flushed
+	return nil
+}
+
+// Close writes all queued logs and stops the background goroutine
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+// enqueue queues the log, waiting for the room of the queue
+func (w *AsyncWriter) enqueue(item asyncItem) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for !w.closed && w.logs >= w.size {
+		w.cond.Wait()
+	}
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	w.push(item)
+	return nil
+}
+
+// push appends the log to the queue. w.mu must be held.
+func (w *AsyncWriter) push(item asyncItem) {
+	w.items = append(w.items, item)
+	w.logs++
+	w.cond.Broadcast()
+}
+
+// enqueueLog queues the log by BackPressure
+func (w *AsyncWriter) enqueueLog(item asyncItem) error {
+	if w.opts.BackPressure == BackPressureBlock {
+		return w.enqueue(item)
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	if w.logs < w.size {
+		w.push(item)
+		w.mu.Unlock()
+		return nil
+	}
+
+	switch w.opts.BackPressure {
+	case BackPressureDropOldest:
+		// the marks of Flush are kept at their positions, so the oldest log is dropped instead
+		if w.dropOldest() {
+			w.push(item)
+		}
+		w.mu.Unlock()
+		countDropped(dropQueueFull)
+		return nil
+	case BackPressureSpill:
+		w.mu.Unlock()
+		if w.opts.Fallback == nil {
+			countDropped(dropQueueFull)
+			return nil
+		}
+		_, err := w.opts.Fallback.Write(item.b)
+		return err
+	default:
+		w.mu.Unlock()
+		countDropped(dropQueueFull)
+		return nil
+	}
+}
+
+// dropOldest removes the oldest log written to the underlying writer from the queue.
+// The logs for the other writers (see asyncAfterWriter) aren't dropped. w.mu must be held.
+func (w *AsyncWriter) dropOldest() bool {
+	for i, item := range w.items {
+		if item.flushed == nil && item.out == nil {
+			copy(w.items[i:], w.items[i+1:])
+			w.items[len(w.items)-1] = asyncItem{}
+			w.items = w.items[:len(w.items)-1]
+			w.logs--
+			return true
+		}
+	}
+
+	return false
+}
+
+// flusher is implemented by buffered writers like AsyncWriter and bufio.Writer
+type flusher interface {
+	Flush() error
+}
+
+// asyncAfterWriter queues the logs for out to AsyncWriter, so that they are written after the logs queued before.
+// The logs are queued regardless of BackPressure, because dropping or spilling them breaks the order.
+type asyncAfterWriter struct {
+	w   *AsyncWriter
+	out io.Writer
+}
+
+func (a asyncAfterWriter) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	if err := a.w.enqueue(asyncItem{b: b, out: a.out}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// flushContextLog flushes the buffered context logs.
+// It must be called before writing a request log, because Cloud Logging groups
+// the context logs under the request log which spans them.
+// AsyncWriter isn't flushed, because the request log is queued after the context logs instead (see afterContextLogs).
+func flushContextLog(config *Config) {
+	if config.SingleStream {
+		// both logs are in the same stream, so the order is already kept
+		return
+	}
+
+	if _, ok := config.ContextLogOut.(*AsyncWriter); ok {
+		return
+	}
+	if f, ok := config.ContextLogOut.(flusher); ok {
+		_ = f.Flush()
+	}
+}
+
+// afterContextLogs returns the writer which writes the request log to out after the queued context logs.
+// With AsyncWriter, the request log is queued behind the context logs without waiting for them to be written.
+func (c *Config) afterContextLogs(out io.Writer) io.Writer {
+	if c.SingleStream {
+		return out
+	}
+
+	if w, ok := c.ContextLogOut.(*AsyncWriter); ok && out != io.Writer(w) {
+		return asyncAfterWriter{w: w, out: out}
+	}
+
+	return out
+}
@@ -0,0 +1,285 @@
+package stalog
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// orderRecorder records the lines written to some writers in the order of writing
+type orderRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+type recordWriter struct {
+	recorder *orderRecorder
+	name     string
+	delay    time.Duration
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+
+	w.recorder.mu.Lock()
+	defer w.recorder.mu.Unlock()
+	w.recorder.lines = append(w.recorder.lines, w.name)
+
+	return len(p), nil
+}
+
+func TestAsyncWriterOrdering(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	recorder := &orderRecorder{}
+	contextLogOut := NewAsyncWriter(&recordWriter{recorder: recorder, name: "context", delay: 10 * time.Millisecond}, 10)
+	defer contextLogOut.Close()
+
+	config := NewConfig("test")
+	config.RequestLogOut = &recordWriter{recorder: recorder, name: "request"}
+	config.ContextLogOut = contextLogOut
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Infof("1")
+		RequestContextLogger(r).Infof("2")
+	}))
+	handler.ServeHTTP(w, r)
+
+	// the request log is queued behind the context logs without blocking the request
+	recorder.mu.Lock()
+	if len(recorder.lines) != 0 {
+		t.Errorf("request must not wait for the queued logs: %v", recorder.lines)
+	}
+	recorder.mu.Unlock()
+	if err := contextLogOut.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(recorder.lines, ","); got != "context,context,request" {
+		t.Errorf("unexpected order: %s", got)
+	}
+}
+
+func TestAsyncWriterClose(t *testing.T) {
+	recorder := &orderRecorder{}
+	w := NewAsyncWriter(&recordWriter{recorder: recorder, name: "log"}, 1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write([]byte("log\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.lines) != 3 {
+		t.Errorf("queued logs must be written on close: %v", recorder.lines)
+	}
+	if _, err := w.Write([]byte("log\n")); err != ErrWriterClosed {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// blockingWriter blocks writes until released
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	mu      sync.Mutex
+	lines   []string
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+func TestAsyncWriterBackPressure(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   BackPressure
+		written  string
+		fallback string
+	}{
+		{name: "drop oldest", policy: BackPressureDropOldest, written: "1,3,4"},
+		{name: "drop newest", policy: BackPressureDropNewest, written: "1,2,3"},
+		{name: "spill", policy: BackPressureSpill, written: "1,2,3", fallback: "4"},
+	}
+
+	for _, tt := range tests {
+		out := newBlockingWriter()
+		fallback := &orderRecorder{}
+		var depths []int
+		w := NewAsyncWriterWithOptions(out, 2, AsyncWriterOptions{
+			BackPressure:   tt.policy,
+			Fallback:       &lineRecorder{recorder: fallback},
+			QueueDepthHook: func(depth int) { depths = append(depths, depth) },
+		})
+
+		// "1" is being written, and "2" and "3" fill the queue
+		_, _ = w.Write([]byte("1"))
+		<-out.started
+		_, _ = w.Write([]byte("2"))
+		_, _ = w.Write([]byte("3"))
+		_, _ = w.Write([]byte("4"))
+
+		close(out.release)
+		_ = w.Close()
+
+		if got := strings.Join(out.lines, ","); got != tt.written {
+			t.Errorf("%s: unexpected written logs: %s", tt.name, got)
+		}
+		if got := strings.Join(fallback.lines, ","); got != tt.fallback {
+			t.Errorf("%s: unexpected spilled logs: %s", tt.name, got)
+		}
+		if len(depths) != 4 || depths[3] != 2 {
+			t.Errorf("%s: unexpected depths: %v", tt.name, depths)
+		}
+	}
+}
+
+// lineRecorder records the written logs
+type lineRecorder struct {
+	recorder *orderRecorder
+}
+
+func (w *lineRecorder) Write(p []byte) (int, error) {
+	w.recorder.mu.Lock()
+	defer w.recorder.mu.Unlock()
+	w.recorder.lines = append(w.recorder.lines, string(p))
+	return len(p), nil
+}
+
+func TestAsyncWriterDropOldestKeepsFlush(t *testing.T) {
+	out := newBlockingWriter()
+	w := NewAsyncWriterWithOptions(out, 2, AsyncWriterOptions{BackPressure: BackPressureDropOldest})
+
+	// "1" is being written, and the flush marks are queued behind it
+	_, _ = w.Write([]byte("1"))
+	<-out.started
+	flushed := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			_ = w.Flush()
+			flushed <- struct{}{}
+		}()
+	}
+	for queued(w) != 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the queue overflows while "1" is still being written, and "2" is dropped instead of the marks
+	writes := make(chan struct{})
+	go func() {
+		for _, log := range []string{"2", "3", "4"} {
+			_, _ = w.Write([]byte(log))
+		}
+		close(writes)
+	}()
+	select {
+	case <-writes:
+	case <-time.After(time.Second):
+		t.Fatal("DropOldest must not block")
+	}
+	if n := queued(w); n != 5 {
+		t.Errorf("the marks must be kept: %d items", n)
+	}
+
+	select {
+	case <-flushed:
+		t.Error("Flush must not return before the logs queued before it are written")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(out.release)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-flushed:
+		case <-time.After(time.Second):
+			t.Fatal("Flush must return after the logs are written")
+		}
+	}
+	_ = w.Close()
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if got := strings.Join(out.lines, ","); got != "1,3,4" {
+		t.Errorf("unexpected written logs: %s", got)
+	}
+}
+
+// queued returns the number of the queued items including the marks of Flush
+func queued(w *AsyncWriter) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.items)
+}
+
+// gateWriter blocks writes until released
+type gateWriter struct {
+	release chan struct{}
+	out     io.Writer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.out.Write(p)
+}
+
+func TestAsyncWriterOrderingWithFullQueue(t *testing.T) {
+	for _, policy := range []BackPressure{BackPressureDropOldest, BackPressureDropNewest, BackPressureSpill} {
+		recorder := &orderRecorder{}
+		gate := &gateWriter{release: make(chan struct{}), out: &recordWriter{recorder: recorder, name: "context"}}
+		contextLogOut := NewAsyncWriterWithOptions(gate, 2, AsyncWriterOptions{
+			BackPressure: policy,
+			Fallback:     &recordWriter{recorder: recorder, name: "fallback"},
+		})
+
+		config := NewConfig("test")
+		config.RequestLogOut = &recordWriter{recorder: recorder, name: "request"}
+		config.ContextLogOut = contextLogOut
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// "1" is being written, and "2" and "3" fill the queue
+			RequestContextLogger(r).Infof("1")
+			for contextLogOut.QueueDepth() != 0 {
+				time.Sleep(time.Millisecond)
+			}
+			RequestContextLogger(r).Infof("2")
+			RequestContextLogger(r).Infof("3")
+		}))
+
+		served := make(chan struct{})
+		go func() {
+			r, _ := http.NewRequest("GET", "/foo", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			close(served)
+		}()
+
+		// the request log waits for the room of the queue instead of being dropped or spilled
+		time.Sleep(20 * time.Millisecond)
+		close(gate.release)
+		<-served
+		_ = contextLogOut.Close()
+
+		if got := strings.Join(recorder.lines, ","); got != "context,context,context,request" {
+			t.Errorf("%d: unexpected order: %s", policy, got)
+		}
+	}
+}
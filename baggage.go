@@ -0,0 +1,113 @@
+package stalog
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// baggageHeader is the canonical key of the W3C Baggage header
+const baggageHeader = "Baggage"
+
+// parseBaggage parses the W3C Baggage header into the key-values. Properties of members are ignored.
+func parseBaggage(h string) map[string]string {
+	if h == "" {
+		return nil
+	}
+
+	baggage := map[string]string{}
+	for _, member := range strings.Split(h, ",") {
+		if i := strings.Index(member, ";"); i >= 0 {
+			member = member[:i]
+		}
+		eq := strings.Index(member, "=")
+		if eq < 0 {
+			continue
+		}
+
+		key := strings.TrimSpace(member[:eq])
+		value, err := url.PathUnescape(strings.TrimSpace(member[eq+1:]))
+		if key == "" || err != nil {
+			continue
+		}
+		baggage[key] = value
+	}
+
+	return baggage
+}
+
+// formatBaggage formats the key-values as the W3C Baggage header
+func formatBaggage(baggage map[string]string) string {
+	keys := make([]string, 0, len(baggage))
+	for k := range baggage {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	members := make([]string, 0, len(keys))
+	for _, k := range keys {
+		members = append(members, k+"="+url.PathEscape(baggage[k]))
+	}
+
+	return strings.Join(members, ",")
+}
+
+// extractBaggage sets the baggage entries in BaggageLabels to the logger as labels
+func (l *ContextLogger) extractBaggage(r *http.Request) {
+	if len(l.config.BaggageLabels) == 0 {
+		return
+	}
+
+	baggage := parseBaggage(strings.Join(r.Header[baggageHeader], ","))
+	for _, key := range l.config.BaggageLabels {
+		if v, ok := baggage[key]; ok {
+			l.SetLabel(key, v)
+		}
+	}
+}
+
+// injectBaggage adds the labels in BaggageLabels to the W3C Baggage header
+func (l *ContextLogger) injectBaggage(headers http.Header) {
+	if len(l.config.BaggageLabels) == 0 {
+		return
+	}
+
+	labels := l.currentLabels()
+	baggage := parseBaggage(strings.Join(headers[baggageHeader], ","))
+	if baggage == nil {
+		baggage = map[string]string{}
+	}
+	for _, key := range l.config.BaggageLabels {
+		if v, ok := labels[key]; ok {
+			baggage[key] = v
+		}
+	}
+	if len(baggage) > 0 {
+		headers.Set(baggageHeader, formatBaggage(baggage))
+	}
+}
+
+// Transport propagates the trace, the labels (see InjectContext) and the baggage of the request-context logger
+// in the context of the outgoing requests.
+type Transport struct {
+	// Base is the underlying RoundTripper (default: http.DefaultTransport)
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// RoundTripper must not modify the request
+	r := req.Clone(req.Context())
+	InjectContext(r.Header, r.Context())
+	if logger, ok := r.Context().Value(ContextLoggerKey).(*ContextLogger); ok {
+		logger.injectBaggage(r.Header)
+	}
+
+	return base.RoundTrip(r)
+}
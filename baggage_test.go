@@ -0,0 +1,65 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseBaggage(t *testing.T) {
+	expected := map[string]string{"tenant": "acme corp", "userId": "42", "empty": ""}
+	actual := parseBaggage("tenant=acme%20corp;ttl=60, userId = 42,invalid,empty=")
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+
+	if h := formatBaggage(expected); h != "empty=,tenant=acme%20corp,userId=42" {
+		t.Errorf("unexpected baggage: %s", h)
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	var downstreamBaggage string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamBaggage = r.Header.Get("baggage")
+	}))
+	defer downstream.Close()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.BaggageLabels = []string{"tenant", "region"}
+
+	client := &http.Client{Transport: &Transport{}}
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).SetLabel("region", "asia")
+
+		req, _ := http.NewRequest("GET", downstream.URL, nil)
+		res, err := client.Do(req.WithContext(r.Context()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+	}))
+
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("baggage", "tenant=acme,secret=xxx")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"tenant": "acme", "region": "asia"}
+	if diff := cmp.Diff(expected, httpRequestLog.Labels); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+	if downstreamBaggage != "region=asia,tenant=acme" {
+		t.Errorf("unexpected baggage: %s", downstreamBaggage)
+	}
+}
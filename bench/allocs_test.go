@@ -0,0 +1,40 @@
+//go:build !race
+// +build !race
+
+package bench
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gcp-kit/stalog"
+)
+
+// TestAllocations is excluded with the race detector, which allocates in the instrumented code
+func TestAllocations(t *testing.T) {
+	config := newConfig()
+	logger := newLogger(config)
+	fieldsLogger := logger.WithFields(stalog.String("user", "alice"), stalog.Int("count", 3))
+	handler := stalog.RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	r, _ := http.NewRequest("GET", "/foo?bar=baz", nil)
+	w := httptest.NewRecorder()
+
+	tests := []struct {
+		name   string
+		target float64
+		fn     func()
+	}{
+		{"write", 16, func() { logger.Info("hello") }},
+		{"write with fields", 12, func() { fieldsLogger.Info("hello") }},
+		{"request", 200, func() { handler.ServeHTTP(w, r) }},
+	}
+
+	for _, tt := range tests {
+		if allocs := testing.AllocsPerRun(100, tt.fn); allocs > tt.target {
+			t.Errorf("%s: %.0f allocs/op exceeds the target %.0f", tt.name, allocs, tt.target)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package bench
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gcp-kit/stalog"
+)
+
+func newConfig() *stalog.Config {
+	config := stalog.NewConfig("bench")
+	config.RequestLogOut = ioutil.Discard
+	config.ContextLogOut = ioutil.Discard
+	config.AdditionalData = stalog.AdditionalData{
+		"service": "bench",
+		"version": 1.0,
+	}
+	return config
+}
+
+// newLogger gets the request-context logger of a request
+func newLogger(config *stalog.Config) *stalog.ContextLogger {
+	var logger *stalog.ContextLogger
+	handler := stalog.RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger = stalog.RequestContextLogger(r)
+	}))
+	r, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	return logger
+}
+
+func BenchmarkWrite(b *testing.B) {
+	logger := newLogger(newConfig())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("hello")
+	}
+}
+
+func BenchmarkWriteWithFields(b *testing.B) {
+	logger := newLogger(newConfig()).WithFields(
+		stalog.String("user", "alice"),
+		stalog.Int("count", 3),
+		stalog.Duration("db_time", 1500*time.Millisecond),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("hello")
+	}
+}
+
+func BenchmarkRequestLog(b *testing.B) {
+	handler := stalog.RequestLogging(newConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	r, _ := http.NewRequest("GET", "/foo?bar=baz", nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkConcurrentWrite(b *testing.B) {
+	logger := newLogger(newConfig())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info("hello")
+		}
+	})
+}
@@ -0,0 +1,12 @@
+// Package bench has the benchmarks of stalog and the allocation regression tests.
+//
+// Run them with:
+//
+//	go test -bench . -benchmem ./bench
+//
+// Performance targets in allocations per operation, which are guarded by TestAllocations:
+//
+//	plain write                     16
+//	write with a WithFields logger  12
+//	request with a request log      200
+package bench
@@ -0,0 +1,144 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// captureBody keeps up to limit bytes of the request body read by the handler
+type captureBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if room := b.limit - b.buf.Len(); room >= n {
+			b.buf.Write(p[:n])
+		} else {
+			if room > 0 {
+				b.buf.Write(p[:room])
+			}
+			b.truncated = true
+		}
+	}
+
+	return n, err
+}
+
+// binaryContentTypes are the prefixes of the content types whose bodies aren't captured
+var binaryContentTypes = []string{
+	"image/", "audio/", "video/", "font/", "application/octet-stream", "application/pdf", "application/zip",
+	"application/gzip", "application/grpc", "application/protobuf", "application/x-protobuf", "multipart/",
+}
+
+// xmlSpaces matches the whitespaces between XML tags
+var xmlSpaces = regexp.MustCompile(`>\s+<`)
+
+// requestBodyData returns the captured body for the request log's data by the content type:
+// the form-encoded and JSON bodies are parsed into the values with redacting secret-like keys,
+// the XML and text bodies are kept as strings only if text is true (the whitespaces between XML tags are removed),
+// and the binary bodies are skipped.
+func (b *captureBody) requestBodyData(contentType string, text bool) AdditionalData {
+	if b.buf.Len() == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	body := map[string]interface{}{"contentType": mediaType}
+	if b.truncated {
+		body["truncated"] = true
+	}
+
+	switch {
+	case isBinaryContentType(mediaType):
+		body["skipped"] = "binary"
+	case mediaType == "application/x-www-form-urlencoded" && !b.truncated:
+		values, err := url.ParseQuery(b.buf.String())
+		if err != nil {
+			body["text"] = sanitizeMessage(b.buf.String())
+			break
+		}
+		form := make(map[string]interface{}, len(values))
+		for k, vs := range values {
+			for i := range vs {
+				vs[i] = redact(k, vs[i])
+			}
+			if len(vs) == 1 {
+				form[k] = vs[0]
+			} else {
+				form[k] = vs
+			}
+		}
+		body["form"] = form
+	case mediaType == "application/x-www-form-urlencoded":
+		// the truncated form may have a broken secret
+		body["skipped"] = "truncated form"
+	case isJSONContentType(mediaType) && b.truncated:
+		// the truncated JSON can't be parsed to redact the secrets
+		body["skipped"] = "truncated json"
+	case isJSONContentType(mediaType):
+		decoder := json.NewDecoder(&b.buf)
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			body["skipped"] = "invalid json"
+			break
+		}
+		body["json"] = redactJSON(v)
+	case !text:
+		// the secrets in the XML and text bodies can't be redacted
+		body["skipped"] = "text"
+	case strings.HasSuffix(mediaType, "/xml") || strings.HasSuffix(mediaType, "+xml"):
+		body["text"] = sanitizeMessage(strings.TrimSpace(xmlSpaces.ReplaceAllString(b.buf.String(), "><")))
+	default:
+		body["text"] = sanitizeMessage(b.buf.String())
+	}
+
+	return AdditionalData{"requestBody": body}
+}
+
+func isJSONContentType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// redactJSON redacts the values of secret-like keys in the decoded JSON recursively
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if s, ok := value.(string); ok {
+				v[k] = redact(k, s)
+			} else if redact(k, "") != "" {
+				// the objects and the numbers under secret-like keys are redacted as a whole
+				v[k] = "[REDACTED]"
+			} else {
+				v[k] = redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJSON(value)
+		}
+	}
+
+	return v
+}
+
+func isBinaryContentType(mediaType string) bool {
+	for _, prefix := range binaryContentTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCaptureRequestBody(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		contentType string
+		body        string
+		text        bool
+		want        map[string]interface{}
+	}{
+		{
+			"form", "application/x-www-form-urlencoded", "user=alice&password=hunter2&tag=a&tag=b", false,
+			map[string]interface{}{"contentType": "application/x-www-form-urlencoded", "form": map[string]interface{}{
+				"user": "alice", "password": "[REDACTED]", "tag": []interface{}{"a", "b"},
+			}},
+		},
+		{
+			"xml", "text/xml; charset=utf-8", "<Envelope>\n  <Body>\n    <Ping/>\n  </Body>\n</Envelope>\n", true,
+			map[string]interface{}{"contentType": "text/xml", "text": "<Envelope><Body><Ping/></Body></Envelope>"},
+		},
+		{
+			"truncated text", "text/plain", strings.Repeat("a", 80), true,
+			map[string]interface{}{"contentType": "text/plain", "text": strings.Repeat("a", 64), "truncated": true},
+		},
+		{
+			"xml without CaptureTextRequestBody", "application/soap+xml", "<Envelope><Password>hunter2</Password></Envelope>", false,
+			map[string]interface{}{"contentType": "application/soap+xml", "skipped": "text"},
+		},
+		{
+			"truncated form", "application/x-www-form-urlencoded", "token=" + strings.Repeat("x", 80), false,
+			map[string]interface{}{"contentType": "application/x-www-form-urlencoded", "skipped": "truncated form", "truncated": true},
+		},
+		{
+			"json", "application/json", `{"user":"al","password":"pw","auth":{"apiKey":1},"n":[1]}`, false,
+			map[string]interface{}{"contentType": "application/json", "json": map[string]interface{}{
+				"user": "al", "password": "[REDACTED]", "auth": map[string]interface{}{"apiKey": "[REDACTED]"}, "n": []interface{}{float64(1)},
+			}},
+		},
+		{
+			"truncated json", "application/json", `{"token":"` + strings.Repeat("x", 80) + `"}`, false,
+			map[string]interface{}{"contentType": "application/json", "skipped": "truncated json", "truncated": true},
+		},
+		{
+			"binary", "image/png", "\x89PNG\r\n", false,
+			map[string]interface{}{"contentType": "image/png", "skipped": "binary"},
+		},
+	} {
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.CaptureRequestBody = 64
+		config.CaptureTextRequestBody = tt.text
+
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			if string(b) != tt.body {
+				t.Errorf("%s: the handler got %q", tt.name, b)
+			}
+		}))
+		r, _ := http.NewRequest("POST", "/hook", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", tt.contentType)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		var requestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+			t.Fatal(err)
+		}
+		if got := requestLog.AdditionalData["requestBody"]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package stalog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultByteBudgetPeriod is the window of ByteBudget when ByteBudgetPeriod is 0
+const defaultByteBudgetPeriod = time.Minute
+
+// processByteBudget limits the bytes of context logs across all requests
+var processByteBudget = &byteBudget{}
+
+// byteBudget counts the bytes of context logs written in the current window
+type byteBudget struct {
+	mu        sync.Mutex
+	start     time.Time
+	used      int64
+	throttled bool
+}
+
+// roll starts a new window if the current one is over. It must be called with mu held.
+func (b *byteBudget) roll(period time.Duration, now time.Time) {
+	if now.Sub(b.start) >= period {
+		b.start = now
+		b.used = 0
+		b.throttled = false
+	}
+}
+
+// allow reports whether a log at the severity can be written in the current window.
+// WARNING and more severe logs are always allowed. notice is true for the first log throttled in the window.
+func (b *byteBudget) allow(severity Severity, limit int64, period time.Duration, now time.Time) (ok bool, notice bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll(period, now)
+	if b.used < limit || severity >= SeverityWarning {
+		return true, false
+	}
+
+	notice = !b.throttled
+	b.throttled = true
+	return false, notice
+}
+
+// add counts the bytes of a written log
+func (b *byteBudget) add(n int, period time.Duration, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll(period, now)
+	b.used += int64(n)
+}
+
+// byteBudgetPeriod returns the window of ByteBudget
+func (c *Config) byteBudgetPeriod() time.Duration {
+	if c.ByteBudgetPeriod > 0 {
+		return c.ByteBudgetPeriod
+	}
+
+	return defaultByteBudgetPeriod
+}
+
+// allowByteBudget reports whether the log at the severity is written under ByteBudget,
+// and writes a NOTICE when the context logs start to be throttled
+func (l *ContextLogger) allowByteBudget(severity Severity) bool {
+	if l.config.ByteBudget <= 0 {
+		return true
+	}
+
+	period := l.config.byteBudgetPeriod()
+	ok, notice := processByteBudget.allow(severity, l.config.ByteBudget, period, time.Now())
+	if notice {
+		msg := fmt.Sprintf("context logs below WARNING are throttled until the window resets (ByteBudget: %d bytes per %s)", l.config.ByteBudget, period)
+		_ = l.output(l.newLog(SeverityNotice, SourceLocation{}, msg))
+	}
+	if !ok {
+		countDropped(dropByteBudget)
+	}
+
+	return ok
+}
+
+// countByteBudget counts the bytes of a written context log against ByteBudget
+func (l *ContextLogger) countByteBudget(n int) {
+	if l.config.ByteBudget <= 0 {
+		return
+	}
+
+	processByteBudget.add(n, l.config.byteBudgetPeriod(), time.Now())
+}
@@ -0,0 +1,70 @@
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestByteBudget(t *testing.T) {
+	b := &byteBudget{}
+	now := time.Now()
+
+	if ok, _ := b.allow(SeverityInfo, 100, time.Minute, now); !ok {
+		t.Error("first log must be allowed")
+	}
+	b.add(100, time.Minute, now)
+
+	if ok, notice := b.allow(SeverityInfo, 100, time.Minute, now); ok || !notice {
+		t.Errorf("log over the budget must be throttled with a notice: ok=%v, notice=%v", ok, notice)
+	}
+	if ok, notice := b.allow(SeverityDebug, 100, time.Minute, now); ok || notice {
+		t.Errorf("notice must be once per window: ok=%v, notice=%v", ok, notice)
+	}
+	if ok, _ := b.allow(SeverityWarning, 100, time.Minute, now); !ok {
+		t.Error("WARNING must be allowed over the budget")
+	}
+	if ok, _ := b.allow(SeverityInfo, 100, time.Minute, now.Add(time.Minute)); !ok {
+		t.Error("log must be allowed after the window resets")
+	}
+}
+
+func TestContextLoggerByteBudget(t *testing.T) {
+	processByteBudget = &byteBudget{}
+	defer func() { processByteBudget = &byteBudget{} }()
+
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.ByteBudget = 1
+	config.ByteBudgetPeriod = time.Hour
+
+	logger := newDefaultLogger(config)
+	logger.Info("first")
+	logger.Info("throttled")
+	logger.Debug("throttled")
+	logger.Warning("warning")
+
+	var messages []string
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var cLog contextLog
+		if err := json.Unmarshal(scanner.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, cLog.Severity+" "+cLog.Message)
+	}
+
+	if len(messages) != 3 {
+		t.Fatalf("unexpected logs: %q", messages)
+	}
+	if messages[0] != "INFO first" || messages[2] != "WARNING warning" {
+		t.Errorf("unexpected logs: %q", messages)
+	}
+	if !strings.HasPrefix(messages[1], "NOTICE context logs below WARNING are throttled") {
+		t.Errorf("unexpected notice: %q", messages[1])
+	}
+}
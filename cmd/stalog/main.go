@@ -0,0 +1,188 @@
+// Command stalog renders the logs of stalog in NDJSON format as the human-readable text for local debugging.
+//
+//	kubectl logs deploy/api | stalog
+//
+// The logs with a trace are grouped by the trace and printed when the request log of the trace is read,
+// and the lines which aren't logs are printed as they are.
+// Set -data-key and -promote for the logs written with DataKey and PromoteFields of the config.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gcp-kit/stalog"
+)
+
+func main() {
+	noColor := flag.Bool("no-color", os.Getenv("NO_COLOR") != "", "disable colors")
+	noGroup := flag.Bool("no-group", false, "print the logs in the order of the input without grouping by the trace")
+	dataKey := flag.String("data-key", "", "key of the data in the logs (DataKey of the config)")
+	promote := flag.String("promote", "", "comma-separated keys of the data at the top level of the logs (PromoteFields of the config)")
+	flag.Parse()
+
+	config := &stalog.Config{DataKey: *dataKey}
+	if *promote != "" {
+		config.PromoteFields = strings.Split(*promote, ",")
+	}
+
+	p := &printer{out: bufio.NewWriter(os.Stdout), color: !*noColor, group: !*noGroup, traces: map[string][]*stalog.Entry{}, config: config}
+	if err := p.run(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+const (
+	colorReset  = "\x1b[0m"
+	colorGray   = "\x1b[90m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorCyan   = "\x1b[36m"
+)
+
+// printer renders the logs. The logs of a trace are kept until the request log of the trace.
+type printer struct {
+	out    *bufio.Writer
+	color  bool
+	group  bool
+	traces map[string][]*stalog.Entry
+	order  []string
+	config *stalog.Config
+}
+
+func (p *printer) run(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			p.line(line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, trace := range p.order {
+		p.flush(trace)
+	}
+
+	return p.out.Flush()
+}
+
+// line renders a line, or keeps it until the request log of the trace
+func (p *printer) line(line []byte) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+
+	entry, err := p.decoder(line).Decode()
+	if err != nil {
+		_, _ = p.out.Write(bytes.TrimRight(line, "\n"))
+		_ = p.out.WriteByte('\n')
+		return
+	}
+
+	if !p.group || entry.Trace == "" {
+		p.print(entry, "")
+		return
+	}
+
+	if _, ok := p.traces[entry.Trace]; !ok {
+		p.order = append(p.order, entry.Trace)
+	}
+	p.traces[entry.Trace] = append(p.traces[entry.Trace], entry)
+
+	if entry.RequestLog {
+		p.flush(entry.Trace)
+	}
+}
+
+// decoder returns the decoder of the line with the data layout of the flags
+func (p *printer) decoder(line []byte) *stalog.Decoder {
+	if p.config == nil {
+		return stalog.NewDecoder(bytes.NewReader(line))
+	}
+
+	return stalog.NewDecoderForConfig(bytes.NewReader(line), p.config)
+}
+
+// flush renders the logs of the trace with the header
+func (p *printer) flush(trace string) {
+	entries, ok := p.traces[trace]
+	if !ok {
+		return
+	}
+	delete(p.traces, trace)
+	for i, t := range p.order {
+		if t == trace {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			break
+		}
+	}
+
+	fmt.Fprintf(p.out, "%s\n", p.paint(colorCyan, "── "+trace[strings.LastIndex(trace, "/")+1:]))
+	for _, entry := range entries {
+		p.print(entry, "  ")
+	}
+}
+
+// print renders a log in a line like "15:04:05.000 WARNING  message file.go:12 key=value"
+func (p *printer) print(entry *stalog.Entry, indent string) {
+	fmt.Fprintf(p.out, "%s%s %s ", indent, p.paint(colorGray, entry.Time.Format("15:04:05.000")), p.severity(entry.Severity))
+
+	if entry.RequestLog {
+		r := entry.HTTPRequest
+		fmt.Fprintf(p.out, "%s %s %d %s", r.RequestMethod, r.RequestUrl, r.Status, r.Latency)
+	} else {
+		p.out.WriteString(entry.Message)
+	}
+
+	if entry.SourceLocation != nil {
+		fmt.Fprintf(p.out, " %s", p.paint(colorGray, entry.SourceLocation.File+":"+entry.SourceLocation.Line))
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(p.out, " %s=%v", p.paint(colorBlue, k), entry.Data[k])
+	}
+
+	p.out.WriteByte('\n')
+}
+
+// severity renders the severity padded to the same width
+func (p *printer) severity(severity stalog.Severity) string {
+	s := fmt.Sprintf("%-9s", severity)
+	switch {
+	case severity >= stalog.SeverityError:
+		return p.paint(colorRed, s)
+	case severity >= stalog.SeverityWarning:
+		return p.paint(colorYellow, s)
+	case severity <= stalog.SeverityDebug:
+		return p.paint(colorGray, s)
+	default:
+		return s
+	}
+}
+
+func (p *printer) paint(color string, s string) string {
+	if !p.color {
+		return s
+	}
+
+	return color + s + colorReset
+}
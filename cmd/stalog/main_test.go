@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gcp-kit/stalog"
+)
+
+func TestPrinter(t *testing.T) {
+	input := strings.Join([]string{
+		`{"time":"2020-01-02T03:04:05.678Z","logging.googleapis.com/trace":"projects/p/traces/t1","severity":"INFO","message":"first","data":{"user":"alice"}}`,
+		`{"time":"2020-01-02T03:04:05.700Z","severity":"WARNING","message":"no trace"}`,
+		`panic: oops`,
+		`{"time":"2020-01-02T03:04:05.800Z","logging.googleapis.com/trace":"projects/p/traces/t1","severity":"ERROR","httpRequest":{"requestMethod":"GET","requestUrl":"/foo","status":500,"latency":"0.1s"}}`,
+		`{"time":"2020-01-02T03:04:05.900Z","logging.googleapis.com/trace":"projects/p/traces/t2","severity":"DEBUG","message":"unfinished"}`,
+	}, "\n")
+
+	out := new(bytes.Buffer)
+	p := &printer{out: bufio.NewWriter(out), group: true, traces: map[string][]*stalog.Entry{}}
+	if err := p.run(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"03:04:05.700 WARNING   no trace",
+		"panic: oops",
+		"── t1",
+		"  03:04:05.678 INFO      first user=alice",
+		"  03:04:05.800 ERROR     GET /foo 500 0.1s",
+		"── t2",
+		"  03:04:05.900 DEBUG     unfinished",
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestPrinterDataLayout(t *testing.T) {
+	input := `{"time":"2020-01-02T03:04:05.678Z","severity":"INFO","message":"hello","user":"alice","payload":{"count":3}}`
+
+	out := new(bytes.Buffer)
+	config := &stalog.Config{DataKey: "payload", PromoteFields: []string{"user"}}
+	p := &printer{out: bufio.NewWriter(out), traces: map[string][]*stalog.Entry{}, config: config}
+	if err := p.run(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "03:04:05.678 INFO      hello count=3 user=alice\n"; out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
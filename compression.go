@@ -0,0 +1,47 @@
+package stalog
+
+import (
+	"fmt"
+)
+
+// The size of the response depends on the order of the middleware and compression middlewares:
+//
+//   - the middleware outside compression counts the compressed bytes as responseSize
+//   - the middleware inside compression counts the uncompressed bytes as responseSize
+//
+// Compression writers can report the other size by implementing CompressedSizer (outside)
+// or calling UncompressedSizeRecorder (inside), and then the request log has both
+// responseSize (compressed) and "uncompressedSize" in the data.
+
+// CompressedSizer is implemented by compression ResponseWriters which wrap the middleware
+// to report the compressed size written to the client
+type CompressedSizer interface {
+	CompressedSize() int64
+}
+
+// UncompressedSizeRecorder is implemented by the ResponseWriter of the middleware.
+// Compression ResponseWriters inside the middleware can record the uncompressed size with it.
+type UncompressedSizeRecorder interface {
+	AddUncompressedSize(n int)
+}
+
+// AddUncompressedSize records the uncompressed size of the response
+func (w *wrappedResponseWriter) AddUncompressedSize(n int) {
+	w.uncompressedSize += n
+}
+
+// compressionSizes applies the sizes reported by the compression writer to the request log,
+// and returns the size of the body written to the client
+func (w *wrappedResponseWriter) compressionSizes(requestLog *HTTPRequestLog) int {
+	size, uncompressed := w.responseSize, w.uncompressedSize
+	if cs, ok := w.ResponseWriter.(CompressedSizer); ok {
+		size, uncompressed = int(cs.CompressedSize()), w.responseSize
+		requestLog.HTTPRequest.ResponseSize = fmt.Sprintf("%d", size)
+	}
+
+	if uncompressed > 0 {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, AdditionalData{"uncompressedSize": uncompressed})
+	}
+
+	return size
+}
@@ -0,0 +1,108 @@
+package stalog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// gzipResponseWriter is a minimal compression writer for the tests
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz         *gzip.Writer
+	compressed *countingWriter
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+func newGzipResponseWriter(w http.ResponseWriter) *gzipResponseWriter {
+	cw := &countingWriter{ResponseWriter: w}
+	return &gzipResponseWriter{ResponseWriter: w, gz: gzip.NewWriter(cw), compressed: cw}
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	w.Header().Set("Content-Encoding", "gzip")
+	n, err := w.gz.Write(b)
+	if r, ok := w.ResponseWriter.(UncompressedSizeRecorder); ok {
+		r.AddUncompressedSize(n)
+	}
+	return n, err
+}
+
+func (w *gzipResponseWriter) CompressedSize() int64 {
+	return w.compressed.n
+}
+
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gw := newGzipResponseWriter(w)
+		next.ServeHTTP(gw, r)
+		_ = gw.gz.Close()
+	})
+}
+
+func TestCompressionSizes(t *testing.T) {
+	body := strings.Repeat("a", 10000)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+
+	newConfig := func(out *bytes.Buffer) *Config {
+		config := NewConfig("test")
+		config.RequestLogOut = out
+		config.ContextLogOut = new(bytes.Buffer)
+		return config
+	}
+
+	tests := []struct {
+		name    string
+		handler func(config *Config) http.Handler
+	}{
+		{
+			name: "compression inside",
+			handler: func(config *Config) http.Handler {
+				return RequestLogging(config)(gzipMiddleware(handler))
+			},
+		},
+		{
+			name: "compression outside",
+			handler: func(config *Config) http.Handler {
+				return gzipMiddleware(RequestLogging(config)(handler))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		out := new(bytes.Buffer)
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		tt.handler(newConfig(out)).ServeHTTP(w, r)
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(out.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+
+		// responseSize is the compressed size written to the client
+		if size, err := strconv.Atoi(httpRequestLog.HTTPRequest.ResponseSize); err != nil || size >= len(body) {
+			t.Errorf("%s: unexpected response size: %s", tt.name, httpRequestLog.HTTPRequest.ResponseSize)
+		}
+		if httpRequestLog.AdditionalData["uncompressedSize"] != float64(10000) {
+			t.Errorf("%s: unexpected data: %+v", tt.name, httpRequestLog.AdditionalData)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package stalog
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// MessageHandler handles a message of queues like Pub/Sub, Cloud Tasks and Kafka
+type MessageHandler func(ctx context.Context, msg interface{}) error
+
+// ErrorWithSeverity is implemented by errors which specify the severity to be logged
+type ErrorWithSeverity interface {
+	error
+	Severity() Severity
+}
+
+type consumerInfo struct {
+	Name    string `json:"name"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type consumerLog struct {
+	Time           string            `json:"time"`
+	Trace          string            `json:"logging.googleapis.com/trace"`
+	SpanID         string            `json:"logging.googleapis.com/spanId,omitempty"`
+	TraceSampled   bool              `json:"logging.googleapis.com/trace_sampled,omitempty"`
+	LogName        string            `json:"logging.googleapis.com/logName,omitempty"`
+	Severity       string            `json:"severity"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
+	Message        string            `json:"message"`
+	Consumer       consumerInfo      `json:"consumer"`
+	ServiceContext *ServiceContext   `json:"serviceContext,omitempty"`
+	AdditionalData AdditionalData    `json:"data,omitempty"`
+}
+
+// MessageLogging wraps the handler of queue consumers like RequestLogging.
+// It creates a logger for each message which can be retrieved by ContextLogger key from the context,
+// and logs a summary of the message to RequestLogOut with the latency.
+// The trace of the span in the context is used if exists, then the trace in the attributes of the message
+// written by TraceAttributes (see messageAttributes for the supported messages), otherwise a new trace is created.
+// Returned errors are logged at ERROR, or the severity of ErrorWithSeverity in the chain of the wrapped errors.
+func MessageLogging(config *Config, name string, next MessageHandler) MessageHandler {
+	return func(ctx context.Context, msg interface{}) error {
+		before := time.Now()
+
+		span := trace.FromContext(ctx)
+		if span == nil {
+			if parent, ok := traceFromAttributes(messageAttributes(msg)); ok {
+				ctx, span = trace.StartSpanWithRemoteParent(ctx, name, parent)
+			} else {
+				ctx, span = trace.StartSpan(ctx, name)
+			}
+			defer span.End()
+		}
+		sc := span.SpanContext()
+		traceId := sc.TraceID.String()
+		traces := config.traceResource(nil, traceId)
+
+		logger := newContextLogger(config, traces, traceId)
+		logger.spanId = sc.SpanID.String()
+		logger.traceSampled = sc.IsSampled()
+		ctx = ContextWithLogger(ctx, logger)
+
+		err := next(ctx, msg)
+
+		elapsed := time.Since(before)
+		logger.flushSuppressed()
+		flushContextLog(config)
+
+		severity := logger.MaxSeverity()
+		message := fmt.Sprintf("message handled: %s", name)
+		info := consumerInfo{Name: name, Latency: fmt.Sprintf("%fs", elapsed.Seconds())}
+		if err != nil {
+			errSeverity := SeverityError
+			var se ErrorWithSeverity
+			if errors.As(err, &se) {
+				errSeverity = se.Severity()
+			}
+			if errSeverity > severity {
+				severity = errSeverity
+			}
+			message = fmt.Sprintf("message failed: %s: %s", name, err.Error())
+			info.Error = err.Error()
+		}
+
+		log := &consumerLog{
+			Time:           config.logTime(),
+			Trace:          traces,
+			SpanID:         logger.spanId,
+			TraceSampled:   logger.traceSampled,
+			LogName:        config.RequestLogName,
+			Severity:       severity.String(),
+			Labels:         logger.currentLabels(),
+			Message:        message,
+			Consumer:       info,
+			ServiceContext: config.serviceContext(),
+			AdditionalData: config.AdditionalData,
+		}
+		out := config.route(&Entry{
+			Severity:   severity,
+			Message:    log.Message,
+			Trace:      log.Trace,
+			Labels:     log.Labels,
+			Data:       log.AdditionalData,
+			RequestLog: true,
+		}, config.requestLogOut())
+		if config.Format == FormatConsole {
+			if _, werr := out.Write(consoleSummary(log.Time, log.Severity, log.Message, info.Latency, log.AdditionalData)); werr != nil {
+				config.reportError(werr)
+			}
+		} else if werr := writeJSON(config, out, log, &log.AdditionalData); werr != nil {
+			config.reportError(werr)
+		}
+
+		return err
+	}
+}
+
+// messageAttributes returns the attributes of the message, which is map[string]string,
+// a value with the method "Attributes() map[string]string",
+// or a struct (or a pointer to it) with the field "Attributes map[string]string" like pubsub.Message
+func messageAttributes(msg interface{}) map[string]string {
+	switch m := msg.(type) {
+	case map[string]string:
+		return m
+	case interface{ Attributes() map[string]string }:
+		return m.Attributes()
+	}
+
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if f := v.FieldByName("Attributes"); f.IsValid() && f.CanInterface() {
+		attrs, _ := f.Interface().(map[string]string)
+		return attrs
+	}
+
+	return nil
+}
+
+// traceFromAttributes returns the trace context in the attributes written by TraceAttributes
+func traceFromAttributes(attrs map[string]string) (trace.SpanContext, bool) {
+	var traceId, spanId string
+	var sampled, ok bool
+	if h, found := attrs["traceparent"]; found {
+		traceId, spanId, sampled, ok = parseTraceparent(h)
+	}
+	if h, found := attrs["X-Cloud-Trace-Context"]; found && !ok {
+		traceId, spanId, sampled, ok = parseCloudTraceContext(h)
+	}
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+
+	var sc trace.SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(traceId)); err != nil {
+		return trace.SpanContext{}, false
+	}
+	if spanId != "" {
+		if _, err := hex.Decode(sc.SpanID[:], []byte(spanId)); err != nil {
+			return trace.SpanContext{}, false
+		}
+	}
+	if sampled {
+		sc.TraceOptions = 1
+	}
+
+	return sc, true
+}
+
+// parseCloudTraceContext parses "TRACE_ID/SPAN_ID;o=TRACE_TRUE" and returns the span ID in hex like the propagation package
+func parseCloudTraceContext(h string) (traceId string, spanId string, sampled bool, ok bool) {
+	traceId = strings.ToLower(h)
+	var rest string
+	if slash := strings.Index(traceId, "/"); slash >= 0 {
+		traceId, rest = traceId[:slash], traceId[slash+1:]
+	}
+	if !validTraceId(traceId) {
+		return "", "", false, false
+	}
+
+	if semicolon := strings.Index(rest, ";"); semicolon >= 0 {
+		sampled = rest[semicolon+1:] == "o=1"
+		rest = rest[:semicolon]
+	}
+	if id, err := strconv.ParseUint(rest, 10, 64); err == nil && id != 0 {
+		spanId = fmt.Sprintf("%016x", id)
+	}
+
+	return traceId, spanId, sampled, true
+}
+
+// parseTraceparent parses W3C "traceparent" ("00-TRACE_ID-SPAN_ID-FLAGS")
+func parseTraceparent(h string) (traceId string, spanId string, sampled bool, ok bool) {
+	parts := strings.Split(strings.ToLower(h), "-")
+	if len(parts) < 4 || parts[0] == "ff" || !validTraceId(parts[1]) || !validSpanId(parts[2]) || len(parts[3]) != 2 {
+		return "", "", false, false
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return "", "", false, false
+	}
+
+	return parts[1], parts[2], flags[0]&1 == 1, true
+}
@@ -0,0 +1,154 @@
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+type retryableError struct{}
+
+func (retryableError) Error() string      { return "retry later" }
+func (retryableError) Severity() Severity { return SeverityWarning }
+
+func TestMessageLogging(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		severity string
+		message  string
+	}{
+		{"success", nil, "INFO", "message handled: worker"},
+		{"error", errors.New("failed"), "ERROR", "message failed: worker: failed"},
+		{"error with severity", retryableError{}, "WARNING", "message failed: worker: retry later"},
+		{"wrapped error with severity", fmt.Errorf("publish: %w", retryableError{}), "WARNING", "message failed: worker: publish: retry later"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestLogOut := new(bytes.Buffer)
+			contextLogOut := new(bytes.Buffer)
+
+			config := NewConfig("test")
+			config.RequestLogOut = requestLogOut
+			config.ContextLogOut = contextLogOut
+
+			handler := MessageLogging(config, "worker", func(ctx context.Context, msg interface{}) error {
+				ctx.Value(ContextLoggerKey).(*ContextLogger).Infof("got %v", msg)
+				return tt.err
+			})
+			if err := handler(context.Background(), "hello"); err != tt.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			var cLog consumerLog
+			if err := json.Unmarshal(requestLogOut.Bytes(), &cLog); err != nil {
+				t.Fatal(err)
+			}
+			if cLog.Severity != tt.severity || cLog.Message != tt.message || cLog.Consumer.Name != "worker" {
+				t.Errorf("unexpected log: %s", requestLogOut.String())
+			}
+
+			var ctxLog contextLog
+			if err := json.Unmarshal(contextLogOut.Bytes(), &ctxLog); err != nil {
+				t.Fatal(err)
+			}
+			if ctxLog.Message != "got hello" || ctxLog.Trace != cLog.Trace {
+				t.Errorf("unexpected context log: %s", contextLogOut.String())
+			}
+		})
+	}
+}
+
+// pubsubMessage has the same fields as pubsub.Message for the tests
+type pubsubMessage struct {
+	Data       []byte
+	Attributes map[string]string
+}
+
+func TestMessageLoggingTraceAttributes(t *testing.T) {
+	exporter := &testExporter{}
+	trace.RegisterExporter(exporter)
+	defer trace.UnregisterExporter(exporter)
+
+	producer := newContextLogger(NewConfig("test"), "", "105445aa7843bc8bf206b12000100000")
+	producer.spanId = "0000000000000001"
+	producer.traceSampled = true
+	attrs := TraceAttributes(ContextWithLogger(context.Background(), producer))
+
+	for _, msg := range []interface{}{
+		attrs,
+		pubsubMessage{Attributes: attrs},
+		&pubsubMessage{Attributes: map[string]string{"X-Cloud-Trace-Context": attrs["X-Cloud-Trace-Context"]}},
+	} {
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+
+		handler := MessageLogging(config, "worker", func(ctx context.Context, msg interface{}) error {
+			return nil
+		})
+		if err := handler(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+
+		var cLog consumerLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		if cLog.Trace != "projects/test/traces/105445aa7843bc8bf206b12000100000" || !cLog.TraceSampled {
+			t.Errorf("%T: the trace of the producer must be continued: %s", msg, requestLogOut.String())
+		}
+	}
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if len(exporter.spans) != 3 || exporter.spans[0].Name != "worker" {
+		t.Errorf("the spans of the messages must be ended: %d", len(exporter.spans))
+	}
+}
+
+func TestMessageLoggingConsole(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewDevelopmentConfig()
+	config.RequestLogOut = out
+	config.ContextLogOut = out
+
+	handler := MessageLogging(config, "worker", func(ctx context.Context, msg interface{}) error {
+		return nil
+	})
+	_ = handler(context.Background(), "hello")
+
+	if got := out.String(); !strings.Contains(got, " message handled: worker ") || strings.HasPrefix(got, "{") {
+		t.Errorf("unexpected log: %q", got)
+	}
+}
+
+func TestParseCloudTraceContext(t *testing.T) {
+	tests := []struct {
+		header  string
+		traceId string
+		spanId  string
+		sampled bool
+		ok      bool
+	}{
+		{header: "105445aa7843bc8bf206b12000100000/255;o=0", traceId: "105445aa7843bc8bf206b12000100000", spanId: "00000000000000ff", ok: true},
+		{header: "105445aa7843bc8bf206b12000100000", traceId: "105445aa7843bc8bf206b12000100000", ok: true},
+		{header: "invalid/1;o=1"},
+		{header: ""},
+	}
+
+	for _, tt := range tests {
+		traceId, spanId, sampled, ok := parseCloudTraceContext(tt.header)
+		if traceId != tt.traceId || spanId != tt.spanId || sampled != tt.sampled || ok != tt.ok {
+			t.Errorf("%q: unexpected result: %s, %s, %v, %v", tt.header, traceId, spanId, sampled, ok)
+		}
+	}
+}
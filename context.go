@@ -1,5 +1,27 @@
 package stalog
 
+import (
+	"context"
+)
+
 type contextKey struct{}
 
+// ContextLoggerKey is the key of the request-context logger in context.Context.
+// Use ContextWithLogger and LoggerFromContext instead of the key to interoperate with other middlewares.
 var ContextLoggerKey = &contextKey{}
+
+// ContextWithLogger returns a copy of ctx which carries the request-context logger,
+// e.g. for the middlewares which replace the context of the request after stalog runs,
+// or for the frameworks which don't pass the context of the request to the handlers
+//
+//	ctx := stalog.ContextWithLogger(otherCtx, stalog.RequestContextLogger(r))
+//	next.ServeHTTP(w, r.WithContext(ctx))
+func ContextWithLogger(ctx context.Context, l *ContextLogger) context.Context {
+	return context.WithValue(ctx, ContextLoggerKey, l)
+}
+
+// LoggerFromContext returns the request-context logger in ctx, or nil if ctx doesn't have it
+func LoggerFromContext(ctx context.Context) *ContextLogger {
+	l, _ := ctx.Value(ContextLoggerKey).(*ContextLogger)
+	return l
+}
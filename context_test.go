@@ -0,0 +1,43 @@
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextWithLogger(t *testing.T) {
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = new(bytes.Buffer)
+
+	var original *ContextLogger
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		original = RequestContextLogger(r)
+
+		// a middleware which replaces the context of the request
+		replaced := r.WithContext(context.Background())
+		if l := RequestContextLogger(replaced); l != nil {
+			t.Errorf("unexpected logger: %v", l)
+		}
+
+		restored := replaced.WithContext(ContextWithLogger(replaced.Context(), original))
+		if l := RequestContextLogger(restored); l != original {
+			t.Errorf("got %v, want %v", l, original)
+		}
+		if l := LoggerFromContext(restored.Context()); l != original {
+			t.Errorf("got %v, want %v", l, original)
+		}
+	}))
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if original == nil {
+		t.Fatal("no logger")
+	}
+	if l := LoggerFromContext(context.Background()); l != nil {
+		t.Errorf("unexpected logger: %v", l)
+	}
+}
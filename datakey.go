@@ -0,0 +1,132 @@
+package stalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// reservedKeys are the top-level fields of the logs which DataKey and PromoteFields can't use
+var reservedKeys = map[string]bool{
+	"time":           true,
+	"severity":       true,
+	"message":        true,
+	"httpRequest":    true,
+	"serviceContext": true,
+	"stack_trace":    true,
+	"@type":          true,
+	"goroutineId":    true,
+	"workerId":       true,
+	"job":            true,
+	"consumer":       true,
+	"seq":            true,
+}
+
+// validateDataLayout checks that DataKey and PromoteFields don't collide with the fields of the logs
+func (c *Config) validateDataLayout() error {
+	for _, key := range append([]string{c.DataKey}, c.PromoteFields...) {
+		if reservedKeys[key] || strings.HasPrefix(key, "logging.googleapis.com/") {
+			return fmt.Errorf("stalog: %q collides with the field of the logs", key)
+		}
+	}
+	for _, key := range c.PromoteFields {
+		if key == c.dataKey() {
+			return fmt.Errorf("stalog: promoted field %q collides with DataKey", key)
+		}
+	}
+
+	return nil
+}
+
+// customDataLayout reports whether AdditionalData is written in other than "data"
+func (c *Config) customDataLayout() bool {
+	return (c.DataKey != "" && c.DataKey != "data") || len(c.PromoteFields) > 0
+}
+
+// marshalLog encodes the log whose AdditionalData is data, writing data in DataKey and the promoted fields
+// at the top level
+func (c *Config) marshalLog(log interface{}, data *AdditionalData) ([]byte, error) {
+	b, err := c.marshalWithDataLayout(log, data)
+	if err == nil && c.StableOrder {
+		b, err = stableOrder(b, c.dataKey())
+	}
+	if err == nil {
+		c.validateStrict(b)
+	}
+
+	return b, err
+}
+
+func (c *Config) marshalWithDataLayout(log interface{}, data *AdditionalData) ([]byte, error) {
+	if data == nil || !c.customDataLayout() {
+		return c.marshalReplacing(log, data)
+	}
+
+	// encode the log without data, and then append the fields of data
+	d := *data
+	*data = nil
+	b, err := c.marshalReplacing(log, data)
+	*data = d
+	if err != nil {
+		return nil, err
+	}
+
+	promoted, rest := c.splitData(d)
+	b = b[:len(b)-1]
+
+	if len(promoted) > 0 {
+		p, err := c.marshalReplacing(&promoted, &promoted)
+		if err != nil {
+			return nil, err
+		}
+		// append the fields without the braces
+		b = append(b, ',')
+		b = append(b, p[1:len(p)-1]...)
+	}
+
+	if len(rest) > 0 {
+		r, err := c.marshalReplacing(&rest, &rest)
+		if err != nil {
+			return nil, err
+		}
+		key, _ := json.Marshal(c.dataKey())
+		b = append(b, ',')
+		b = append(b, key...)
+		b = append(b, ':')
+		b = append(b, r...)
+	}
+
+	return append(b, '}'), nil
+}
+
+// dataKey returns DataKey or "data"
+func (c *Config) dataKey() string {
+	if c.DataKey == "" {
+		return "data"
+	}
+
+	return c.DataKey
+}
+
+// splitData splits data into the promoted fields and the rest
+func (c *Config) splitData(data AdditionalData) (promoted AdditionalData, rest AdditionalData) {
+	if len(c.PromoteFields) == 0 {
+		return nil, data
+	}
+
+	rest = make(AdditionalData, len(data))
+	for k, v := range data {
+		rest[k] = v
+	}
+	for _, k := range c.PromoteFields {
+		if v, ok := rest[k]; ok {
+			if promoted == nil {
+				promoted = AdditionalData{}
+			}
+			promoted[k] = v
+			delete(rest, k)
+		}
+	}
+
+	return promoted, rest
+}
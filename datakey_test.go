@@ -0,0 +1,60 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDataLayout(t *testing.T) {
+	contextLogOut := new(bytes.Buffer)
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = contextLogOut
+	config.RequestLogOut = requestLogOut
+	config.AdditionalData = AdditionalData{"service": "api", "version": "1"}
+	config.DataKey = "payload"
+	config.PromoteFields = []string{"service", "userId"}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).WithFields(String("userId", "u1"), String("plan", "free")).Info("hello")
+	}))
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var contextLog map[string]interface{}
+	if err := json.Unmarshal(contextLogOut.Bytes(), &contextLog); err != nil {
+		t.Fatalf("%v: %s", err, contextLogOut.String())
+	}
+	if contextLog["service"] != "api" || contextLog["userId"] != "u1" || contextLog["message"] != "hello" || contextLog["data"] != nil {
+		t.Errorf("got %v", contextLog)
+	}
+	if payload, _ := contextLog["payload"].(map[string]interface{}); len(payload) != 2 || payload["plan"] != "free" || payload["version"] != "1" {
+		t.Errorf("got %v", contextLog["payload"])
+	}
+
+	var requestLog map[string]interface{}
+	if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+		t.Fatalf("%v: %s", err, requestLogOut.String())
+	}
+	if requestLog["service"] != "api" || requestLog["httpRequest"] == nil {
+		t.Errorf("got %v", requestLog)
+	}
+	if payload, _ := requestLog["payload"].(map[string]interface{}); payload["version"] != "1" {
+		t.Errorf("got %v", requestLog["payload"])
+	}
+
+	for _, c := range []func(c *Config){
+		func(c *Config) { c.DataKey = "message" },
+		func(c *Config) { c.PromoteFields = []string{"logging.googleapis.com/trace"} },
+		func(c *Config) { c.DataKey = "payload"; c.PromoteFields = []string{"payload"} },
+	} {
+		config := NewConfig("test")
+		c(config)
+		if err := config.Validate(); err == nil {
+			t.Errorf("no error: %+v", config)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package stalog
+
+// keepDebugTail keeps the log in the ring buffer of DebugTailSize
+func (l *ContextLogger) keepDebugTail(log *contextLog) {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	if len(l.state.debugTail) < l.config.DebugTailSize {
+		l.state.debugTail = append(l.state.debugTail, log)
+		return
+	}
+
+	l.state.debugTail[l.state.debugTailNext] = log
+	l.state.debugTailNext = (l.state.debugTailNext + 1) % len(l.state.debugTail)
+}
+
+// flushDebugTail writes the kept DEBUG logs in order and clears the ring buffer
+func (l *ContextLogger) flushDebugTail() {
+	l.state.mu.Lock()
+	logs := make([]*contextLog, 0, len(l.state.debugTail))
+	logs = append(logs, l.state.debugTail[l.state.debugTailNext:]...)
+	logs = append(logs, l.state.debugTail[:l.state.debugTailNext]...)
+	l.state.debugTail = l.state.debugTail[:0]
+	l.state.debugTailNext = 0
+	l.state.mu.Unlock()
+
+	for _, log := range logs {
+		_ = l.output(log)
+	}
+}
@@ -0,0 +1,54 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDebugTail(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.DebugTailSize = 2
+	config.ContextLogOut = out
+	logger := newContextLogger(config, "", "")
+
+	logger.Debug("1")
+	logger.Debug("2")
+	logger.Debug("3")
+	logger.Info("4")
+	if strings.Contains(out.String(), "DEBUG") {
+		t.Fatalf("debug logs must not be written before an error: %s", out.String())
+	}
+
+	logger.Error("5")
+	logger.Error("6")
+
+	logs := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	expected := []struct {
+		Severity string
+		Message  string
+	}{
+		{"INFO", "4"},
+		{"DEBUG", "2"},
+		{"DEBUG", "3"},
+		{"ERROR", "5"},
+		{"ERROR", "6"},
+	}
+	if len(logs) != len(expected) {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+	for idx, log := range logs {
+		var cLog contextLog
+		if err := json.Unmarshal([]byte(log), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		if cLog.Severity != expected[idx].Severity || cLog.Message != expected[idx].Message {
+			t.Errorf("unexpected log: %s", log)
+		}
+		if cLog.SourceLocation == nil || cLog.SourceLocation.File != "debugtail_test.go" {
+			t.Errorf("unexpected source location: %+v", cLog.SourceLocation)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Decoder reads the logs written by this package in NDJSON format (one JSON per line), e.g. captured by tests
+// or collected from the containers
+type Decoder struct {
+	r      *bufio.Reader
+	line   int
+	config *Config
+}
+
+// NewDecoder creates Decoder which reads from r. AdditionalData is read from "data",
+// so use NewDecoderForConfig for the logs written with DataKey or PromoteFields.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// NewDecoderForConfig creates Decoder which reads from r the logs written with the config.
+// AdditionalData is read from DataKey and the promoted fields of the config.
+func NewDecoderForConfig(r io.Reader, config *Config) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), config: config}
+}
+
+// decodedLog has the fields of both the context logs and the request logs
+type decodedLog struct {
+	Time           string            `json:"time"`
+	Trace          string            `json:"logging.googleapis.com/trace"`
+	SpanID         string            `json:"logging.googleapis.com/spanId"`
+	SourceLocation *SourceLocation   `json:"logging.googleapis.com/sourceLocation"`
+	Severity       string            `json:"severity"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels"`
+	Message        *string           `json:"message"`
+	HTTPRequest    *HTTPRequest      `json:"httpRequest"`
+	Seq            int64             `json:"seq"`
+	AdditionalData AdditionalData    `json:"data"`
+}
+
+// Decode reads the next log. Empty lines are skipped, and io.EOF is returned at the end of the input.
+// The error for a line which isn't a log has the line number, and Decode can be called again to read the next line.
+func (d *Decoder) Decode() (*Entry, error) {
+	for {
+		line, err := d.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+		d.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var log decodedLog
+		if err := json.Unmarshal(line, &log); err != nil {
+			return nil, fmt.Errorf("stalog: line %d: %w", d.line, err)
+		}
+		if d.config != nil && d.config.customDataLayout() {
+			data, err := d.config.unmarshalData(line)
+			if err != nil {
+				return nil, fmt.Errorf("stalog: line %d: %w", d.line, err)
+			}
+			log.AdditionalData = data
+		}
+
+		return log.entry(), nil
+	}
+}
+
+// entry converts the log to Entry. The logs without the message are the request logs.
+func (log *decodedLog) entry() *Entry {
+	severity, _ := ParseSeverity(log.Severity)
+	t, _ := time.Parse(time.RFC3339Nano, log.Time)
+
+	entry := &Entry{
+		Time:           t,
+		Severity:       severity,
+		Trace:          log.Trace,
+		SpanID:         log.SpanID,
+		SourceLocation: log.SourceLocation,
+		Labels:         log.Labels,
+		Data:           log.AdditionalData,
+		HTTPRequest:    log.HTTPRequest,
+		Seq:            log.Seq,
+		RequestLog:     log.Message == nil && log.HTTPRequest != nil,
+	}
+	if log.Message != nil {
+		entry.Message = *log.Message
+	}
+
+	return entry
+}
+
+// unmarshalData reads AdditionalData of the log from DataKey and the promoted fields
+func (c *Config) unmarshalData(line []byte) (AdditionalData, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, err
+	}
+
+	var data AdditionalData
+	if raw, ok := fields[c.dataKey()]; ok {
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range c.PromoteFields {
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		if data == nil {
+			data = AdditionalData{}
+		}
+		data[key] = v
+	}
+
+	return data, nil
+}
+
+// Decode reads all logs from r. It stops at the first line which isn't a log,
+// and returns the logs read until the line with the error. AdditionalData is read from "data" like NewDecoder.
+func Decode(r io.Reader) ([]Entry, error) {
+	d := NewDecoder(r)
+
+	var entries []Entry
+	for {
+		entry, err := d.Decode()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, *entry)
+	}
+}
@@ -0,0 +1,95 @@
+package stalog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	out := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.RequestLogOut = out
+	config.Labels = map[string]string{"app": "api"}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).WithFields(String("user", "alice")).Warning("hello")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	entries, err := Decode(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+
+	contextLog, requestLog := entries[0], entries[1]
+	if contextLog.RequestLog || contextLog.Severity != SeverityWarning || contextLog.Message != "hello" ||
+		contextLog.Trace != "projects/test/traces/105445aa7843bc8bf206b12000100000" ||
+		contextLog.Labels["app"] != "api" || contextLog.Data["user"] != "alice" ||
+		contextLog.SourceLocation == nil || contextLog.Time.IsZero() {
+		t.Errorf("unexpected context log: %+v", contextLog)
+	}
+	if !requestLog.RequestLog || requestLog.Severity != SeverityWarning || requestLog.HTTPRequest == nil ||
+		requestLog.HTTPRequest.Status != http.StatusCreated || requestLog.HTTPRequest.RequestUrl != "/foo" {
+		t.Errorf("unexpected request log: %+v", requestLog)
+	}
+}
+
+func TestDecoderInvalidLine(t *testing.T) {
+	d := NewDecoder(strings.NewReader("{\"severity\":\"INFO\",\"message\":\"a\"}\n\npanic: oops\n{\"severity\":\"ERROR\",\"message\":\"b\"}"))
+
+	if entry, err := d.Decode(); err != nil || entry.Message != "a" {
+		t.Fatalf("got %+v, %v", entry, err)
+	}
+	if _, err := d.Decode(); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("got %v", err)
+	}
+	if entry, err := d.Decode(); err != nil || entry.Message != "b" || entry.Severity != SeverityError {
+		t.Fatalf("got %+v, %v", entry, err)
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestDecoderForConfig(t *testing.T) {
+	out := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.RequestLogOut = new(bytes.Buffer)
+	config.DataKey = "payload"
+	config.PromoteFields = []string{"user"}
+
+	logger := newContextLogger(config, "", "")
+	logger.WithFields(String("user", "alice"), Int("count", 3)).Info("hello")
+	line := out.String()
+
+	entry, err := NewDecoderForConfig(strings.NewReader(line), config).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Message != "hello" || entry.Data["user"] != "alice" || entry.Data["count"] != float64(3) {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	// NewDecoder reads only "data"
+	entry, err = NewDecoder(strings.NewReader(line)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Message != "hello" || entry.Data != nil {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
@@ -0,0 +1,36 @@
+package stalog
+
+import (
+	"sync/atomic"
+)
+
+var defaultLogger atomic.Value
+
+func init() {
+	defaultLogger.Store(newDefaultLogger(NewConfig("")))
+}
+
+// SetDefault replaces the default logger with a logger created from the config
+func SetDefault(config *Config) {
+	defaultLogger.Store(newDefaultLogger(config))
+}
+
+// Default returns the default logger which is not bound to any request.
+// Use it for logs at init time or in the main goroutine, so that they have the same structured format.
+func Default() *ContextLogger {
+	return defaultLogger.Load().(*ContextLogger)
+}
+
+// L is the shorthand for Default
+func L() *ContextLogger {
+	return Default()
+}
+
+func newDefaultLogger(config *Config) *ContextLogger {
+	// the limit per request doesn't make sense for the process-wide logger
+	c := *config
+	c.MaxEntriesPerRequest = 0
+	c.DebugTailSize = 0
+
+	return newContextLogger(&c, "", "")
+}
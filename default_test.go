@@ -0,0 +1,41 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	original := Default()
+	defer defaultLogger.Store(original)
+
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.MaxEntriesPerRequest = 1
+	SetDefault(config)
+
+	L().Infof("1")
+	Default().Warnf("2")
+
+	var logs []contextLog
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		var cLog contextLog
+		if err := decoder.Decode(&cLog); err != nil {
+			t.Fatal(err)
+		}
+		logs = append(logs, cLog)
+	}
+
+	if len(logs) != 2 {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+	if logs[0].Trace != "" || logs[1].Severity != "WARNING" {
+		t.Errorf("unexpected logs: %+v", logs)
+	}
+	if logs[0].SourceLocation == nil || logs[0].SourceLocation.File != "default_test.go" {
+		t.Errorf("unexpected source location: %+v", logs[0].SourceLocation)
+	}
+}
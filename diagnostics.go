@@ -0,0 +1,109 @@
+package stalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// writeErrors records the errors of encoding and writing the logs in the process for DiagnosticsHandler
+var writeErrors struct {
+	count int64 // accessed atomically
+	mu    sync.Mutex
+	last  string
+	at    time.Time
+}
+
+// recordError records the error as the last error
+func recordError(err error) {
+	atomic.AddInt64(&writeErrors.count, 1)
+
+	writeErrors.mu.Lock()
+	defer writeErrors.mu.Unlock()
+	writeErrors.last = err.Error()
+	writeErrors.at = time.Now()
+}
+
+// diagnostics is the state of the logging pipeline written by DiagnosticsHandler
+type diagnostics struct {
+	Severity           string                       `json:"severity"`
+	SamplingBySeverity map[string]float64           `json:"samplingBySeverity,omitempty"`
+	RateLimit          float64                      `json:"rateLimit,omitempty"`
+	RateLimitBurst     int                          `json:"rateLimitBurst,omitempty"`
+	Dropped            map[string]int64             `json:"dropped"`
+	Writers            map[string]writerDiagnostics `json:"writers"`
+	Errors             int64                        `json:"errors"`
+	LastError          string                       `json:"lastError,omitempty"`
+	LastErrorTime      string                       `json:"lastErrorTime,omitempty"`
+}
+
+// writerDiagnostics is the state of an output
+type writerDiagnostics struct {
+	Type          string `json:"type"`
+	QueueDepth    *int   `json:"queueDepth,omitempty"`
+	QueueCapacity *int   `json:"queueCapacity,omitempty"`
+	Closed        bool   `json:"closed,omitempty"`
+}
+
+// DiagnosticsHandler returns the handler which writes the state of the logging pipeline in JSON
+// for operational debugging: the severity and the sampling rates of the config, the counts of the dropped logs
+// and the errors of writing since the process started, and the types and the queue depths of the outputs.
+// Mount it on an internal port or protect it, e.g. http.Handle("/debug/stalog", stalog.DiagnosticsHandler(config)).
+func DiagnosticsHandler(config *Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(newDiagnostics(config))
+	})
+}
+
+func newDiagnostics(config *Config) *diagnostics {
+	d := &diagnostics{
+		Severity:       config.severity().String(),
+		RateLimit:      config.RateLimit,
+		RateLimitBurst: config.RateLimitBurst,
+		Dropped:        make(map[string]int64, numDropReasons),
+		Writers: map[string]writerDiagnostics{
+			"contextLogOut": newWriterDiagnostics(config.ContextLogOut),
+			"requestLogOut": newWriterDiagnostics(config.requestLogOut()),
+		},
+		Errors: atomic.LoadInt64(&writeErrors.count),
+	}
+
+	if sampling := config.samplingBySeverity(); len(sampling) > 0 {
+		d.SamplingBySeverity = make(map[string]float64, len(sampling))
+		for severity, rate := range sampling {
+			d.SamplingBySeverity[severity.String()] = rate
+		}
+	}
+
+	for reason := dropReason(0); reason < numDropReasons; reason++ {
+		d.Dropped[dropReasonNames[reason]] = atomic.LoadInt64(&droppedTotal[reason])
+	}
+
+	writeErrors.mu.Lock()
+	if writeErrors.last != "" {
+		d.LastError = writeErrors.last
+		d.LastErrorTime = writeErrors.at.Format(time.RFC3339Nano)
+	}
+	writeErrors.mu.Unlock()
+
+	return d
+}
+
+func newWriterDiagnostics(out io.Writer) writerDiagnostics {
+	d := writerDiagnostics{Type: fmt.Sprintf("%T", out)}
+	if w, ok := out.(*AsyncWriter); ok {
+		depth, capacity := w.QueueDepth(), w.size
+		d.QueueDepth, d.QueueCapacity = &depth, &capacity
+
+		w.mu.Lock()
+		d.Closed = w.closed
+		w.mu.Unlock()
+	}
+
+	return d
+}
@@ -0,0 +1,87 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiagnosticsHandler(t *testing.T) {
+	async := NewAsyncWriter(new(bytes.Buffer), 8)
+	defer async.Close()
+
+	config := NewConfig("test")
+	config.ContextLogOut = async
+	config.RequestLogOut = new(bytes.Buffer)
+	config.Severity = SeverityWarning
+	config.SamplingBySeverity = map[Severity]float64{SeverityDebug: 0.1}
+	config.OnError = func(err error) {}
+
+	countDropped(dropRateLimit)
+	config.reportError(errors.New("broken pipe"))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/debug/stalog", nil)
+	DiagnosticsHandler(config).ServeHTTP(w, r)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got %s", ct)
+	}
+
+	var d diagnostics
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Severity != "WARNING" || d.SamplingBySeverity["DEBUG"] != 0.1 || d.Dropped["rateLimit"] < 1 ||
+		d.Errors < 1 || d.LastError != "broken pipe" || d.LastErrorTime == "" {
+		t.Errorf("got %+v", d)
+	}
+
+	context := d.Writers["contextLogOut"]
+	if context.Type != "*stalog.AsyncWriter" || context.QueueCapacity == nil || *context.QueueCapacity != 8 || context.QueueDepth == nil {
+		t.Errorf("got %+v", context)
+	}
+	if request := d.Writers["requestLogOut"]; request.Type != "*bytes.Buffer" || request.QueueDepth != nil {
+		t.Errorf("got %+v", request)
+	}
+}
+
+type errorWriter struct{ err error }
+
+func (w errorWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestDiagnosticsContextLogWriteError(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatConsole} {
+		var reported error
+		config := NewConfig("test")
+		config.ContextLogOut = errorWriter{err: errors.New("disk full")}
+		config.RequestLogOut = new(bytes.Buffer)
+		config.Format = format
+		config.OnError = func(err error) { reported = err }
+
+		before := atomic.LoadInt64(&writeErrors.count)
+		logger := newContextLogger(config, "", "")
+		logger.Info("hello")
+		if reported == nil || atomic.LoadInt64(&writeErrors.count) != before+1 {
+			t.Errorf("format %v: the write error isn't reported: %v", format, reported)
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/debug/stalog", nil)
+		DiagnosticsHandler(config).ServeHTTP(w, r)
+
+		var d diagnostics
+		if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+			t.Fatal(err)
+		}
+		if d.LastError != reported.Error() {
+			t.Errorf("format %v: got %q", format, d.LastError)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package stalog
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// dropReason is the reason why a log is dropped
+type dropReason int
+
+const (
+	dropSampling dropReason = iota
+	dropRateLimit
+	dropMaxEntriesPerRequest
+	dropQueueFull
+	dropByteBudget
+	numDropReasons
+)
+
+var dropReasonNames = [numDropReasons]string{
+	dropSampling:             "sampling",
+	dropRateLimit:            "rateLimit",
+	dropMaxEntriesPerRequest: "maxEntriesPerRequest",
+	dropQueueFull:            "queueFull",
+	dropByteBudget:           "byteBudget",
+}
+
+// droppedEntries counts the dropped logs in the process by the reason since the last report
+var droppedEntries [numDropReasons]int64
+
+// droppedTotal counts the dropped logs in the process by the reason since the start
+var droppedTotal [numDropReasons]int64
+
+// countDropped counts the dropped log
+func countDropped(reason dropReason) {
+	atomic.AddInt64(&droppedEntries[reason], 1)
+	atomic.AddInt64(&droppedTotal[reason], 1)
+}
+
+// DropReporter periodically logs "N entries dropped since last report" at NOTICE
+// with the counts by the reason (sampling, rate limiting, the byte budget and the overflow of AsyncWriter),
+// so that silent data loss is observable. Nothing is logged if no log is dropped.
+type DropReporter struct {
+	logger *ContextLogger
+	ticks  *periodic
+}
+
+// NewDropReporter creates DropReporter which logs to ContextLogOut of the config at every interval
+func NewDropReporter(config *Config, interval time.Duration) *DropReporter {
+	r := &DropReporter{
+		logger: newDefaultLogger(config).WithFields(String("logger", "stalog.dropped")),
+	}
+	r.ticks = startPeriodic(interval, r.report, r.report)
+
+	return r
+}
+
+// Close logs the logs dropped since the last report and stops reporting
+func (r *DropReporter) Close() error {
+	r.ticks.close()
+	return nil
+}
+
+// report logs the counts of the dropped logs and resets them
+func (r *DropReporter) report() {
+	var total int64
+	counts := make(map[string]int64, numDropReasons)
+	for reason := dropReason(0); reason < numDropReasons; reason++ {
+		if n := atomic.SwapInt64(&droppedEntries[reason], 0); n > 0 {
+			counts[dropReasonNames[reason]] = n
+			total += n
+		}
+	}
+	if total == 0 {
+		return
+	}
+
+	logger := r.logger.WithFields(Any("dropped", counts))
+	_ = logger.output(logger.newLog(SeverityNotice, SourceLocation{}, fmt.Sprintf("%d entries dropped since last report", total)))
+}
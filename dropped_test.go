@@ -0,0 +1,55 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDropReporter(t *testing.T) {
+	for reason := dropReason(0); reason < numDropReasons; reason++ {
+		atomic.StoreInt64(&droppedEntries[reason], 0)
+	}
+
+	config := NewConfig("test")
+	config.ContextLogOut = new(bytes.Buffer)
+	config.Severity = SeverityDebug
+	config.SamplingBySeverity = map[Severity]float64{SeverityDebug: 0}
+	config.MaxEntriesPerRequest = 1
+
+	logger := newContextLogger(config, "", "")
+	logger.Debug("sampled out")
+	logger.Debug("sampled out")
+	logger.Info("written")
+	logger.Info("over the limit")
+
+	out := new(bytes.Buffer)
+	reporterConfig := NewConfig("test")
+	reporterConfig.ContextLogOut = out
+	reporter := NewDropReporter(reporterConfig, time.Hour)
+	if err := reporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var cLog contextLog
+	if err := json.Unmarshal(out.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "NOTICE" || cLog.Message != "3 entries dropped since last report" {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+	dropped := cLog.AdditionalData["dropped"].(map[string]interface{})
+	if dropped["sampling"] != float64(2) || dropped["maxEntriesPerRequest"] != float64(1) {
+		t.Errorf("unexpected counts: %+v", dropped)
+	}
+
+	// nothing is logged without dropped logs
+	out.Reset()
+	reporter = NewDropReporter(reporterConfig, time.Hour)
+	_ = reporter.Close()
+	if out.Len() != 0 {
+		t.Errorf("unexpected log: %s", out.String())
+	}
+}
@@ -0,0 +1,19 @@
+package stalog
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// EchoContextLoggerKey is the key of the request-context logger in the store of echo.Context
+const EchoContextLoggerKey = "stalog.logger"
+
+// EchoContextLogger returns the request-context logger from echo.Context set by RequestLoggingWithEcho,
+// for the handlers which have echo.Context but not the request.
+// It falls back to the logger in the context of the request, and returns nil if neither has the logger.
+func EchoContextLogger(c echo.Context) *ContextLogger {
+	if l, ok := c.Get(EchoContextLoggerKey).(*ContextLogger); ok {
+		return l
+	}
+
+	return RequestContextLogger(c.Request())
+}
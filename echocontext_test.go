@@ -0,0 +1,40 @@
+package stalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestEchoContextLogger(t *testing.T) {
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = contextLogOut
+
+	e := echo.New()
+	e.Use(RequestLoggingWithEcho(config))
+	e.GET("/foo", func(c echo.Context) error {
+		logger := EchoContextLogger(c)
+		if logger == nil || logger != RequestContextLogger(c.Request()) {
+			t.Errorf("unexpected logger: %v", logger)
+		}
+		logger.Info("hello")
+		return c.String(http.StatusOK, "ok")
+	})
+
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+	if contextLogOut.Len() == 0 {
+		t.Error("no context log")
+	}
+
+	// without the middleware
+	c := echo.New().NewContext(r, httptest.NewRecorder())
+	if logger := EchoContextLogger(c); logger != nil {
+		t.Errorf("unexpected logger: %v", logger)
+	}
+}
@@ -0,0 +1,85 @@
+package stalog
+
+import (
+	"encoding/json"
+
+	"github.com/labstack/echo/v4"
+)
+
+// echoLevels maps the levels of the Echo logger to Severity
+var echoLevels = map[string]Severity{
+	"DEBUG": SeverityDebug,
+	"INFO":  SeverityInfo,
+	"WARN":  SeverityWarning,
+	"ERROR": SeverityError,
+	"PANIC": SeverityCritical,
+	"FATAL": SeverityCritical,
+}
+
+// echoLogHeader is the header of the Echo logger parsed by echoLogWriter
+const echoLogHeader = `{"level":"${level}","file":"${short_file}","line":"${line}"}`
+
+// DisableFrameworkLogger turns off the banner and the startup message of Echo, and wires stalog in instead:
+// the request logs by RequestLoggingWithEcho, the logs of e.Logger as context logs at the mapped severities,
+// and the internal error logs of the server by HTTPServerErrorLog.
+// Don't use the access logger of Echo (middleware.Logger) with it, or the request logs are duplicated.
+//
+//	e := echo.New()
+//	stalog.DisableFrameworkLogger(e, config)
+func DisableFrameworkLogger(e *echo.Echo, config *Config) {
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.Logger.SetHeader(echoLogHeader)
+	e.Logger.SetOutput(&echoLogWriter{logger: newDefaultLogger(config).WithFields(String("logger", "echo"))})
+	e.StdLogger = HTTPServerErrorLog(config)
+
+	e.Use(RequestLoggingWithEcho(config))
+}
+
+// echoLogWriter writes the JSON logs of the Echo logger as context logs
+type echoLogWriter struct {
+	logger *ContextLogger
+}
+
+func (w *echoLogWriter) Write(p []byte) (int, error) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(p, &entry); err != nil {
+		// not the JSON header
+		if err := w.logger.write(SeverityDefault, string(p)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	level, _ := entry["level"].(string)
+	msg, _ := entry["message"].(string)
+	location := &SourceLocation{}
+	location.File, _ = entry["file"].(string)
+	location.Line, _ = entry["line"].(string)
+	for _, key := range []string{"level", "message", "file", "line"} {
+		delete(entry, key)
+	}
+
+	// the fields of the logs by Debugj, Infoj, ... are added to data
+	logger := w.logger
+	if len(entry) > 0 {
+		logger = logger.WithFields(fieldsOf(entry)...)
+	}
+
+	if err := logger.writeAt(echoLevels[level], location, msg); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// fieldsOf converts the map to fields
+func fieldsOf(m map[string]interface{}) []Field {
+	fields := make([]Field, 0, len(m))
+	for k, v := range m {
+		fields = append(fields, Any(k, v))
+	}
+
+	return fields
+}
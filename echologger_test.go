@@ -0,0 +1,64 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDisableFrameworkLogger(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+
+	e := echo.New()
+	DisableFrameworkLogger(e, config)
+	e.GET("/foo", func(c echo.Context) error {
+		c.Logger().Errorj(map[string]interface{}{"user": "alice"})
+		c.Logger().Error("boom")
+		return c.String(http.StatusOK, "ok")
+	})
+
+	if !e.HideBanner || !e.HidePort {
+		t.Error("the banner and the port should be hidden")
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	e.ServeHTTP(w, r)
+
+	if n := strings.Count(requestLogOut.String(), "\n"); n != 1 {
+		t.Errorf("unexpected request logs: %d", n)
+	}
+
+	lines := strings.Split(strings.TrimSpace(contextLogOut.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected context logs: %s", contextLogOut.String())
+	}
+
+	var cLog contextLog
+	if err := json.Unmarshal([]byte(lines[0]), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "ERROR" || cLog.AdditionalData["user"] != "alice" || cLog.AdditionalData["logger"] != "echo" {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+
+	cLog = contextLog{}
+	if err := json.Unmarshal([]byte(lines[1]), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "ERROR" || cLog.Message != "boom" {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+	if cLog.SourceLocation == nil || cLog.SourceLocation.File != "echologger_test.go" {
+		t.Errorf("unexpected source location: %+v", cLog.SourceLocation)
+	}
+}
@@ -0,0 +1,176 @@
+package stalog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Enrich is the bitmask of the fields which are automatically added to all logs as labels
+type Enrich int
+
+const (
+	// EnrichHostname adds the hostname as "hostname" label
+	EnrichHostname Enrich = 1 << iota
+	// EnrichZone adds the GCP zone from the metadata server as "zone" label
+	EnrichZone
+	// EnrichRegion adds the GCP region from the metadata server as "region" label
+	EnrichRegion
+	// EnrichInstanceID adds the instance ID from the metadata server as "instance_id" label
+	EnrichInstanceID
+	// EnrichBuildInfo adds the main module version and the VCS revision and commit time embedded by go build
+	// as "version", "vcs_revision" and "vcs_time" labels
+	EnrichBuildInfo
+
+	EnrichAll = EnrichHostname | EnrichZone | EnrichRegion | EnrichInstanceID | EnrichBuildInfo
+)
+
+// enrichLabelNames maps the label names to the bits of Enrich
+var enrichLabelNames = map[string]Enrich{
+	"hostname":     EnrichHostname,
+	"zone":         EnrichZone,
+	"region":       EnrichRegion,
+	"instance_id":  EnrichInstanceID,
+	"version":      EnrichBuildInfo,
+	"vcs_revision": EnrichBuildInfo,
+	"vcs_time":     EnrichBuildInfo,
+}
+
+// metadataTimeout is the timeout for each request to the metadata server
+const metadataTimeout = 500 * time.Millisecond
+
+// metadataEnrich is the bits of Enrich which need the metadata server
+const metadataEnrich = EnrichZone | EnrichRegion | EnrichInstanceID
+
+var enrichment struct {
+	localOnce    sync.Once
+	metadataOnce sync.Once
+	local        map[string]string
+	values       atomic.Value // *enrichedValues
+}
+
+// enrichedValues is the values fetched so far with the labels filtered by each bitmask.
+// It is replaced when the metadata arrives, so the cached labels never miss them.
+type enrichedValues struct {
+	values map[string]string
+	byMask sync.Map // Enrich -> map[string]string
+}
+
+// enrichLabels returns the labels specified by the bitmask.
+// The values are fetched only once per process, and unavailable values (e.g. outside GCP) are omitted.
+// The values from the metadata server are fetched in the background only if the bitmask needs them,
+// not to block the first logs, so the logs until they arrive don't have them.
+// The returned map is shared by the logs with the same bitmask and must not be modified.
+func enrichLabels(mask Enrich) map[string]string {
+	if mask == 0 {
+		return nil
+	}
+
+	enrichment.localOnce.Do(func() {
+		enrichment.local = localEnrichment()
+		enrichment.values.Store(&enrichedValues{values: enrichment.local})
+	})
+	if mask&metadataEnrich != 0 {
+		enrichment.metadataOnce.Do(func() {
+			go func() {
+				values := metadataEnrichment()
+				for name, value := range enrichment.local {
+					values[name] = value
+				}
+				enrichment.values.Store(&enrichedValues{values: values})
+			}()
+		})
+	}
+
+	enriched := enrichment.values.Load().(*enrichedValues)
+	if labels, ok := enriched.byMask.Load(mask); ok {
+		return labels.(map[string]string)
+	}
+
+	labels := make(map[string]string, len(enriched.values))
+	for name, value := range enriched.values {
+		if mask&enrichLabelNames[name] != 0 && value != "" {
+			labels[name] = value
+		}
+	}
+	enriched.byMask.Store(mask, labels)
+
+	return labels
+}
+
+// localEnrichment returns the values which are available without the network
+func localEnrichment() map[string]string {
+	values := map[string]string{}
+
+	if hostname, err := os.Hostname(); err == nil {
+		values["hostname"] = hostname
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		addBuildInfo(values, bi)
+	}
+
+	return values
+}
+
+// metadataEnrichment returns the values from the metadata server
+func metadataEnrichment() map[string]string {
+	values := map[string]string{}
+
+	// "projects/123456789/zones/us-central1-a"
+	if zone := getMetadata("instance/zone"); zone != "" {
+		values["zone"] = zone[strings.LastIndex(zone, "/")+1:]
+		if i := strings.LastIndex(values["zone"], "-"); i > 0 {
+			values["region"] = values["zone"][:i]
+		}
+	}
+
+	// "projects/123456789/regions/us-central1" (serverless environments)
+	if values["region"] == "" {
+		if region := getMetadata("instance/region"); region != "" {
+			values["region"] = region[strings.LastIndex(region, "/")+1:]
+		}
+	}
+
+	values["instance_id"] = getMetadata("instance/id")
+
+	return values
+}
+
+// getMetadata gets the value from the metadata server and returns empty string on any error.
+// The host can be overwritten by GCE_METADATA_HOST like Google Cloud client libraries.
+func getMetadata(path string) string {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+
+	req, err := http.NewRequest("GET", "http://"+host+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: metadataTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
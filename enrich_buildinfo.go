@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package stalog
+
+import "runtime/debug"
+
+// addBuildInfo adds the main module version and the VCS revision and commit time (Go 1.18+) to the values
+func addBuildInfo(values map[string]string, bi *debug.BuildInfo) {
+	if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		values["version"] = bi.Main.Version
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			values["vcs_revision"] = setting.Value
+		case "vcs.time":
+			values["vcs_time"] = setting.Value
+		}
+	}
+}
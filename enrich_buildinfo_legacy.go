@@ -0,0 +1,13 @@
+//go:build !go1.18
+// +build !go1.18
+
+package stalog
+
+import "runtime/debug"
+
+// addBuildInfo adds the main module version to the values. The VCS information isn't embedded before Go 1.18.
+func addBuildInfo(values map[string]string, bi *debug.BuildInfo) {
+	if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		values["version"] = bi.Main.Version
+	}
+}
@@ -0,0 +1,44 @@
+//go:build go1.18
+// +build go1.18
+
+package stalog
+
+import (
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestAddBuildInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		bi   *debug.BuildInfo
+		want map[string]string
+	}{
+		{
+			"release",
+			&debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+				Settings: []debug.BuildSetting{
+					{Key: "vcs", Value: "git"},
+					{Key: "vcs.revision", Value: "0123456789abcdef"},
+					{Key: "vcs.time", Value: "2022-03-01T00:00:00Z"},
+				},
+			},
+			map[string]string{"version": "v1.2.3", "vcs_revision": "0123456789abcdef", "vcs_time": "2022-03-01T00:00:00Z"},
+		},
+		{
+			"devel",
+			&debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "(devel)"}},
+			map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		values := map[string]string{}
+		addBuildInfo(values, tt.bi)
+		if !reflect.DeepEqual(values, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, values, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package stalog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadEnrichment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/zone":
+			_, _ = fmt.Fprint(w, "projects/123456789/zones/asia-northeast1-b")
+		case "/computeMetadata/v1/instance/id":
+			_, _ = fmt.Fprint(w, "1234567890")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	_ = os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	defer os.Unsetenv("GCE_METADATA_HOST")
+
+	values := metadataEnrichment()
+	for name, value := range localEnrichment() {
+		values[name] = value
+	}
+	expected := map[string]string{
+		"zone":        "asia-northeast1-b",
+		"region":      "asia-northeast1",
+		"instance_id": "1234567890",
+	}
+	for name, v := range expected {
+		if values[name] != v {
+			t.Errorf("unexpected value for %s: %s", name, values[name])
+		}
+	}
+	if values["hostname"] == "" {
+		t.Error("hostname is empty")
+	}
+}
+
+func TestEnrichLabelsInBackground(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.URL.Path == "/computeMetadata/v1/instance/id" {
+			_, _ = fmt.Fprint(w, "1234567890")
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_ = os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	defer os.Unsetenv("GCE_METADATA_HOST")
+
+	resetEnrichment()
+	defer resetEnrichment()
+
+	// the metadata server doesn't block the first log
+	labels := enrichLabels(EnrichHostname | EnrichInstanceID)
+	if labels["hostname"] == "" || labels["instance_id"] != "" {
+		t.Errorf("unexpected labels before the metadata: %v", labels)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for enrichLabels(EnrichInstanceID)["instance_id"] != "1234567890" {
+		if time.Now().After(deadline) {
+			t.Fatal("the metadata must be added when it arrives")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEnrichLabelsWithoutMetadata(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_ = os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	defer os.Unsetenv("GCE_METADATA_HOST")
+
+	resetEnrichment()
+	defer resetEnrichment()
+
+	labels := enrichLabels(EnrichHostname | EnrichBuildInfo)
+	if labels["hostname"] == "" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+
+	// the labels are cached for the bitmask
+	if again := enrichLabels(EnrichHostname | EnrichBuildInfo); fmt.Sprintf("%p", again) != fmt.Sprintf("%p", labels) {
+		t.Error("the labels must be cached")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("the metadata server must not be requested: %d", n)
+	}
+}
+
+// resetEnrichment clears the values fetched by the other tests
+func resetEnrichment() {
+	enrichment.localOnce = sync.Once{}
+	enrichment.metadataOnce = sync.Once{}
+}
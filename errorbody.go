@@ -0,0 +1,30 @@
+package stalog
+
+import (
+	"net/http"
+)
+
+// captureErrorBody keeps the first bytes of the response body of 5xx up to CaptureErrorResponse
+func (w *wrappedResponseWriter) captureErrorBody(b []byte) {
+	if w.status < http.StatusInternalServerError || w.logger == nil {
+		return
+	}
+
+	room := w.logger.config.CaptureErrorResponse - len(w.errorBody)
+	if room <= 0 {
+		return
+	}
+	if len(b) > room {
+		b = b[:room]
+	}
+	w.errorBody = append(w.errorBody, b...)
+}
+
+// errorBodyData returns the captured response body of 5xx for the request log's data
+func (w *wrappedResponseWriter) errorBodyData() AdditionalData {
+	if len(w.errorBody) == 0 {
+		return nil
+	}
+
+	return AdditionalData{"responseErrorBody": sanitizeMessage(string(w.errorBody))}
+}
@@ -0,0 +1,47 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaptureErrorResponse(t *testing.T) {
+	for _, tt := range []struct {
+		status int
+		want   interface{}
+	}{
+		{http.StatusInternalServerError, `{"error":"database`},
+		{http.StatusBadRequest, nil},
+		{http.StatusOK, nil},
+	} {
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.CaptureErrorResponse = 18
+
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			_, _ = w.Write([]byte(`{"error":"data`))
+			_, _ = w.Write([]byte(`base is down"}`))
+		}))
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if rec.Body.String() != `{"error":"database is down"}` {
+			t.Errorf("%d: the response is changed: %s", tt.status, rec.Body.String())
+		}
+
+		var requestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+			t.Fatal(err)
+		}
+		if got := requestLog.AdditionalData["responseErrorBody"]; got != tt.want {
+			t.Errorf("%d: got %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package stalog
+
+import (
+	"log"
+	"strings"
+)
+
+// HTTPServerErrorLog creates the logger for http.Server.ErrorLog.
+// net/http's internal error logs (e.g. TLS handshake errors) are written to ContextLogOut as structured logs at WARNING.
+func HTTPServerErrorLog(config *Config) *log.Logger {
+	logger := newDefaultLogger(config).WithFields(String("logger", "net/http"))
+	return log.New(&errorLogWriter{logger: logger}, "", 0)
+}
+
+// errorLogWriter writes each line from log.Logger as a context log
+type errorLogWriter struct {
+	logger *ContextLogger
+}
+
+func (w *errorLogWriter) Write(p []byte) (int, error) {
+	// the caller is in the log package and the depth from net/http varies, so the source location is omitted
+	msg := strings.TrimSuffix(string(p), "\n")
+	if err := w.logger.writeAt(SeverityWarning, &SourceLocation{}, msg); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,32 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestHTTPServerErrorLog(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+
+	HTTPServerErrorLog(config).Printf("http: TLS handshake error from %s: EOF", "192.0.2.1:1234")
+
+	var cLog contextLog
+	if err := json.Unmarshal(out.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "WARNING" {
+		t.Errorf("unexpected severity: %s", cLog.Severity)
+	}
+	if cLog.Message != "http: TLS handshake error from 192.0.2.1:1234: EOF" {
+		t.Errorf("unexpected message: %q", cLog.Message)
+	}
+	if cLog.SourceLocation != nil {
+		t.Errorf("the source location in the log package must be omitted: %+v", cLog.SourceLocation)
+	}
+	if cLog.AdditionalData["logger"] != "net/http" {
+		t.Errorf("unexpected data: %+v", cLog.AdditionalData)
+	}
+}
@@ -0,0 +1,73 @@
+package stalog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"text/template"
+)
+
+// ErrorTemplate renders the error response body of WriteError with ErrorResponse.
+// Both text/template and html/template can be used.
+type ErrorTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// ErrorResponse is the data for ErrorTemplate.
+// The error itself is not included so as not to leak the details to clients.
+type ErrorResponse struct {
+	Status     int
+	StatusText string
+	// TraceID is the reference for the support to find the logs of the request
+	TraceID string
+}
+
+var defaultErrorTemplate = template.Must(template.New("error").Parse(
+	"{{.Status}} {{.StatusText}}\n{{if .TraceID}}Reference: {{.TraceID}}\n{{end}}",
+))
+
+// WriteError logs the error at ERROR severity by the request-context logger and
+// writes the status and the error response body including the trace ID.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	tmpl, contentType := ErrorTemplate(defaultErrorTemplate), "text/plain; charset=utf-8"
+	res := ErrorResponse{
+		Status:     status,
+		StatusText: http.StatusText(status),
+	}
+
+	if logger := RequestContextLogger(r); logger != nil {
+		logger.writeError(status, err)
+		res.TraceID = logger.traceId
+		if logger.config.ErrorTemplate != nil {
+			tmpl, contentType = logger.config.ErrorTemplate, logger.config.ErrorContentType
+		}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, res); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+	}
+}
+
+// writeError logs the error for WriteError with the stack trace if ErrorStackTrace is enabled.
+// The status text is logged if err is nil.
+func (l *ContextLogger) writeError(status int, err error) {
+	msg := http.StatusText(status)
+	if err != nil {
+		msg = err.Error()
+	}
+
+	child := l.WithFields(Int("status", int64(status)))
+	// WriteError calls writeError instead of the user code
+	child.Skip++
+	if l.config.ErrorStackTrace {
+		// Error Reporting expects the stack trace in the format of Go's panic output
+		child.stackTrace = fmt.Sprintf("%s\n\n%s", msg, debug.Stack())
+	}
+
+	_ = child.write(SeverityError, msg)
+}
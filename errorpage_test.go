@@ -0,0 +1,139 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	w := httptest.NewRecorder()
+
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = contextLogOut
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, r, http.StatusServiceUnavailable, errors.New("database is down"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status: %d", w.Code)
+	}
+	expected := "503 Service Unavailable\nReference: 105445aa7843bc8bf206b12000100000\n"
+	if w.Body.String() != expected {
+		t.Errorf("unexpected body: %q", w.Body.String())
+	}
+
+	var cLog contextLog
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "ERROR" || cLog.Message != "database is down" || cLog.AdditionalData["status"] != float64(503) {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+	if cLog.SourceLocation == nil || cLog.SourceLocation.File != "errorpage_test.go" {
+		t.Errorf("unexpected source location: %+v", cLog.SourceLocation)
+	}
+	if cLog.StackTrace != "" {
+		t.Error("stack trace must be omitted by default")
+	}
+}
+
+func TestWriteErrorWithTemplate(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = contextLogOut
+	config.ErrorTemplate = template.Must(template.New("error").Parse("<p>{{.StatusText}} ({{.TraceID}})</p>"))
+	config.ErrorContentType = "text/html; charset=utf-8"
+	config.ErrorStackTrace = true
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, r, http.StatusBadRequest, errors.New("<invalid>"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	if !strings.HasPrefix(w.Body.String(), "<p>Bad Request (") || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("unexpected response: %q", w.Body.String())
+	}
+
+	var cLog contextLog
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cLog.StackTrace, "goroutine") || cLog.Type != reportedErrorEventType {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+	if cLog.SourceLocation == nil || cLog.SourceLocation.File != "errorpage_test.go" {
+		t.Errorf("unexpected source location: %+v", cLog.SourceLocation)
+	}
+}
+
+func TestWriteErrorWithoutMiddleware(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	WriteError(w, r, http.StatusInternalServerError, errors.New("failed"))
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "500 Internal Server Error\n" {
+		t.Errorf("unexpected response: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestWriteErrorWithoutError(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity Severity
+		logged   bool
+	}{
+		{name: "logged", severity: SeverityInfo, logged: true},
+		{name: "filtered by severity", severity: SeverityCritical, logged: false},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		w := httptest.NewRecorder()
+
+		contextLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = new(bytes.Buffer)
+		config.ContextLogOut = contextLogOut
+		config.ErrorStackTrace = true
+		config.Severity = tt.severity
+
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			WriteError(w, r, http.StatusNotFound, nil)
+		}))
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: unexpected status: %d", tt.name, w.Code)
+		}
+		if !tt.logged {
+			if contextLogOut.Len() != 0 {
+				t.Errorf("%s: the log must be filtered: %s", tt.name, contextLogOut.String())
+			}
+			continue
+		}
+
+		var cLog contextLog
+		if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		if cLog.Message != "Not Found" || !strings.HasPrefix(cLog.StackTrace, "Not Found\n\n") || cLog.Type != reportedErrorEventType {
+			t.Errorf("%s: unexpected log: %+v", tt.name, cLog)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// eventLogLevel is the level of the Windows Event Log
+type eventLogLevel int
+
+const (
+	eventLogInfo eventLogLevel = iota
+	eventLogWarning
+	eventLogError
+)
+
+// eventLogEntry parses a log and returns the message and the level of the Windows Event Log.
+// WARNING is mapped to Warning, ERROR and above are mapped to Error, and the others are mapped to Information.
+// The logs which aren't JSON are written at Information.
+func eventLogEntry(p []byte) (string, eventLogLevel) {
+	line := bytes.TrimSuffix(p, []byte("\n"))
+
+	var log struct {
+		Severity string `json:"severity"`
+	}
+	if err := json.Unmarshal(line, &log); err != nil {
+		return string(line), eventLogInfo
+	}
+
+	severity, _ := ParseSeverity(log.Severity)
+	switch {
+	case severity >= SeverityError:
+		return string(line), eventLogError
+	case severity >= SeverityWarning:
+		return string(line), eventLogWarning
+	default:
+		return string(line), eventLogInfo
+	}
+}
@@ -0,0 +1,27 @@
+package stalog
+
+import (
+	"testing"
+)
+
+func TestEventLogEntry(t *testing.T) {
+	for _, tt := range []struct {
+		line  string
+		level eventLogLevel
+	}{
+		{`{"severity":"DEBUG","message":"a"}` + "\n", eventLogInfo},
+		{`{"severity":"NOTICE","message":"a"}` + "\n", eventLogInfo},
+		{`{"severity":"WARNING","message":"a"}` + "\n", eventLogWarning},
+		{`{"severity":"ERROR","message":"a"}` + "\n", eventLogError},
+		{`{"severity":"EMERGENCY","message":"a"}` + "\n", eventLogError},
+		{"plain text\n", eventLogInfo},
+	} {
+		msg, level := eventLogEntry([]byte(tt.line))
+		if level != tt.level {
+			t.Errorf("%s: got %d, want %d", tt.line, level, tt.level)
+		}
+		if msg+"\n" != tt.line {
+			t.Errorf("got %q, want %q", msg, tt.line)
+		}
+	}
+}
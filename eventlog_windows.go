@@ -0,0 +1,59 @@
+//go:build windows
+// +build windows
+
+package stalog
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// EventLogWriter writes the logs in JSON format to the Windows Event Log,
+// for the services deployed on Windows GCE instances where stdout is not collected.
+// The severities are mapped to the event types (Information, Warning and Error).
+type EventLogWriter struct {
+	log     *eventlog.Log
+	eventID uint32
+}
+
+// NewEventLogWriter opens the Windows Event Log of the source. eventID is the event ID of all the logs.
+// The source must be registered in advance, e.g. by InstallEventLogSource.
+func NewEventLogWriter(source string, eventID uint32) (*EventLogWriter, error) {
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("stalog: failed to open the event log: %w", err)
+	}
+
+	return &EventLogWriter{log: l, eventID: eventID}, nil
+}
+
+// InstallEventLogSource registers the source to the Application log. It requires the administrator privilege.
+func InstallEventLogSource(source string) error {
+	return eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+}
+
+// Write writes a log to the Windows Event Log
+func (w *EventLogWriter) Write(p []byte) (int, error) {
+	msg, level := eventLogEntry(p)
+
+	var err error
+	switch level {
+	case eventLogError:
+		err = w.log.Error(w.eventID, msg)
+	case eventLogWarning:
+		err = w.log.Warning(w.eventID, msg)
+	default:
+		err = w.log.Info(w.eventID, msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the Windows Event Log
+func (w *EventLogWriter) Close() error {
+	return w.log.Close()
+}
@@ -0,0 +1,104 @@
+package stalog
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// TraceExtractor extracts the trace context from the request. See Config.TraceExtractor.
+type TraceExtractor func(r *http.Request) (traceId string, spanId string, sampled bool, ok bool)
+
+// ChainTraceExtractors returns TraceExtractor which tries the extractors in order
+// and uses the result of the first one which succeeds
+func ChainTraceExtractors(extractors ...TraceExtractor) TraceExtractor {
+	return func(r *http.Request) (string, string, bool, bool) {
+		for _, extractor := range extractors {
+			if traceId, spanId, sampled, ok := extractor(r); ok {
+				return traceId, spanId, sampled, true
+			}
+		}
+
+		return "", "", false, false
+	}
+}
+
+// B3TraceExtractor extracts the trace context from B3 headers of Zipkin (used by Envoy and Istio).
+// Both the single header "b3" and the multiple headers "X-B3-*" are supported.
+func B3TraceExtractor(r *http.Request) (string, string, bool, bool) {
+	// b3: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+	if b3 := r.Header.Get("b3"); b3 != "" {
+		parts := strings.Split(b3, "-")
+		if len(parts) < 2 {
+			return "", "", false, false
+		}
+
+		sampled := len(parts) >= 3 && (parts[2] == "1" || parts[2] == "d")
+		return normalizeTraceId(parts[0], parts[1], sampled)
+	}
+
+	traceId := r.Header.Get("X-B3-TraceId")
+	spanId := r.Header.Get("X-B3-SpanId")
+	if traceId == "" || spanId == "" {
+		return "", "", false, false
+	}
+
+	sampled := r.Header.Get("X-B3-Sampled")
+	debug := r.Header.Get("X-B3-Flags") == "1"
+	return normalizeTraceId(traceId, spanId, sampled == "1" || sampled == "true" || debug)
+}
+
+// JaegerTraceExtractor extracts the trace context from "uber-trace-id" header of Jaeger
+func JaegerTraceExtractor(r *http.Request) (string, string, bool, bool) {
+	header := r.Header.Get("uber-trace-id")
+	if header == "" {
+		return "", "", false, false
+	}
+
+	// the value may be URL-encoded by some clients
+	if unescaped, err := url.QueryUnescape(header); err == nil {
+		header = unescaped
+	}
+
+	// uber-trace-id: {trace-id}:{span-id}:{parent-span-id}:{flags}
+	parts := strings.Split(header, ":")
+	if len(parts) != 4 {
+		return "", "", false, false
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return "", "", false, false
+	}
+
+	return normalizeTraceId(parts[0], parts[1], flags&1 == 1)
+}
+
+// normalizeTraceId pads the hex IDs to 32 digits (trace ID) and 16 digits (span ID)
+// because B3 and Jaeger allow shorter IDs like 64-bit trace IDs
+func normalizeTraceId(traceId string, spanId string, sampled bool) (string, string, bool, bool) {
+	traceId = strings.ToLower(traceId)
+	spanId = strings.ToLower(spanId)
+	if !isHex(traceId) || len(traceId) > 32 || !isHex(spanId) || len(spanId) > 16 {
+		return "", "", false, false
+	}
+
+	traceId = strings.Repeat("0", 32-len(traceId)) + traceId
+	spanId = strings.Repeat("0", 16-len(spanId)) + spanId
+	return traceId, spanId, sampled, true
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,78 @@
+package stalog
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTraceExtractors(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor TraceExtractor
+		headers   map[string]string
+		traceId   string
+		spanId    string
+		sampled   bool
+		ok        bool
+	}{
+		{
+			name:      "b3 single",
+			extractor: B3TraceExtractor,
+			headers:   map[string]string{"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90"},
+			traceId:   "80f198ee56343ba864fe8b2a57d3eff7",
+			spanId:    "e457b5a2e4d86bd1",
+			sampled:   true,
+			ok:        true,
+		},
+		{
+			name:      "b3 multi with 64-bit trace ID",
+			extractor: B3TraceExtractor,
+			headers:   map[string]string{"X-B3-TraceId": "64fe8b2a57d3eff7", "X-B3-SpanId": "e457b5a2e4d86bd1", "X-B3-Sampled": "0"},
+			traceId:   "000000000000000064fe8b2a57d3eff7",
+			spanId:    "e457b5a2e4d86bd1",
+			sampled:   false,
+			ok:        true,
+		},
+		{
+			name:      "b3 invalid",
+			extractor: B3TraceExtractor,
+			headers:   map[string]string{"b3": "not-hex"},
+		},
+		{
+			name:      "jaeger",
+			extractor: JaegerTraceExtractor,
+			headers:   map[string]string{"uber-trace-id": "6e0c63257de34c92bf9efcd03927272e%3A1b4ac8a2c2b7f2d3%3A0%3A1"},
+			traceId:   "6e0c63257de34c92bf9efcd03927272e",
+			spanId:    "1b4ac8a2c2b7f2d3",
+			sampled:   true,
+			ok:        true,
+		},
+		{
+			name:      "chain",
+			extractor: ChainTraceExtractors(B3TraceExtractor, JaegerTraceExtractor),
+			headers:   map[string]string{"uber-trace-id": "6e0c63257de34c92:1b4ac8a2c2b7f2d3:0:0"},
+			traceId:   "00000000000000006e0c63257de34c92",
+			spanId:    "1b4ac8a2c2b7f2d3",
+			sampled:   false,
+			ok:        true,
+		},
+		{
+			name:      "no headers",
+			extractor: ChainTraceExtractors(B3TraceExtractor, JaegerTraceExtractor),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			traceId, spanId, sampled, ok := tt.extractor(r)
+			if traceId != tt.traceId || spanId != tt.spanId || sampled != tt.sampled || ok != tt.ok {
+				t.Errorf("unexpected result: %s, %s, %v, %v", traceId, spanId, sampled, ok)
+			}
+		})
+	}
+}
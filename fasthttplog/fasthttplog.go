@@ -0,0 +1,130 @@
+// Package fasthttplog logs the requests of fasthttp servers with stalog.
+// It is a separate module, so that the users of stalog don't depend on fasthttp.
+//
+//	config := stalog.NewConfig("my-project")
+//	handler := fasthttplog.RequestLogging(config, func(ctx *fasthttp.RequestCtx) {
+//		fasthttplog.ContextLogger(ctx).Infof("hello")
+//	})
+package fasthttplog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gcp-kit/stalog"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// contextLoggerKey is the key of the request-context logger in the user values of fasthttp.RequestCtx
+const contextLoggerKey = "stalog.contextLogger"
+
+// RequestLogging wraps the fasthttp.RequestHandler to log a request log and create a request-context logger.
+// The request is converted to *http.Request for stalog.Tracker, so that the request log is filtered and enriched
+// by the options of the config in the same way as stalog.RequestLogging (e.g. TracePolicy, SkipStatuses and RouteSummary).
+// It panics if the config is invalid.
+func RequestLogging(config *stalog.Config, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		outer := ContextLogger(ctx)
+		if nested, ok := nestedLogger(config, outer); ok {
+			ctx.SetUserValue(contextLoggerKey, nested)
+			defer ctx.SetUserValue(contextLoggerKey, outer)
+			next(ctx)
+			return
+		}
+
+		r, err := convertRequest(ctx)
+		if err != nil {
+			// the request URI is invalid, so fasthttp responds with the error anyway
+			next(ctx)
+			return
+		}
+
+		_, tracker := stalog.StartTracker(config, r)
+		ctx.SetUserValue(contextLoggerKey, tracker.Logger())
+		if outer != nil {
+			defer ctx.SetUserValue(contextLoggerKey, outer)
+		}
+
+		defer func() {
+			if config.Recover {
+				if v := recover(); v != nil {
+					tracker.LogPanic(v)
+					ctx.Error(http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}
+
+			// logging
+			ctx.Response.Header.VisitAll(func(k, v []byte) {
+				tracker.Header().Add(string(k), string(v))
+			})
+			tracker.Finish(ctx.Response.StatusCode(), responseSize(ctx))
+		}()
+
+		next(ctx)
+	}
+}
+
+// ContextLogger gets request-context logger for the fasthttp request.
+// You must use RequestLogging in advance for this function to work.
+func ContextLogger(ctx *fasthttp.RequestCtx) *stalog.ContextLogger {
+	v, _ := ctx.UserValue(contextLoggerKey).(*stalog.ContextLogger)
+	return v
+}
+
+// convertRequest converts the request for stalog.Tracker with the protocol of the request
+func convertRequest(ctx *fasthttp.RequestCtx) (*http.Request, error) {
+	r := new(http.Request)
+	if err := fasthttpadaptor.ConvertRequest(ctx, r, true); err != nil {
+		return nil, err
+	}
+
+	protocol := string(ctx.Request.Header.Protocol())
+	if major, minor, ok := http.ParseHTTPVersion(protocol); ok {
+		r.Proto, r.ProtoMajor, r.ProtoMinor = protocol, major, minor
+	}
+
+	return r, nil
+}
+
+// responseSize returns the size of the response body.
+// Content-Length is set when the response is written, so the body is used unless it is a stream.
+func responseSize(ctx *fasthttp.RequestCtx) int {
+	if ctx.Response.IsBodyStream() {
+		if cl := ctx.Response.Header.ContentLength(); cl > 0 {
+			return cl
+		}
+		return 0
+	}
+
+	return len(ctx.Response.Body())
+}
+
+// nestedLogger returns the logger for the handler by Config.Nested if outer is the logger of an outer RequestLogging.
+// It returns false if RequestLogging should log the request by itself.
+func nestedLogger(config *stalog.Config, outer *stalog.ContextLogger) (*stalog.ContextLogger, bool) {
+	if outer == nil {
+		return nil, false
+	}
+
+	switch config.Nested {
+	case stalog.NestedReuse:
+		return outer, true
+	case stalog.NestedChildOperation:
+		return outer.Child(newOperationID()), true
+	default:
+		return nil, false
+	}
+}
+
+// newOperationID generates a random operation ID
+func newOperationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,173 @@
+package fasthttplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gcp-kit/stalog"
+	"github.com/valyala/fasthttp"
+)
+
+// entry is the fields of the context log checked in the tests
+type entry struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Trace    string `json:"logging.googleapis.com/trace"`
+}
+
+func TestRequestLogging(t *testing.T) {
+	var req fasthttp.Request
+	req.SetRequestURI("/foo?bar=baz")
+	req.Header.SetMethod("GET")
+	req.Header.SetUserAgent("test")
+	req.Header.Set("X-Cloud-Trace-Context", "105445AA7843BC8BF206B12000100000/1;o=1")
+
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}, nil)
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+	config := stalog.NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+
+	handler := RequestLogging(config, func(ctx *fasthttp.RequestCtx) {
+		ContextLogger(ctx).Warnf("warn")
+		ctx.SetStatusCode(fasthttp.StatusCreated)
+		ctx.SetBodyString("created")
+	})
+	handler(&ctx)
+
+	var cLog entry
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	var httpRequestLog stalog.HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTrace := "projects/test/traces/105445aa7843bc8bf206b12000100000"
+	if cLog.Trace != expectedTrace || httpRequestLog.Trace != expectedTrace {
+		t.Errorf("unexpected traces: %s, %s", cLog.Trace, httpRequestLog.Trace)
+	}
+	if httpRequestLog.Severity != "WARNING" {
+		t.Errorf("unexpected severity: %s", httpRequestLog.Severity)
+	}
+
+	r := httpRequestLog.HTTPRequest
+	if r.RequestMethod != "GET" || r.RequestUrl != "/foo?bar=baz" || r.Status != fasthttp.StatusCreated ||
+		r.ResponseSize != "7" || r.UserAgent != "test" || r.RemoteIP != "192.0.2.1" || r.Protocol != "HTTP/1.1" {
+		t.Errorf("unexpected httpRequest: %+v", r)
+	}
+}
+
+func TestRequestLoggingFiltering(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := stalog.NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.SkipStatuses = []int{fasthttp.StatusNotFound}
+
+	handler := RequestLogging(config, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+	})
+
+	var req fasthttp.Request
+	req.SetRequestURI("/missing")
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, nil, nil)
+	handler(&ctx)
+
+	if requestLogOut.Len() != 0 {
+		t.Errorf("the request log isn't skipped: %s", requestLogOut.String())
+	}
+}
+
+func TestRequestLoggingRecover(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+	config := stalog.NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.Recover = true
+
+	handler := RequestLogging(config, func(ctx *fasthttp.RequestCtx) {
+		panic("boom")
+	})
+
+	var req fasthttp.Request
+	req.SetRequestURI("/panic")
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, nil, nil)
+	handler(&ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusInternalServerError {
+		t.Errorf("unexpected status: %d", ctx.Response.StatusCode())
+	}
+	var cLog entry
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "CRITICAL" || cLog.Message != "boom" {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+	var httpRequestLog stalog.HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.HTTPRequest.Status != fasthttp.StatusInternalServerError {
+		t.Errorf("unexpected status: %d", httpRequestLog.HTTPRequest.Status)
+	}
+}
+
+func TestNested(t *testing.T) {
+	for _, tt := range []struct {
+		policy      stalog.NestedPolicy
+		requestLogs int
+		sameLogger  bool
+	}{
+		{stalog.NestedReuse, 1, true},
+		{stalog.NestedChildOperation, 1, false},
+		{stalog.NestedSeparate, 2, false},
+	} {
+		requestLogOut := new(bytes.Buffer)
+		config := stalog.NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.Nested = tt.policy
+
+		var outer, inner, restored *stalog.ContextLogger
+		innerHandler := RequestLogging(config, func(ctx *fasthttp.RequestCtx) {
+			inner = ContextLogger(ctx)
+			inner.Warning("inner")
+		})
+		handler := RequestLogging(config, func(ctx *fasthttp.RequestCtx) {
+			outer = ContextLogger(ctx)
+			innerHandler(ctx)
+			restored = ContextLogger(ctx)
+		})
+
+		var req fasthttp.Request
+		req.SetRequestURI("/foo")
+		var ctx fasthttp.RequestCtx
+		ctx.Init(&req, nil, nil)
+		handler(&ctx)
+
+		if n := strings.Count(requestLogOut.String(), "\n"); n != tt.requestLogs {
+			t.Errorf("%d: got %d request logs", tt.policy, n)
+		}
+		if (outer == inner) != tt.sameLogger {
+			t.Errorf("%d: unexpected logger", tt.policy)
+		}
+		if restored != outer {
+			t.Errorf("%d: the outer logger isn't restored", tt.policy)
+		}
+		if tt.policy != stalog.NestedSeparate && outer.MaxSeverity() != stalog.SeverityWarning {
+			t.Errorf("%d: severity isn't rolled up: %s", tt.policy, outer.MaxSeverity())
+		}
+	}
+}
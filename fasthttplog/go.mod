@@ -0,0 +1,10 @@
+module github.com/gcp-kit/stalog/fasthttplog
+
+go 1.13
+
+require (
+	github.com/gcp-kit/stalog v0.0.0
+	github.com/valyala/fasthttp v1.34.0
+)
+
+replace github.com/gcp-kit/stalog => ../
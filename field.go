@@ -0,0 +1,91 @@
+package stalog
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Field is a key-value pair which is added to data of context logs.
+// Use the typed constructors like Duration and Time for consistent encoding.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a string field
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int creates an integer field
+func Int(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float creates a float field
+func Float(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool creates a boolean field
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration creates a field of the duration in seconds as float (e.g. 1.5 for 1500ms),
+// which enables numeric filtering in Logs Explorer
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value.Seconds()}
+}
+
+// Time creates a field of the time in RFC3339 with nanoseconds
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value.Format(time.RFC3339Nano)}
+}
+
+// Any creates a field of any value which can be encoded to JSON
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// WithFields returns a logger which adds the fields to data of context logs.
+// It shares the request with the original logger.
+func (l *ContextLogger) WithFields(fields ...Field) *ContextLogger {
+	data := make(AdditionalData, len(fields))
+	for _, f := range fields {
+		data[f.Key] = f.Value
+	}
+
+	child := *l
+	child.AdditionalData = limitDataDepth(mergeData(l.AdditionalData, data))
+	child.encodedData = nil
+	if len(child.AdditionalData) > 0 {
+		// encode the static fields once because the logger is usually reused for many logs
+		if b, err := json.Marshal(child.AdditionalData); err == nil {
+			child.encodedData = b
+		}
+	}
+	return &child
+}
+
+// marshal encodes the log. The pre-encoded data of the logger is appended to the tail
+// instead of encoding AdditionalData for each log, unless the log has other data.
+func (l *ContextLogger) marshal(log *contextLog) ([]byte, error) {
+	if l.encodedData == nil || l.config.customDataLayout() || l.config.StableOrder || l.config.Strict || reflect.ValueOf(log.AdditionalData).Pointer() != reflect.ValueOf(l.AdditionalData).Pointer() {
+		log.AdditionalData = limitDataDepth(log.AdditionalData)
+		return l.config.marshalLog(log, &log.AdditionalData)
+	}
+
+	// data is the last field of contextLog, so the output is the same as json.Marshal
+	trimmed := *log
+	trimmed.AdditionalData = nil
+	b, err := json.Marshal(&trimmed)
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b[:len(b)-1], `,"data":`...)
+	b = append(b, l.encodedData...)
+	return append(b, '}'), nil
+}
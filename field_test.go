@@ -0,0 +1,66 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithFields(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.AdditionalData = AdditionalData{"service": "foo"}
+	logger := newContextLogger(config, "", "")
+
+	expires := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	logger.WithFields(
+		String("user", "alice"),
+		Int("count", 3),
+		Bool("cached", true),
+		Duration("db_time", 1500*time.Millisecond),
+		Time("expires_at", expires),
+	).Infof("1")
+
+	var cLog contextLog
+	if err := json.Unmarshal(out.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := AdditionalData{
+		"service":    "foo",
+		"user":       "alice",
+		"count":      3.0,
+		"cached":     true,
+		"db_time":    1.5,
+		"expires_at": "2020-01-02T03:04:05.000000006Z",
+	}
+	if !cmp.Equal(cLog.AdditionalData, expected) {
+		t.Errorf("diff: %s", cmp.Diff(cLog.AdditionalData, expected))
+	}
+	if len(config.AdditionalData) != 1 {
+		t.Errorf("config must not be modified: %v", config.AdditionalData)
+	}
+}
+
+func TestWithFieldsEncodedData(t *testing.T) {
+	config := NewConfig("test")
+	logger := newContextLogger(config, "", "").WithFields(String("user", "<alice>"), Int("count", 3))
+
+	log := logger.newLog(SeverityInfo, SourceLocation{File: "main.go"}, "hello")
+	got, err := logger.marshal(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := json.Marshal(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != string(expected) {
+		t.Errorf("unexpected encoding:\n got: %s\nwant: %s", got, expected)
+	}
+}
@@ -0,0 +1,270 @@
+package stalog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// FileCompressor compresses the rotated files of FileWriter
+type FileCompressor interface {
+	// Extension is appended to the name of the compressed file (e.g. ".gz")
+	Extension() string
+	// NewWriter returns the writer which compresses the data written to it into w
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Extension() string { return ".gz" }
+
+func (gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+type zstdCompressor struct{}
+
+func (zstdCompressor) Extension() string { return ".zst" }
+
+func (zstdCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+var (
+	// GzipCompressor compresses the rotated files with gzip
+	GzipCompressor FileCompressor = gzipCompressor{}
+	// ZstdCompressor compresses the rotated files with zstd
+	ZstdCompressor FileCompressor = zstdCompressor{}
+)
+
+// rename is os.Rename, which is replaced in the tests
+var rename = os.Rename
+
+// rotatedTimeFormat is the suffix of the rotated files, which sorts in the order of the rotation
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// FileWriterOptions is the options for NewFileWriter
+type FileWriterOptions struct {
+	// MaxSize is the size in bytes at which the file is rotated. The file isn't rotated by the size if 0.
+	MaxSize int64
+
+	// Compressor compresses the rotated files in the background. The files aren't compressed if nil.
+	Compressor FileCompressor
+
+	// MaxAge is the retention period of the rotated files. The files are kept regardless of the age if 0.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of the rotated files to keep. All files are kept if 0.
+	MaxBackups int
+}
+
+// FileWriter writes logs to a file, rotating it to "<path>.<timestamp>" by the size or by Rotate,
+// and compresses and removes the rotated files by the options.
+type FileWriter struct {
+	path string
+	opts FileWriterOptions
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+
+	// cleanup serializes the compression and the removal of the rotated files
+	cleanup sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// NewFileWriter opens the file in append mode, creating it if it doesn't exist
+func NewFileWriter(path string, opts FileWriterOptions) (*FileWriter, error) {
+	w := &FileWriter{path: path, opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *FileWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("stalog: failed to open the log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("stalog: failed to open the log file: %w", err)
+	}
+
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+// Write writes a log to the file. The file is rotated before the write if the log exceeds MaxSize.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	if w.file == nil {
+		// the file couldn't be reopened by the last rotation
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.opts.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Rotate rotates the file, e.g. on SIGHUP or at every day
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+
+	return w.rotate()
+}
+
+func (w *FileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	rotated := w.path + "." + time.Now().UTC().Format(rotatedTimeFormat)
+	if err := rename(w.path, rotated); err != nil {
+		// keep writing to the current file
+		if oerr := w.open(); oerr != nil {
+			w.file = nil
+		}
+		return err
+	}
+
+	if err := w.open(); err != nil {
+		w.file = nil
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.cleanup.Lock()
+		defer w.cleanup.Unlock()
+		if w.opts.Compressor != nil {
+			_ = compressFile(rotated, w.opts.Compressor)
+		}
+		w.removeOld()
+	}()
+
+	return nil
+}
+
+// Close closes the file and waits for the compression of the rotated files
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return err
+}
+
+// compressFile compresses the file to the file with the extension and removes the original
+func compressFile(path string, c FileCompressor) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+c.Extension(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	cw, err := c.NewWriter(dst)
+	if err == nil {
+		if _, err = io.Copy(cw, src); err == nil {
+			err = cw.Close()
+		}
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path + c.Extension())
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// removeOld removes the rotated files older than MaxAge and beyond MaxBackups
+func (w *FileWriter) removeOld() {
+	if w.opts.MaxAge <= 0 && w.opts.MaxBackups <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return
+	}
+
+	type rotatedFile struct {
+		path string
+		time time.Time
+	}
+	var files []rotatedFile
+	for _, m := range matches {
+		suffix := strings.TrimPrefix(m, w.path+".")
+		if len(suffix) < len(rotatedTimeFormat) {
+			continue
+		}
+		t, err := time.Parse(rotatedTimeFormat, suffix[:len(rotatedTimeFormat)])
+		if err != nil {
+			continue
+		}
+		files = append(files, rotatedFile{path: m, time: t})
+	}
+
+	// newest first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].time.After(files[j].time)
+	})
+
+	for i, f := range files {
+		if (w.opts.MaxBackups > 0 && i >= w.opts.MaxBackups) ||
+			(w.opts.MaxAge > 0 && time.Since(f.time) > w.opts.MaxAge) {
+			_ = os.Remove(f.path)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package stalog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestFileWriter(t *testing.T) {
+	for _, tt := range []struct {
+		compressor FileCompressor
+		decompress func(r io.Reader) (io.Reader, error)
+	}{
+		{GzipCompressor, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{ZstdCompressor, func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	} {
+		dir, err := ioutil.TempDir("", "stalog")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "app.log")
+		w, err := NewFileWriter(path, FileWriterOptions{MaxSize: 10, Compressor: tt.compressor, MaxBackups: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+			if _, err := w.Write([]byte(line)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		current, _ := ioutil.ReadFile(path)
+		if string(current) != "line 4\n" {
+			t.Errorf("got %q", current)
+		}
+
+		rotated, _ := filepath.Glob(path + ".*")
+		if len(rotated) != 2 {
+			t.Fatalf("got %v", rotated)
+		}
+		for i, want := range []string{"line 2\n", "line 3\n"} {
+			if !strings.HasSuffix(rotated[i], tt.compressor.Extension()) {
+				t.Errorf("not compressed: %s", rotated[i])
+			}
+			b, _ := ioutil.ReadFile(rotated[i])
+			r, err := tt.decompress(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := ioutil.ReadAll(r)
+			if string(got) != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		}
+	}
+}
+
+func TestFileWriterRenameFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rename = func(string, string) error { return errors.New("rename failed") }
+	defer func() { rename = os.Rename }()
+
+	path := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(path, FileWriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err == nil {
+		t.Error("rename failure must be returned")
+	}
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatalf("writes must continue after the failed rotation: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "before\nafter\n" {
+		t.Errorf("unexpected file: %q", b)
+	}
+}
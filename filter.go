@@ -0,0 +1,67 @@
+package stalog
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// statusSeverity returns the severity derived from the status code
+func statusSeverity(status int) Severity {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return SeverityError
+	case status >= http.StatusBadRequest:
+		return SeverityWarning
+	default:
+		return SeverityDefault
+	}
+}
+
+// skipRequestLog reports whether the request log is skipped by the config
+func (rv *Reserve) skipRequestLog(status int) bool {
+	if rv.config.DisableRequestLog || rv.config.skipPath(rv.request.URL.Path) {
+		return true
+	}
+
+	// the max severity can be raised without context logs by the gRPC status
+	if rv.config.QuietRequestLog && status < http.StatusBadRequest && atomic.LoadInt64(&rv.contextLogger.state.logCount) == 0 &&
+		rv.contextLogger.MaxSeverity() < SeverityWarning {
+		return true
+	}
+
+	severity := rv.contextLogger.MaxSeverity()
+	if s := statusSeverity(status); s > severity {
+		severity = s
+	}
+
+	// requests with errors are always logged like sampling
+	if severity < SeverityError && (rv.config.skipMethod(rv.request.Method) || rv.config.skipStatus(status) ||
+		(rv.config.SkipPreflight && isPreflight(rv.request))) {
+		return true
+	}
+
+	return severity < rv.config.RequestLogMinSeverity
+}
+
+// skipMethod reports whether the request log for the method is skipped
+func (c *Config) skipMethod(method string) bool {
+	for _, m := range c.SkipMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipStatus reports whether the request log for the status is skipped
+func (c *Config) skipStatus(status int) bool {
+	for _, s := range c.SkipStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
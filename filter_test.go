@@ -0,0 +1,176 @@
+package stalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLogMinSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected bool
+	}{
+		{
+			name: "ok",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				RequestContextLogger(r).Infof("info")
+				w.WriteHeader(http.StatusOK)
+			},
+			expected: false,
+		},
+		{
+			name: "warning log",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				RequestContextLogger(r).Warnf("warn")
+				w.WriteHeader(http.StatusOK)
+			},
+			expected: true,
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		w := httptest.NewRecorder()
+
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.RequestLogMinSeverity = SeverityWarning
+
+		RequestLogging(config)(tt.handler).ServeHTTP(w, r)
+
+		if written := requestLogOut.Len() > 0; written != tt.expected {
+			t.Errorf("%s: expected written=%v, but got %v", tt.name, tt.expected, written)
+		}
+	}
+}
+
+func TestQuietRequestLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected bool
+	}{
+		{
+			name: "no logs",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				RequestContextLogger(r).Debugf("below the threshold")
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expected: false,
+		},
+		{
+			name: "with logs",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				RequestContextLogger(r).Infof("info")
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expected: true,
+		},
+		{
+			name: "client error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		w := httptest.NewRecorder()
+
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.Severity = SeverityInfo
+		config.QuietRequestLog = true
+
+		RequestLogging(config)(tt.handler).ServeHTTP(w, r)
+
+		if written := requestLogOut.Len() > 0; written != tt.expected {
+			t.Errorf("%s: expected written=%v, but got %v", tt.name, tt.expected, written)
+		}
+	}
+}
+
+func TestSkipMethodsAndStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		handler  http.HandlerFunc
+		expected bool
+	}{
+		{
+			name:   "skipped method",
+			method: "OPTIONS",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expected: false,
+		},
+		{
+			name:   "skipped status",
+			method: "GET",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expected: false,
+		},
+		{
+			name:   "skipped status with an error log",
+			method: "GET",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				RequestContextLogger(r).Errorf("failed")
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expected: true,
+		},
+		{
+			name:   "skipped method with server error",
+			method: "HEAD",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expected: true,
+		},
+		{
+			name:   "not skipped",
+			method: "GET",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest(tt.method, "/foo", nil)
+		w := httptest.NewRecorder()
+
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.SkipMethods = []string{"OPTIONS", "head"}
+		config.SkipStatuses = []int{http.StatusNotFound}
+
+		RequestLogging(config)(tt.handler).ServeHTTP(w, r)
+
+		if written := requestLogOut.Len() > 0; written != tt.expected {
+			t.Errorf("%s: expected written=%v, but got %v", tt.name, tt.expected, written)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package stalog
+
+import (
+	"context"
+)
+
+// GRPCMethodLabel is the label of the gRPC method recorded by RecordGRPCMethod
+const GRPCMethodLabel = "grpc_method"
+
+// RecordGRPCMethod records the gRPC method (e.g. "/pkg.Service/Method") as the label of the request log
+// and the context logs written after the call. It does nothing if ctx has no request-context logger.
+//
+// With gRPC-gateway serving the gRPC services in-process (RegisterXxxHandlerServer) behind RequestLogging,
+// the gateway calls the services with the context of the HTTP request, so the HTTP request log is the only
+// request log of the external call. Record the method from the metadata annotator of the gateway:
+//
+//	mux := runtime.NewServeMux(runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+//		if method, ok := runtime.RPCMethod(ctx); ok {
+//			stalog.RecordGRPCMethod(ctx, method)
+//		}
+//		return nil
+//	}))
+//	http.ListenAndServe(":8080", stalog.RequestLogging(config)(mux))
+func RecordGRPCMethod(ctx context.Context, method string) {
+	if l := LoggerFromContext(ctx); l != nil {
+		l.SetLabel(GRPCMethodLabel, method)
+	}
+}
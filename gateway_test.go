@@ -0,0 +1,43 @@
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordGRPCMethod(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	// the gateway calls the service in-process with the context of the HTTP request
+	service := func(ctx context.Context) {
+		LoggerFromContext(ctx).Info("in service")
+	}
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RecordGRPCMethod(r.Context(), "/pkg.Service/GetUser")
+		service(r.Context())
+	}))
+	r, _ := http.NewRequest("GET", "/v1/users/1", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if n := strings.Count(requestLogOut.String(), "\n"); n != 1 {
+		t.Fatalf("got %d request logs", n)
+	}
+	var requestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+		t.Fatal(err)
+	}
+	if requestLog.Labels[GRPCMethodLabel] != "/pkg.Service/GetUser" || requestLog.HTTPRequest.RequestUrl != "/v1/users/1" {
+		t.Errorf("got %+v", requestLog)
+	}
+
+	// without the middleware
+	RecordGRPCMethod(context.Background(), "/pkg.Service/GetUser")
+}
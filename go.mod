@@ -5,6 +5,11 @@ go 1.13
 require (
 	github.com/go-chi/chi v4.0.3+incompatible
 	github.com/google/go-cmp v0.5.3
+	github.com/klauspost/compress v1.15.0
 	github.com/labstack/echo/v4 v4.5.0
 	go.opencensus.io v0.23.0
+	golang.org/x/crypto v0.0.0-20220214200702-86341886e292 // indirect
+	golang.org/x/net v0.0.0-20220225172249-27dd8689420f // indirect
+	golang.org/x/sys v0.0.0-20220227234510-4e6760a101f9
+	gopkg.in/yaml.v2 v2.4.0
 )
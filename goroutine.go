@@ -0,0 +1,29 @@
+package stalog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// WithWorkerID returns a logger which adds the worker ID to context logs.
+// It shares the request with the original logger, so logs of workers spawned by the handler
+// are grouped under the same request log and can be separated by the worker ID.
+func (l *ContextLogger) WithWorkerID(workerId string) *ContextLogger {
+	child := *l
+	child.workerId = workerId
+	return &child
+}
+
+// goroutineID parses the ID of the current goroutine from the stack trace like "goroutine 18 [running]:"
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
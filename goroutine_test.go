@@ -0,0 +1,46 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.GoroutineID = true
+	logger := newContextLogger(config, "", "")
+
+	logger.Info("main")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logger.WithWorkerID("worker-1").Info("worker")
+	}()
+	wg.Wait()
+
+	logs := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+
+	var main, worker contextLog
+	if err := json.Unmarshal([]byte(logs[0]), &main); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(logs[1]), &worker); err != nil {
+		t.Fatal(err)
+	}
+
+	if main.GoroutineID == 0 || worker.GoroutineID == 0 || main.GoroutineID == worker.GoroutineID {
+		t.Errorf("unexpected goroutine IDs: main=%d, worker=%d", main.GoroutineID, worker.GoroutineID)
+	}
+	if main.WorkerID != "" || worker.WorkerID != "worker-1" {
+		t.Errorf("unexpected worker IDs: main=%s, worker=%s", main.WorkerID, worker.WorkerID)
+	}
+}
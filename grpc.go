@@ -0,0 +1,79 @@
+package stalog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// grpcCodeNames are the names of the gRPC status codes
+var grpcCodeNames = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound", "AlreadyExists", "PermissionDenied",
+	"ResourceExhausted", "FailedPrecondition", "Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable",
+	"DataLoss", "Unauthenticated",
+}
+
+// DefaultGRPCSeverities maps the gRPC status codes to the severities of the request logs.
+// The errors caused by the clients are WARNING or lower, and only the server errors are ERROR.
+var DefaultGRPCSeverities = map[string]Severity{
+	"OK":                 SeverityDefault,
+	"Canceled":           SeverityInfo,
+	"Unknown":            SeverityError,
+	"InvalidArgument":    SeverityWarning,
+	"DeadlineExceeded":   SeverityWarning,
+	"NotFound":           SeverityInfo,
+	"AlreadyExists":      SeverityInfo,
+	"PermissionDenied":   SeverityWarning,
+	"ResourceExhausted":  SeverityWarning,
+	"FailedPrecondition": SeverityWarning,
+	"Aborted":            SeverityWarning,
+	"OutOfRange":         SeverityWarning,
+	"Unimplemented":      SeverityWarning,
+	"Internal":           SeverityError,
+	"Unavailable":        SeverityWarning,
+	"DataLoss":           SeverityError,
+	"Unauthenticated":    SeverityWarning,
+}
+
+// grpcCodeName returns the name of the gRPC status code
+func grpcCodeName(code int) string {
+	if code >= 0 && code < len(grpcCodeNames) {
+		return grpcCodeNames[code]
+	}
+
+	return fmt.Sprintf("Code(%d)", code)
+}
+
+// grpcSeverity returns the severity of the gRPC status code by GRPCSeverities and DefaultGRPCSeverities.
+// The unknown codes are ERROR.
+func (c *Config) grpcSeverity(code string) Severity {
+	if severity, ok := c.GRPCSeverities[code]; ok {
+		return severity
+	}
+	if severity, ok := DefaultGRPCSeverities[code]; ok {
+		return severity
+	}
+
+	return SeverityError
+}
+
+// grpcStatus returns the gRPC status code of the response, e.g. served by grpc.Server.ServeHTTP.
+// It returns false if the request isn't gRPC or the response has no status.
+func grpcStatus(r *http.Request, header http.Header) (string, bool) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		return "", false
+	}
+
+	// the status is a trailer, or a header of the trailers-only response
+	value := header.Get(http.TrailerPrefix + "Grpc-Status")
+	if value == "" {
+		value = header.Get("Grpc-Status")
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil {
+		return "", false
+	}
+
+	return grpcCodeName(code), true
+}
@@ -0,0 +1,83 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestGRPCSeverities(t *testing.T) {
+	for _, tt := range []struct {
+		code     int
+		trailer  bool
+		severity string
+		status   string
+	}{
+		{0, true, "DEFAULT", "OK"},
+		{5, true, "INFO", "NotFound"},
+		{13, true, "ERROR", "Internal"},
+		{14, false, "WARNING", "Unavailable"},
+		{3, true, "NOTICE", "InvalidArgument"},
+		{99, true, "ERROR", "Code(99)"},
+	} {
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.GRPCSeverities = map[string]Severity{"InvalidArgument": SeverityNotice}
+
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tt.trailer {
+				w.Header().Set("Trailer", "Grpc-Status")
+				w.WriteHeader(http.StatusOK)
+				w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(tt.code))
+			} else {
+				// trailers-only response
+				w.Header().Set("Grpc-Status", strconv.Itoa(tt.code))
+				w.WriteHeader(http.StatusOK)
+			}
+		}))
+		r, _ := http.NewRequest("POST", "/pkg.Service/Method", nil)
+		r.Header.Set("Content-Type", "application/grpc")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		var requestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+			t.Fatal(err)
+		}
+		if requestLog.Severity != tt.severity || requestLog.AdditionalData["grpcStatus"] != tt.status {
+			t.Errorf("%d: got %s, %v", tt.code, requestLog.Severity, requestLog.AdditionalData["grpcStatus"])
+		}
+	}
+}
+
+func TestGRPCQuietRequestLog(t *testing.T) {
+	for _, tt := range []struct {
+		code   int
+		logged bool
+	}{
+		{0, false},
+		{13, true},
+	} {
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.QuietRequestLog = true
+
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Grpc-Status", strconv.Itoa(tt.code))
+			w.WriteHeader(http.StatusOK)
+		}))
+		r, _ := http.NewRequest("POST", "/pkg.Service/Method", nil)
+		r.Header.Set("Content-Type", "application/grpc")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		if logged := requestLogOut.Len() > 0; logged != tt.logged {
+			t.Errorf("%d: request log written: %v", tt.code, logged)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+//go:build go1.18
+// +build go1.18
+
+package stalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// StatusError is the error with the status code of the response for Handle.
+// The other errors and the invalid status codes (not in 100-999) are responded with 500 Internal Server Error.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+// Error returns the message of Err, or the status text if Err is nil
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// handleErrorResponse is the response body of Handle for errors.
+// The error itself is not included so as not to leak the details to clients.
+type handleErrorResponse struct {
+	Error   string `json:"error"`
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// Handle creates the handler for JSON APIs with the request log.
+// The request body is decoded as JSON into T, and the response of fn is encoded as JSON (204 No Content for nil).
+// The decode failures are responded with 400 Bad Request, and the errors of fn with the status of StatusError
+// or 500 Internal Server Error, and both are logged at the severity of the status with the source location of fn.
+//
+//	http.Handle("/users", stalog.Handle(config, func(ctx context.Context, logger *stalog.ContextLogger, req CreateUserRequest) (any, error) {
+//		...
+//	}))
+func Handle[T any](config *Config, fn func(ctx context.Context, logger *ContextLogger, req T) (resp any, err error)) http.Handler {
+	// the errors are logged at fn, since the caller of the log is Handle itself
+	location := funcLocation(fn)
+
+	return RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+
+		var req T
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeHandleError(w, logger, location, http.StatusBadRequest, fmt.Errorf("stalog: failed to decode the request: %w", err))
+			return
+		}
+
+		resp, err := fn(r.Context(), logger, req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var se *StatusError
+			if errors.As(err, &se) && se.Status >= 100 && se.Status <= 999 {
+				status = se.Status
+			}
+			writeHandleError(w, logger, location, status, err)
+			return
+		}
+
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if err := writeHandleJSON(w, http.StatusOK, resp); err != nil {
+			writeHandleError(w, logger, location, http.StatusInternalServerError, fmt.Errorf("stalog: failed to encode the response: %w", err))
+		}
+	}))
+}
+
+// writeHandleError logs the error at the severity of the status and writes the error response
+func writeHandleError(w http.ResponseWriter, logger *ContextLogger, location SourceLocation, status int, err error) {
+	severity := statusSeverity(status)
+	if severity == SeverityDefault {
+		severity = SeverityInfo
+	}
+	_ = logger.WithFields(Int("status", int64(status))).writeAt(severity, &location, err.Error())
+
+	_ = writeHandleJSON(w, status, handleErrorResponse{
+		Error:   http.StatusText(status),
+		TraceID: logger.traceId,
+	})
+}
+
+// funcLocation returns the source location of the function's definition
+func funcLocation(fn any) SourceLocation {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return SourceLocation{}
+	}
+
+	file, line := f.FileLine(f.Entry())
+	return SourceLocation{
+		File:     filepath.Base(file),
+		Line:     strconv.Itoa(line),
+		Function: f.Name(),
+	}
+}
+
+// writeHandleJSON writes the response as JSON. Nothing is written if the response can't be encoded.
+func writeHandleJSON(w http.ResponseWriter, status int, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(b, '\n'))
+	return nil
+}
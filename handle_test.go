@@ -0,0 +1,143 @@
+//go:build go1.18
+// +build go1.18
+
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	type request struct {
+		Name string `json:"name"`
+	}
+	type response struct {
+		Greeting string `json:"greeting"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		severity string
+		response string
+	}{
+		{
+			name:     "ok",
+			body:     `{"name":"alice"}`,
+			status:   http.StatusOK,
+			response: `{"greeting":"hello alice"}`,
+		},
+		{
+			name:     "no content",
+			body:     ``,
+			status:   http.StatusNoContent,
+			response: ``,
+		},
+		{
+			name:     "decode failure",
+			body:     `{"name":`,
+			status:   http.StatusBadRequest,
+			severity: "WARNING",
+			response: `{"error":"Bad Request","traceId":"105445aa7843bc8bf206b12000100000"}`,
+		},
+		{
+			name:     "status error",
+			body:     `{"name":"bob"}`,
+			status:   http.StatusNotFound,
+			severity: "WARNING",
+			response: `{"error":"Not Found","traceId":"105445aa7843bc8bf206b12000100000"}`,
+		},
+		{
+			name:     "status error without error",
+			body:     `{"name":"erin"}`,
+			status:   http.StatusConflict,
+			severity: "WARNING",
+			response: `{"error":"Conflict","traceId":"105445aa7843bc8bf206b12000100000"}`,
+		},
+		{
+			name:     "invalid status",
+			body:     `{"name":"dave"}`,
+			status:   http.StatusInternalServerError,
+			severity: "ERROR",
+			response: `{"error":"Internal Server Error","traceId":"105445aa7843bc8bf206b12000100000"}`,
+		},
+		{
+			name:     "error",
+			body:     `{"name":"carol"}`,
+			status:   http.StatusInternalServerError,
+			severity: "ERROR",
+			response: `{"error":"Internal Server Error","traceId":"105445aa7843bc8bf206b12000100000"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		requestLogOut := new(bytes.Buffer)
+		contextLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = contextLogOut
+
+		handler := Handle(config, func(ctx context.Context, logger *ContextLogger, req request) (any, error) {
+			switch req.Name {
+			case "":
+				return nil, nil
+			case "bob":
+				return nil, &StatusError{Status: http.StatusNotFound, Err: errors.New("no such user")}
+			case "carol":
+				return nil, errors.New("database is down")
+			case "erin":
+				return nil, &StatusError{Status: http.StatusConflict}
+			case "dave":
+				return nil, &StatusError{Status: 42, Err: errors.New("invalid status")}
+			}
+			return response{Greeting: "hello " + req.Name}, nil
+		})
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/greet", strings.NewReader(tt.body))
+		r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: unexpected status: %d", tt.name, w.Code)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != tt.response {
+			t.Errorf("%s: unexpected response: %s", tt.name, got)
+		}
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if httpRequestLog.HTTPRequest.Status != tt.status {
+			t.Errorf("%s: unexpected request log status: %d", tt.name, httpRequestLog.HTTPRequest.Status)
+		}
+
+		if tt.severity == "" {
+			if contextLogOut.Len() != 0 {
+				t.Errorf("%s: unexpected context log: %s", tt.name, contextLogOut.String())
+			}
+			continue
+		}
+		var cLog contextLog
+		if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if cLog.Severity != tt.severity || cLog.Message == "" {
+			t.Errorf("%s: unexpected log: %s %q", tt.name, cLog.Severity, cLog.Message)
+		}
+		// the location of the handler, not the one of Handle
+		if location := cLog.SourceLocation; location == nil || location.File != "handle_test.go" ||
+			!strings.HasPrefix(location.Function, "github.com/gcp-kit/stalog.TestHandle.func") {
+			t.Errorf("%s: unexpected source location: %+v", tt.name, location)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package stalog
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Name wraps the handler to record the name in the "handler" field of the request log's data,
+// which makes per-endpoint analysis possible without route patterns.
+func Name(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if logger := RequestContextLogger(r); logger != nil {
+			logger.setHandlerName(name)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NameFunc wraps the handler function to record its function name like Name
+// (e.g. "main.CreateUser" or "handler.(*Server).CreateUser")
+func NameFunc(fn http.HandlerFunc) http.Handler {
+	return Name(funcName(fn), fn)
+}
+
+// funcName returns the short name of the function by reflection
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+
+	return shortFuncName(f.Name())
+}
+
+// shortFuncName trims the package path from the function name
+func shortFuncName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	// method values have "-fm" suffix
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// echoRouteNames caches the names of the routes by "method path" per Echo instance,
+// so that the routes aren't scanned for each request
+type echoRouteNames struct {
+	names sync.Map
+}
+
+// handlerName returns the name of the matched route, which is the handler function name by default.
+// The names are read once per Echo instance, since the routes are registered before serving.
+func (n *echoRouteNames) handlerName(c echo.Context) string {
+	names, ok := n.names.Load(c.Echo())
+	if !ok {
+		m := make(map[string]string)
+		for _, route := range c.Echo().Routes() {
+			m[route.Method+" "+route.Path] = shortFuncName(route.Name)
+		}
+		names, _ = n.names.LoadOrStore(c.Echo(), m)
+	}
+
+	return names.(map[string]string)[c.Request().Method+" "+c.Path()]
+}
+
+// isAnonymousFunc reports whether the function name is of an anonymous function (e.g. "main.main.func1"),
+// which is not useful as the handler name
+func isAnonymousFunc(name string) bool {
+	i := strings.LastIndex(name, ".func")
+	if i < 0 {
+		return false
+	}
+
+	suffix := name[i+len(".func"):]
+	return suffix != "" && strings.Trim(suffix, "0123456789.") == ""
+}
+
+func (l *ContextLogger) setHandlerName(name string) {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	l.state.handlerName = name
+}
+
+func (l *ContextLogger) handlerName() string {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	return l.state.handlerName
+}
@@ -0,0 +1,116 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func createUser(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusCreated)
+}
+
+func getUser(c echo.Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func TestHandlerName(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		expected interface{}
+	}{
+		{
+			name:     "Name",
+			handler:  Name("CreateUser", http.HandlerFunc(createUser)),
+			expected: "CreateUser",
+		},
+		{
+			name:     "NameFunc",
+			handler:  NameFunc(createUser),
+			expected: "stalog.createUser",
+		},
+		{
+			name:     "no name",
+			handler:  http.HandlerFunc(createUser),
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest("POST", "/users", nil)
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+
+		RequestLogging(config)(tt.handler).ServeHTTP(httptest.NewRecorder(), r)
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		if name := httpRequestLog.AdditionalData["handler"]; name != tt.expected {
+			t.Errorf("%s: expected %v, but got %v", tt.name, tt.expected, name)
+		}
+	}
+}
+
+func TestHandlerNameWithEcho(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	middleware := RequestLoggingWithEcho(config)
+	e := echo.New()
+	e.Use(middleware)
+	e.GET("/users/:id", getUser)
+	e.GET("/users", getUser).Name = "ListUsers"
+	e.GET("/anonymous", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{path: "/users/1", expected: "stalog.getUser"},
+		{path: "/users", expected: "ListUsers"},
+		{path: "/anonymous", expected: nil},
+	}
+	for _, tt := range tests {
+		requestLogOut.Reset()
+		r, _ := http.NewRequest("GET", tt.path, nil)
+		e.ServeHTTP(httptest.NewRecorder(), r)
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		if name := httpRequestLog.AdditionalData["handler"]; name != tt.expected {
+			t.Errorf("%s: expected %v, but got %v", tt.path, tt.expected, name)
+		}
+	}
+
+	// the names are cached per Echo instance
+	other := echo.New()
+	other.Use(middleware)
+	other.GET("/users", getUser).Name = "OtherUsers"
+
+	requestLogOut.Reset()
+	r, _ := http.NewRequest("GET", "/users", nil)
+	other.ServeHTTP(httptest.NewRecorder(), r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if name := httpRequestLog.AdditionalData["handler"]; name != "OtherUsers" {
+		t.Errorf("expected OtherUsers, but got %v", name)
+	}
+}
@@ -0,0 +1,55 @@
+package stalog
+
+import (
+	"runtime"
+	"time"
+)
+
+// processStart is the time when the process started, approximately
+var processStart = time.Now()
+
+// Heartbeat periodically logs the heartbeat with the process stats (goroutines, memory and uptime),
+// which is useful on the platforms without metrics agents.
+type Heartbeat struct {
+	logger *ContextLogger
+	ticks  *periodic
+}
+
+// NewHeartbeat creates Heartbeat which logs to ContextLogOut of the config at every interval
+func NewHeartbeat(config *Config, interval time.Duration) *Heartbeat {
+	h := &Heartbeat{
+		logger: newDefaultLogger(config).WithFields(String("logger", "stalog.heartbeat")),
+	}
+	h.ticks = startPeriodic(interval, h.beat, nil)
+
+	return h
+}
+
+// Close stops logging the heartbeat
+func (h *Heartbeat) Close() error {
+	h.ticks.close()
+	return nil
+}
+
+// beat logs the heartbeat with the current process stats
+func (h *Heartbeat) beat() {
+	logger := h.logger.WithFields(Any("heartbeat", processStats()))
+	_ = logger.output(logger.newLog(SeverityInfo, SourceLocation{}, "heartbeat"))
+}
+
+// processStats returns the process stats for the heartbeat. The sizes are in bytes and the durations in seconds.
+func processStats() map[string]interface{} {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return map[string]interface{}{
+		"uptime":       time.Since(processStart).Seconds(),
+		"goroutines":   runtime.NumGoroutine(),
+		"heapAlloc":    m.HeapAlloc,
+		"heapInuse":    m.HeapInuse,
+		"heapObjects":  m.HeapObjects,
+		"sys":          m.Sys,
+		"numGC":        m.NumGC,
+		"gcPauseTotal": time.Duration(m.PauseTotalNs).Seconds(),
+	}
+}
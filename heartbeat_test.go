@@ -0,0 +1,67 @@
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is bytes.Buffer safe for the writes from the background goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func TestHeartbeat(t *testing.T) {
+	out := new(syncBuffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+
+	h := NewHeartbeat(config, 10*time.Millisecond)
+	time.Sleep(35 * time.Millisecond)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	scanner := bufio.NewScanner(bytes.NewReader(out.Bytes()))
+	for scanner.Scan() {
+		var cLog contextLog
+		if err := json.Unmarshal(scanner.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		if cLog.Severity != "INFO" || cLog.Message != "heartbeat" {
+			t.Errorf("unexpected log: %+v", cLog)
+		}
+		stats, ok := cLog.AdditionalData["heartbeat"].(map[string]interface{})
+		if !ok || stats["goroutines"].(float64) < 1 || stats["heapAlloc"].(float64) <= 0 || stats["uptime"].(float64) <= 0 {
+			t.Errorf("unexpected stats: %+v", cLog.AdditionalData)
+		}
+		n++
+	}
+	if n == 0 {
+		t.Error("no heartbeat")
+	}
+
+	// no heartbeat after Close
+	size := len(out.Bytes())
+	time.Sleep(20 * time.Millisecond)
+	if len(out.Bytes()) != size {
+		t.Error("heartbeat after Close")
+	}
+}
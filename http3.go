@@ -0,0 +1,51 @@
+package stalog
+
+import (
+	"net/http"
+)
+
+// RequestLoggingHTTP3 creates the middleware for HTTP/3 servers (e.g. quic-go's http3.Server.Handler).
+// It logs in the same way as RequestLogging, but the protocol is always reported as "HTTP/3",
+// and IPv6 remote addresses of QUIC connections are handled. It panics if the config is invalid.
+func RequestLoggingHTTP3(config *Config) func(http.Handler) http.Handler {
+	config.mustValidate()
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if nested, ok := config.nestedRequest(r); ok {
+				next.ServeHTTP(w, nested)
+				return
+			}
+
+			reserve := NewReserve(config, http3Request(r))
+
+			wrw := &wrappedResponseWriter{ResponseWriter: w, logger: reserve.contextLogger}
+			// logging, which runs after recoverPanic re-panics http.ErrAbortHandler
+			defer reserve.LastHandling(wrw)
+			defer func() {
+				if config.Recover {
+					if v := recover(); v != nil {
+						reserve.recoverPanic(wrw, v)
+					}
+				}
+			}()
+
+			reserve.setTraceResponseHeader(w)
+			next.ServeHTTP(wrw, reserve.request)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// http3Request returns the request with the HTTP/3 protocol version,
+// for servers which don't set it (e.g. adapters from other QUIC implementations)
+func http3Request(r *http.Request) *http.Request {
+	if r.ProtoMajor == 3 {
+		return r
+	}
+
+	r3 := *r
+	r3.Proto, r3.ProtoMajor, r3.ProtoMinor = "HTTP/3.0", 3, 0
+	return &r3
+}
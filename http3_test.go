@@ -0,0 +1,70 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLoggingHTTP3(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/3.0", 3, 0
+	r.RemoteAddr = "[2001:db8::1]:51234"
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	handler := RequestLoggingHTTP3(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Infof("hello")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.HTTPRequest.Protocol != "HTTP/3" {
+		t.Errorf("unexpected protocol: %s", httpRequestLog.HTTPRequest.Protocol)
+	}
+	if httpRequestLog.HTTPRequest.RemoteIP != "2001:db8::1" {
+		t.Errorf("unexpected remote IP: %s", httpRequestLog.HTTPRequest.RemoteIP)
+	}
+	if httpRequestLog.HTTPRequest.Status != http.StatusOK || httpRequestLog.HTTPRequest.ResponseSize != "2" {
+		t.Errorf("unexpected httpRequest: %+v", httpRequestLog.HTTPRequest)
+	}
+	if httpRequestLog.Severity != "INFO" {
+		t.Errorf("unexpected severity: %s", httpRequestLog.Severity)
+	}
+}
+
+func TestRequestLoggingHTTP3Protocol(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	var proto string
+	handler := RequestLoggingHTTP3(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+	}))
+	// the request of a server which doesn't set the protocol version
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.HTTPRequest.Protocol != "HTTP/3" || proto != "HTTP/3.0" {
+		t.Errorf("unexpected protocol: %s, %s", httpRequestLog.HTTPRequest.Protocol, proto)
+	}
+	if r.ProtoMajor != 1 {
+		t.Errorf("the original request is modified: %s", r.Proto)
+	}
+}
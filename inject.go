@@ -0,0 +1,66 @@
+package stalog
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// LabelsHeader is the header which carries the labels to downstream services
+const LabelsHeader = "X-Stalog-Labels"
+
+// InjectContext sets the trace of ctx and the labels in PropagateLabels of the request-context logger
+// to the headers of the outgoing request, so that downstream services using ExtractContext
+// (or middlewares with PropagateLabels) attach them automatically.
+func InjectContext(headers http.Header, ctx context.Context) {
+	for k, v := range TraceAttributes(ctx) {
+		headers.Set(k, v)
+	}
+
+	logger, ok := ctx.Value(ContextLoggerKey).(*ContextLogger)
+	if !ok || len(logger.config.PropagateLabels) == 0 {
+		return
+	}
+
+	labels := logger.currentLabels()
+	values := url.Values{}
+	for _, key := range logger.config.PropagateLabels {
+		if v, ok := labels[key]; ok {
+			values.Set(key, v)
+		}
+	}
+	if len(values) > 0 {
+		headers.Set(LabelsHeader, values.Encode())
+	}
+}
+
+// ExtractContext returns the labels injected by InjectContext from the headers
+func ExtractContext(headers http.Header) map[string]string {
+	values, err := url.ParseQuery(headers.Get(LabelsHeader))
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]string, len(values))
+	for k := range values {
+		labels[k] = values.Get(k)
+	}
+
+	return labels
+}
+
+// extractLabels sets the labels in PropagateLabels from the upstream service to the logger.
+// The labels are accepted only from the peers whose trace is continued (see TracePolicy),
+// so that clients can't set them.
+func (l *ContextLogger) extractLabels(r *http.Request) {
+	if len(l.config.PropagateLabels) == 0 || !l.config.continueTrace(r) {
+		return
+	}
+
+	labels := ExtractContext(r.Header)
+	for _, key := range l.config.PropagateLabels {
+		if v, ok := labels[key]; ok {
+			l.SetLabel(key, v)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInjectAndExtractContext(t *testing.T) {
+	newConfig := func(out *bytes.Buffer) *Config {
+		config := NewConfig("test")
+		config.RequestLogOut = out
+		config.ContextLogOut = new(bytes.Buffer)
+		config.PropagateLabels = []string{"tenant", "requestId"}
+		return config
+	}
+
+	// downstream service
+	downstreamOut := new(bytes.Buffer)
+	downstream := httptest.NewServer(RequestLogging(newConfig(downstreamOut))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer downstream.Close()
+
+	// upstream service
+	var headers http.Header
+	upstream := RequestLogging(newConfig(new(bytes.Buffer)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		logger.SetLabel("tenant", "acme")
+		logger.SetLabel("secret", "xxx")
+
+		req, _ := http.NewRequest("GET", downstream.URL, nil)
+		InjectContext(req.Header, r.Context())
+		headers = req.Header
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+	}))
+
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	upstream.ServeHTTP(httptest.NewRecorder(), r)
+
+	if diff := cmp.Diff(map[string]string{"tenant": "acme"}, ExtractContext(headers)); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(downstreamOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.Trace != "projects/test/traces/105445aa7843bc8bf206b12000100000" {
+		t.Errorf("unexpected trace: %s", httpRequestLog.Trace)
+	}
+	if httpRequestLog.Labels["tenant"] != "acme" || httpRequestLog.Labels["secret"] != "" {
+		t.Errorf("unexpected labels: %+v", httpRequestLog.Labels)
+	}
+}
+
+func TestExtractLabelsTrust(t *testing.T) {
+	tests := []struct {
+		name     string
+		ignore   bool
+		policy   TracePolicy
+		expected string
+	}{
+		{name: "default", expected: "acme"},
+		{name: "trusted", policy: ContinueTraceIfTrusted("192.0.2.1"), expected: "acme"},
+		{name: "untrusted", policy: ContinueTraceIfTrusted("192.0.2.2")},
+		{name: "always new trace", policy: AlwaysNewTrace},
+		{name: "ignore trace headers", ignore: true},
+	}
+
+	for _, tt := range tests {
+		out := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = out
+		config.ContextLogOut = new(bytes.Buffer)
+		config.PropagateLabels = []string{"tenant"}
+		config.IgnoreTraceHeaders = tt.ignore
+		config.TracePolicy = tt.policy
+
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		r.Header.Set(LabelsHeader, "tenant=acme")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(out.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		if tenant := httpRequestLog.Labels["tenant"]; tenant != tt.expected {
+			t.Errorf("%s: unexpected tenant: %q", tt.name, tenant)
+		}
+	}
+}
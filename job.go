@@ -0,0 +1,104 @@
+package stalog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// JobLogger is the logger for background jobs like Cloud Scheduler jobs and workers.
+// All logs between NewJobLogger and Finish are grouped by the fabricated trace
+// like the logs in a request.
+type JobLogger struct {
+	*ContextLogger
+	name   string
+	before time.Time
+	ctx    context.Context
+	span   *trace.Span
+}
+
+type jobInfo struct {
+	Name    string `json:"name"`
+	Latency string `json:"latency,omitempty"`
+}
+
+type jobLog struct {
+	Time           string            `json:"time"`
+	Trace          string            `json:"logging.googleapis.com/trace"`
+	LogName        string            `json:"logging.googleapis.com/logName,omitempty"`
+	Severity       string            `json:"severity"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
+	Message        string            `json:"message"`
+	Job            jobInfo           `json:"job"`
+	ServiceContext *ServiceContext   `json:"serviceContext,omitempty"`
+	AdditionalData AdditionalData    `json:"data,omitempty"`
+}
+
+// NewJobLogger creates the logger for the job and logs "job started" to RequestLogOut
+func NewJobLogger(config *Config, jobName string) *JobLogger {
+	ctx, span := trace.StartSpan(context.Background(), jobName)
+	traceId := span.SpanContext().TraceID.String()
+	traces := config.traceResource(nil, traceId)
+
+	l := &JobLogger{
+		ContextLogger: newContextLogger(config, traces, traceId),
+		name:          jobName,
+		before:        time.Now(),
+		span:          span,
+	}
+	l.ctx = ContextWithLogger(ctx, l.ContextLogger)
+
+	l.writeJobLog(SeverityInfo, "job started", 0)
+	return l
+}
+
+// Context returns the context which has the trace and the logger of the job
+func (l *JobLogger) Context() context.Context {
+	return l.ctx
+}
+
+// Finish logs "job finished" with the duration at the max severity of the job's logs and ends the span of the job
+func (l *JobLogger) Finish() {
+	elapsed := time.Since(l.before)
+	l.flushSuppressed()
+	flushContextLog(l.config)
+	l.writeJobLog(l.MaxSeverity(), "job finished", elapsed)
+	l.span.End()
+}
+
+func (l *JobLogger) writeJobLog(severity Severity, msg string, elapsed time.Duration) {
+	log := &jobLog{
+		Time:           l.config.logTime(),
+		Trace:          l.Trace,
+		LogName:        l.config.RequestLogName,
+		Severity:       severity.String(),
+		Labels:         l.currentLabels(),
+		Message:        fmt.Sprintf("%s: %s", msg, l.name),
+		Job:            jobInfo{Name: l.name},
+		ServiceContext: l.config.serviceContext(),
+		AdditionalData: l.config.AdditionalData,
+	}
+	if elapsed > 0 {
+		log.Job.Latency = fmt.Sprintf("%fs", elapsed.Seconds())
+	}
+
+	out := l.config.route(&Entry{
+		Severity:   severity,
+		Message:    log.Message,
+		Trace:      log.Trace,
+		Labels:     log.Labels,
+		Data:       log.AdditionalData,
+		RequestLog: true,
+	}, l.config.requestLogOut())
+	if l.config.Format == FormatConsole {
+		if _, err := out.Write(consoleSummary(log.Time, log.Severity, log.Message, log.Job.Latency, log.AdditionalData)); err != nil {
+			l.config.reportError(err)
+		}
+		return
+	}
+	if err := writeJSON(l.config, out, log, &log.AdditionalData); err != nil {
+		l.config.reportError(err)
+	}
+}
@@ -0,0 +1,89 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+func TestJobLogger(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+
+	logger := NewJobLogger(config, "cleanup")
+	logger.Infof("1")
+	logger.Context().Value(ContextLoggerKey).(*ContextLogger).Warnf("2")
+	logger.Finish()
+
+	logs := strings.Split(strings.TrimSuffix(requestLogOut.String(), "\n"), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("unexpected job logs: %s", requestLogOut.String())
+	}
+
+	var started, finished jobLog
+	if err := json.Unmarshal([]byte(logs[0]), &started); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(logs[1]), &finished); err != nil {
+		t.Fatal(err)
+	}
+
+	if started.Message != "job started: cleanup" || started.Severity != "INFO" {
+		t.Errorf("unexpected started log: %s", logs[0])
+	}
+	if finished.Message != "job finished: cleanup" || finished.Severity != "WARNING" || !strings.HasSuffix(finished.Job.Latency, "s") {
+		t.Errorf("unexpected finished log: %s", logs[1])
+	}
+
+	for _, log := range strings.Split(strings.TrimSuffix(contextLogOut.String(), "\n"), "\n") {
+		var cLog contextLog
+		if err := json.Unmarshal([]byte(log), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		if cLog.Trace != started.Trace || cLog.Trace != finished.Trace {
+			t.Errorf("different trace: job=%s, context=%s", started.Trace, cLog.Trace)
+		}
+	}
+}
+
+func TestJobLoggerSpan(t *testing.T) {
+	exporter := &testExporter{}
+	trace.RegisterExporter(exporter)
+	defer trace.UnregisterExporter(exporter)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(1e-4)})
+
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = new(bytes.Buffer)
+
+	NewJobLogger(config, "cleanup").Finish()
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if len(exporter.spans) != 1 || exporter.spans[0].Name != "cleanup" {
+		t.Errorf("the span of the job must be ended: %+v", exporter.spans)
+	}
+}
+
+func TestJobLoggerConsole(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := NewDevelopmentConfig()
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = requestLogOut
+
+	NewJobLogger(config, "cleanup").Finish()
+
+	logs := strings.Split(strings.TrimSuffix(requestLogOut.String(), "\n"), "\n")
+	if len(logs) != 2 || !strings.Contains(logs[0], "INFO      job started: cleanup") ||
+		!strings.Contains(logs[1], "job finished: cleanup") || strings.HasPrefix(logs[1], "{") {
+		t.Errorf("unexpected job logs: %q", logs)
+	}
+}
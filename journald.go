@@ -0,0 +1,180 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// journaldSocket is the socket of the native protocol of systemd-journald
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldPriorities maps the severities to the syslog priorities
+var journaldPriorities = map[string]int{
+	"EMERGENCY": 0,
+	"ALERT":     1,
+	"CRITICAL":  2,
+	"ERROR":     3,
+	"WARNING":   4,
+	"NOTICE":    5,
+	"INFO":      6,
+	"DEFAULT":   6,
+	"DEBUG":     7,
+}
+
+// JournaldWriter writes the logs in JSON format to systemd-journald by the native protocol,
+// for the deployments on bare-metal or VMs managed by systemd.
+// The severities are mapped to PRIORITY, and the trace, the span ID, the labels (LABEL_*), the source location
+// (CODE_FILE, CODE_LINE, CODE_FUNC) and the request (HTTP_METHOD, HTTP_URL, HTTP_STATUS) are attached as journal fields.
+// The whole log is kept in STALOG_JSON.
+// The logs larger than the send buffer of the socket are passed in a sealed memfd like sd_journal_send on Linux.
+type JournaldWriter struct {
+	conn       net.Conn
+	identifier string
+}
+
+// NewJournaldWriter connects to systemd-journald. identifier is SYSLOG_IDENTIFIER of the logs (e.g. the name of the service).
+func NewJournaldWriter(identifier string) (*JournaldWriter, error) {
+	return newJournaldWriter(journaldSocket, identifier)
+}
+
+func newJournaldWriter(socket string, identifier string) (*JournaldWriter, error) {
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return nil, fmt.Errorf("stalog: failed to connect to journald: %w", err)
+	}
+
+	return &JournaldWriter{conn: conn, identifier: identifier}, nil
+}
+
+// journalLog is the fields of the context logs and the request logs for journald
+type journalLog struct {
+	Trace          string            `json:"logging.googleapis.com/trace"`
+	SpanID         string            `json:"logging.googleapis.com/spanId"`
+	SourceLocation *SourceLocation   `json:"logging.googleapis.com/sourceLocation"`
+	Severity       string            `json:"severity"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels"`
+	Message        string            `json:"message"`
+	HTTPRequest    *struct {
+		RequestMethod string      `json:"requestMethod"`
+		RequestUrl    string      `json:"requestUrl"`
+		Status        json.Number `json:"status"`
+	} `json:"httpRequest"`
+}
+
+// Write sends a log to journald. The logs which aren't JSON are sent as the message at INFO.
+func (w *JournaldWriter) Write(p []byte) (int, error) {
+	line := bytes.TrimSuffix(p, []byte("\n"))
+
+	var log journalLog
+	if err := json.Unmarshal(line, &log); err != nil {
+		log = journalLog{Message: string(line), Severity: "INFO"}
+		line = nil
+	}
+
+	if err := w.send(w.fields(&log, line)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// send sends the fields as a datagram, or by sendJournalLarge if the datagram is too large
+func (w *JournaldWriter) send(b []byte) error {
+	_, err := w.conn.Write(b)
+	if err != nil && isJournalTooLarge(err) {
+		err = sendJournalLarge(w.conn, b)
+	}
+
+	return err
+}
+
+// Close closes the connection to journald
+func (w *JournaldWriter) Close() error {
+	return w.conn.Close()
+}
+
+// fields encodes the log to the fields of the native protocol
+func (w *JournaldWriter) fields(log *journalLog, raw []byte) []byte {
+	buf := new(bytes.Buffer)
+
+	msg := log.Message
+	if msg == "" && log.HTTPRequest != nil {
+		msg = fmt.Sprintf("%s %s %s", log.HTTPRequest.RequestMethod, log.HTTPRequest.RequestUrl, log.HTTPRequest.Status)
+	}
+	writeJournalField(buf, "MESSAGE", msg)
+
+	priority, ok := journaldPriorities[log.Severity]
+	if !ok {
+		priority = journaldPriorities["DEFAULT"]
+	}
+	writeJournalField(buf, "PRIORITY", fmt.Sprintf("%d", priority))
+
+	if w.identifier != "" {
+		writeJournalField(buf, "SYSLOG_IDENTIFIER", w.identifier)
+	}
+	if log.Trace != "" {
+		writeJournalField(buf, "TRACE", log.Trace)
+	}
+	if log.SpanID != "" {
+		writeJournalField(buf, "SPAN_ID", log.SpanID)
+	}
+	if log.SourceLocation != nil {
+		writeJournalField(buf, "CODE_FILE", log.SourceLocation.File)
+		writeJournalField(buf, "CODE_LINE", log.SourceLocation.Line)
+		writeJournalField(buf, "CODE_FUNC", log.SourceLocation.Function)
+	}
+	if log.HTTPRequest != nil {
+		writeJournalField(buf, "HTTP_METHOD", log.HTTPRequest.RequestMethod)
+		writeJournalField(buf, "HTTP_URL", log.HTTPRequest.RequestUrl)
+		writeJournalField(buf, "HTTP_STATUS", log.HTTPRequest.Status.String())
+	}
+
+	keys := make([]string, 0, len(log.Labels))
+	for k := range log.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeJournalField(buf, "LABEL_"+journalFieldName(k), log.Labels[k])
+	}
+
+	if raw != nil {
+		writeJournalField(buf, "STALOG_JSON", string(raw))
+	}
+
+	return buf.Bytes()
+}
+
+// writeJournalField writes the field. The values with newlines are written in the binary format.
+func writeJournalField(buf *bytes.Buffer, name string, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName converts the key to the field name which only has uppercase letters, digits and underscores
+func journalFieldName(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,59 @@
+//go:build linux
+// +build linux
+
+package stalog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// isJournalTooLarge reports whether the datagram is larger than the send buffer of the socket
+func isJournalTooLarge(err error) bool {
+	return errors.Is(err, unix.EMSGSIZE) || errors.Is(err, unix.ENOBUFS)
+}
+
+// sendJournalLarge writes the fields to a sealed memfd and sends its file descriptor by SCM_RIGHTS
+// with an empty datagram, which journald reads instead of the datagram.
+func sendJournalLarge(conn net.Conn, b []byte) error {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("stalog: the log is too large for journald: %d bytes", len(b))
+	}
+
+	fd, err := unix.MemfdCreate("stalog-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("stalog: failed to create memfd for journald: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "stalog-journal")
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return fmt.Errorf("stalog: failed to write memfd for journald: %w", err)
+	}
+	// journald only accepts the sealed memfd which can't be modified after sending
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		return fmt.Errorf("stalog: failed to seal memfd for journald: %w", err)
+	}
+
+	// WriteMsgUnix rejects the connected datagram socket, so sendmsg is called directly
+	rc, err := uc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := unix.UnixRights(int(f.Fd()))
+	var sendErr error
+	if err := rc.Write(func(s uintptr) bool {
+		sendErr = unix.Sendmsg(int(s), nil, rights, nil, 0)
+		return sendErr != unix.EAGAIN
+	}); err != nil {
+		return err
+	}
+
+	return sendErr
+}
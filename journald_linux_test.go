@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package stalog
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestJournaldWriterLargeLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	w, err := newJournaldWriter(socket, "my-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// the kernel doubles the size, which is still much smaller than the log
+	if err := w.conn.(*net.UnixConn).SetWriteBuffer(4096); err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewConfig("test")
+	config.ContextLogOut = w
+	config.RequestLogOut = w
+
+	msg := strings.Repeat("x", 256*1024)
+	newDefaultLogger(config).Info(msg)
+
+	buf := make([]byte, 1024)
+	oob := make([]byte, unix.CmsgSpace(4))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("the datagram must be empty: %d bytes", n)
+	}
+
+	messages, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("unexpected control messages: %v, %v", messages, err)
+	}
+	fds, err := unix.ParseUnixRights(&messages[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("unexpected rights: %v, %v", fds, err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+
+	seals, err := unix.FcntlInt(f.Fd(), unix.F_GET_SEALS, 0)
+	if err != nil || seals&unix.F_SEAL_WRITE == 0 {
+		t.Errorf("the memfd must be sealed: %d, %v", seals, err)
+	}
+
+	// the file description is shared with the writer, so read it from the beginning
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournalFields(b)
+	if fields["MESSAGE"] != msg || fields["PRIORITY"] != "6" {
+		t.Errorf("unexpected fields: MESSAGE of %d bytes, PRIORITY %q", len(fields["MESSAGE"]), fields["PRIORITY"])
+	}
+}
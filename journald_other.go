@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package stalog
+
+import "net"
+
+// isJournalTooLarge reports false because journald is only on Linux
+func isJournalTooLarge(err error) bool {
+	return false
+}
+
+// sendJournalLarge is never called because journald is only on Linux
+func sendJournalLarge(conn net.Conn, b []byte) error {
+	return nil
+}
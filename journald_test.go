@@ -0,0 +1,105 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// parseJournalFields parses the datagram of the native protocol of journald
+func parseJournalFields(b []byte) map[string]string {
+	fields := map[string]string{}
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if b[i] == '=' {
+			end := bytes.IndexByte(b, '\n')
+			fields[string(b[:i])] = string(b[i+1 : end])
+			b = b[end+1:]
+			continue
+		}
+		n := binary.LittleEndian.Uint64(b[i+1 : i+9])
+		fields[string(b[:i])] = string(b[i+9 : i+9+int(n)])
+		b = b[i+9+int(n)+1:]
+	}
+
+	return fields
+}
+
+func TestJournaldWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	w, err := newJournaldWriter(socket, "my-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	config := NewConfig("test")
+	config.ContextLogOut = w
+	config.RequestLogOut = w
+	config.Labels = map[string]string{"app.kubernetes.io/name": "api"}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Warning("line 1\nline 2")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	read := func() map[string]string {
+		buf := make([]byte, 65536)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parseJournalFields(buf[:n])
+	}
+
+	contextFields := read()
+	for k, v := range map[string]string{
+		"MESSAGE":                      "line 1\nline 2",
+		"PRIORITY":                     "4",
+		"SYSLOG_IDENTIFIER":            "my-service",
+		"TRACE":                        "projects/test/traces/105445aa7843bc8bf206b12000100000",
+		"CODE_FILE":                    "journald_test.go",
+		"LABEL_APP_KUBERNETES_IO_NAME": "api",
+	} {
+		if contextFields[k] != v {
+			t.Errorf("%s: got %q, want %q", k, contextFields[k], v)
+		}
+	}
+	if contextFields["STALOG_JSON"] == "" {
+		t.Error("no STALOG_JSON")
+	}
+
+	requestFields := read()
+	for k, v := range map[string]string{
+		"MESSAGE":     "GET /foo 200",
+		"PRIORITY":    "4",
+		"HTTP_STATUS": "200",
+	} {
+		if requestFields[k] != v {
+			t.Errorf("%s: got %q, want %q", k, requestFields[k], v)
+		}
+	}
+}
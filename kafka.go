@@ -0,0 +1,69 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// KafkaProducer sends a message to a topic of Kafka. Implement it with the producer of the Kafka client of your choice
+// (e.g. github.com/segmentio/kafka-go, github.com/IBM/sarama or confluent-kafka-go), so that stalog doesn't depend on it.
+//
+//	producer := stalog.KafkaProducerFunc(func(topic string, key []byte, value []byte) error {
+//		return kafkaWriter.WriteMessages(context.Background(), kafka.Message{Topic: topic, Key: key, Value: value})
+//	})
+//	config.ContextLogOut = stalog.NewKafkaWriter(producer, "logs")
+type KafkaProducer interface {
+	// Produce sends the message. value must be copied if it is used after Produce returns.
+	Produce(topic string, key []byte, value []byte) error
+}
+
+// KafkaProducerFunc is an adapter to use a function as KafkaProducer
+type KafkaProducerFunc func(topic string, key []byte, value []byte) error
+
+// Produce calls f(topic, key, value)
+func (f KafkaProducerFunc) Produce(topic string, key []byte, value []byte) error {
+	return f(topic, key, value)
+}
+
+// KafkaWriter sends each log in JSON format as a message to a topic of Kafka,
+// so that hybrid-cloud deployments can tee the logs into the existing Kafka-based pipelines.
+// The key of the message is the trace ID, so that the logs of a request are in the same partition in order.
+// The logs without the trace have no key.
+type KafkaWriter struct {
+	producer KafkaProducer
+	topic    string
+}
+
+// NewKafkaWriter creates KafkaWriter which sends the logs to the topic by the producer
+func NewKafkaWriter(producer KafkaProducer, topic string) *KafkaWriter {
+	return &KafkaWriter{producer: producer, topic: topic}
+}
+
+// Write sends a log as a message
+func (w *KafkaWriter) Write(p []byte) (int, error) {
+	value := bytes.TrimRight(p, "\n")
+
+	var key []byte
+	if traceId := kafkaTraceID(value); traceId != "" {
+		key = []byte(traceId)
+	}
+
+	if err := w.producer.Produce(w.topic, key, value); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// kafkaTraceID returns the trace ID of the log ("projects/<project>/traces/<trace ID>"), or empty string
+func kafkaTraceID(log []byte) string {
+	var fields struct {
+		Trace string `json:"logging.googleapis.com/trace"`
+	}
+	if json.Unmarshal(log, &fields) != nil {
+		return ""
+	}
+
+	return fields.Trace[strings.LastIndex(fields.Trace, "/")+1:]
+}
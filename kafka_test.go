@@ -0,0 +1,54 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestKafkaWriter(t *testing.T) {
+	type message struct {
+		topic string
+		key   string
+		value []byte
+	}
+	var messages []message
+	producer := KafkaProducerFunc(func(topic string, key []byte, value []byte) error {
+		messages = append(messages, message{topic: topic, key: string(key), value: append([]byte(nil), value...)})
+		return nil
+	})
+
+	config := NewConfig("my-project")
+	config.ContextLogOut = NewKafkaWriter(producer, "logs")
+	newContextLogger(config, "projects/my-project/traces/abc", "abc").Info("traced")
+	newDefaultLogger(config).Info("untraced")
+
+	if len(messages) != 2 {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+	if messages[0].topic != "logs" || messages[0].key != "abc" || messages[1].key != "" {
+		t.Errorf("unexpected messages: %+v", messages)
+	}
+	if bytes.HasSuffix(messages[0].value, []byte("\n")) {
+		t.Error("trailing newline must be trimmed")
+	}
+
+	var log contextLog
+	if err := json.Unmarshal(messages[0].value, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Message != "traced" {
+		t.Errorf("unexpected log: %+v", log)
+	}
+}
+
+func TestKafkaWriterError(t *testing.T) {
+	w := NewKafkaWriter(KafkaProducerFunc(func(topic string, key []byte, value []byte) error {
+		return errors.New("broker is down")
+	}), "logs")
+
+	if n, err := w.Write([]byte("{}\n")); err == nil || n != 0 {
+		t.Errorf("unexpected result: %d, %v", n, err)
+	}
+}
@@ -0,0 +1,65 @@
+package stalog
+
+import (
+	"io"
+	"strings"
+)
+
+// klogSeverities maps the severity characters of klog/glog headers to Severity
+var klogSeverities = map[byte]Severity{
+	'I': SeverityInfo,
+	'W': SeverityWarning,
+	'E': SeverityError,
+	'F': SeverityCritical,
+}
+
+// NewKlogWriter creates the writer for the output of klog (used by k8s client-go), which writes each log
+// as a structured context log at the mapped severity (I: INFO, W: WARNING, E: ERROR, F: CRITICAL) with the source location
+// in the header, instead of unparsable text lines on stderr.
+// klog is a dependency of k8s clients only, so RedirectKlog which installs the writer is in the separate module
+// github.com/gcp-kit/stalog/klogredirect.
+func NewKlogWriter(config *Config) io.Writer {
+	return &klogWriter{logger: newDefaultLogger(config).WithFields(String("logger", "klog"))}
+}
+
+// klogWriter writes each log of klog as a context log
+type klogWriter struct {
+	logger *ContextLogger
+}
+
+func (w *klogWriter) Write(p []byte) (int, error) {
+	severity, location, msg := parseKlogLine(strings.TrimSuffix(string(p), "\n"))
+	if err := w.logger.writeAt(severity, location, msg); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// parseKlogLine parses the log with the header of klog/glog.
+// It returns INFO and the whole log if the log has no header.
+//
+//	Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+func parseKlogLine(line string) (Severity, *SourceLocation, string) {
+	end := strings.Index(line, "] ")
+	if end < 0 {
+		return SeverityInfo, nil, line
+	}
+
+	fields := strings.Fields(line[:end])
+	if len(fields) != 4 || len(fields[0]) != 5 {
+		return SeverityInfo, nil, line
+	}
+
+	severity, ok := klogSeverities[fields[0][0]]
+	if !ok {
+		return SeverityInfo, nil, line
+	}
+
+	var location *SourceLocation
+	if i := strings.LastIndex(fields[3], ":"); i > 0 {
+		location = &SourceLocation{File: fields[3][:i], Line: fields[3][i+1:]}
+	}
+
+	return severity, location, line[end+2:]
+}
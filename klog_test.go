@@ -0,0 +1,83 @@
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKlogWriter(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+
+	w := NewKlogWriter(config)
+	_, _ = w.Write([]byte("I1017 12:34:56.789012   12345 main.go:10] watch started\n"))
+	_, _ = w.Write([]byte("E1017 12:34:56.789012   12345 reflector.go:441] failed to list pods\n"))
+
+	var logs []contextLog
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var cLog contextLog
+		if err := json.Unmarshal(scanner.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		logs = append(logs, cLog)
+	}
+
+	if len(logs) != 2 {
+		t.Fatalf("unexpected logs: %+v", logs)
+	}
+	if logs[0].Severity != "INFO" || logs[0].Message != "watch started" {
+		t.Errorf("unexpected log: %+v", logs[0])
+	}
+	if logs[1].Severity != "ERROR" || logs[1].Message != "failed to list pods" {
+		t.Errorf("unexpected log: %+v", logs[1])
+	}
+	if logs[1].SourceLocation == nil || logs[1].SourceLocation.File != "reflector.go" || logs[1].SourceLocation.Line != "441" {
+		t.Errorf("unexpected source location: %+v", logs[1].SourceLocation)
+	}
+	if logs[1].AdditionalData["logger"] != "klog" {
+		t.Errorf("unexpected data: %+v", logs[1].AdditionalData)
+	}
+}
+
+func TestParseKlogLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		severity Severity
+		location *SourceLocation
+		msg      string
+	}{
+		{
+			line:     "W1017 12:34:56.789012   12345 reflector.go:441] watch ended",
+			severity: SeverityWarning,
+			location: &SourceLocation{File: "reflector.go", Line: "441"},
+			msg:      "watch ended",
+		},
+		{
+			line:     "F1017 12:34:56.789012       1 main.go:10] fatal: a] b",
+			severity: SeverityCritical,
+			location: &SourceLocation{File: "main.go", Line: "10"},
+			msg:      "fatal: a] b",
+		},
+		{
+			line:     "no header] here",
+			severity: SeverityInfo,
+			msg:      "no header] here",
+		},
+	}
+
+	for _, tt := range tests {
+		severity, location, msg := parseKlogLine(tt.line)
+		if severity != tt.severity || msg != tt.msg {
+			t.Errorf("%q: got %s %q", tt.line, severity, msg)
+		}
+		if diff := cmp.Diff(tt.location, location); diff != "" {
+			t.Errorf("%q: %s", tt.line, diff)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/gcp-kit/stalog/klogredirect
+
+go 1.13
+
+require (
+	github.com/gcp-kit/stalog v0.0.0
+	k8s.io/klog/v2 v2.9.0
+)
+
+replace github.com/gcp-kit/stalog => ../
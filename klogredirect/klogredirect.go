@@ -0,0 +1,29 @@
+// Package klogredirect redirects the output of klog (used by k8s client-go) to stalog.
+// It is a separate module, so that the users of stalog don't depend on klog.
+//
+//	flag.Parse()
+//	klogredirect.Redirect(stalog.NewConfig("my-project"))
+package klogredirect
+
+import (
+	"flag"
+
+	"github.com/gcp-kit/stalog"
+	"k8s.io/klog/v2"
+)
+
+// Redirect installs stalog.NewKlogWriter to klog, so that its output is written to ContextLogOut
+// as structured logs at the mapped severities with the source location in the header.
+// Call it after flag.Parse because it overrides the flags of klog for the output.
+func Redirect(config *stalog.Config) {
+	// klog writes each log to the outputs of the lower severities and to stderr from ERROR by default
+	fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	_ = fs.Set("logtostderr", "false")
+	_ = fs.Set("alsologtostderr", "false")
+	_ = fs.Set("stderrthreshold", "FATAL")
+	_ = fs.Set("one_output", "true")
+	_ = fs.Set("skip_headers", "false")
+
+	klog.SetOutput(stalog.NewKlogWriter(config))
+}
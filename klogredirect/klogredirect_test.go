@@ -0,0 +1,59 @@
+package klogredirect
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/gcp-kit/stalog"
+	"k8s.io/klog/v2"
+)
+
+// entry is the fields of the context log checked in the tests
+type entry struct {
+	Severity       string                 `json:"severity"`
+	Message        string                 `json:"message"`
+	SourceLocation *stalog.SourceLocation `json:"logging.googleapis.com/sourceLocation"`
+	Data           map[string]interface{} `json:"data"`
+}
+
+func TestRedirect(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := stalog.NewConfig("test")
+	config.ContextLogOut = out
+
+	Redirect(config)
+	defer klog.SetOutput(os.Stderr)
+
+	klog.Info("watch started")
+	klog.Errorf("failed to list %s", "pods")
+
+	var logs []entry
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		logs = append(logs, e)
+	}
+
+	// each log is written once
+	if len(logs) != 2 {
+		t.Fatalf("unexpected logs: %+v", logs)
+	}
+	if logs[0].Severity != "INFO" || logs[0].Message != "watch started" {
+		t.Errorf("unexpected log: %+v", logs[0])
+	}
+	if logs[1].Severity != "ERROR" || logs[1].Message != "failed to list pods" {
+		t.Errorf("unexpected log: %+v", logs[1])
+	}
+	if logs[1].SourceLocation == nil || logs[1].SourceLocation.File != "klogredirect_test.go" {
+		t.Errorf("unexpected source location: %+v", logs[1].SourceLocation)
+	}
+	if logs[1].Data["logger"] != "klog" {
+		t.Errorf("unexpected data: %+v", logs[1].Data)
+	}
+}
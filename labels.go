@@ -0,0 +1,33 @@
+package stalog
+
+// SetLabel sets the label to the logs written after this call and the request log of the request.
+// It is shared by the logger and its derived loggers, e.g. set `tenant` after the authentication.
+func (l *ContextLogger) SetLabel(key string, value string) {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	if l.state.labels == nil {
+		l.state.labels = map[string]string{}
+	}
+	l.state.labels[key] = value
+}
+
+// currentLabels returns the labels from the config with the labels set by SetLabel
+func (l *ContextLogger) currentLabels() map[string]string {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	if len(l.state.labels) == 0 {
+		return l.labels
+	}
+
+	labels := make(map[string]string, len(l.labels)+len(l.state.labels))
+	for k, v := range l.labels {
+		labels[k] = v
+	}
+	for k, v := range l.state.labels {
+		labels[k] = v
+	}
+
+	return labels
+}
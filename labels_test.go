@@ -0,0 +1,64 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetLabel(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/foo", func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		logger.Infof("before")
+		logger.WithFields(String("user", "alice")).SetLabel("tenant", "acme")
+		logger.Infof("after")
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.Labels = map[string]string{"env": "test"}
+	handler := RequestLogging(config)(mux)
+	handler.ServeHTTP(w, r)
+
+	logs := strings.Split(strings.TrimSuffix(contextLogOut.String(), "\n"), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("unexpected number of logs: %d", len(logs))
+	}
+
+	var before, after contextLog
+	if err := json.Unmarshal([]byte(logs[0]), &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(logs[1]), &after); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := before.Labels["tenant"]; ok {
+		t.Errorf("unexpected labels before SetLabel: %+v", before.Labels)
+	}
+	if after.Labels["tenant"] != "acme" || after.Labels["env"] != "test" {
+		t.Errorf("unexpected labels after SetLabel: %+v", after.Labels)
+	}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.Labels["tenant"] != "acme" || httpRequestLog.Labels["env"] != "test" {
+		t.Errorf("unexpected request log labels: %+v", httpRequestLog.Labels)
+	}
+	if len(config.Labels) != 1 {
+		t.Errorf("config labels must not be changed: %+v", config.Labels)
+	}
+}
@@ -0,0 +1,86 @@
+package stalog
+
+// Enabled reports whether logs at the severity are written (or kept by DebugTailSize).
+// Use it to skip costly construction of messages.
+func (l *ContextLogger) Enabled(severity Severity) bool {
+	if severity >= l.minSeverity() {
+		return true
+	}
+
+	return severity == SeverityDebug && l.config.DebugTailSize > 0
+}
+
+// DebugEnabled reports whether DEBUG logs are written
+func (l *ContextLogger) DebugEnabled() bool {
+	return l.Enabled(SeverityDebug)
+}
+
+// Defaultl logs a message at DEFAULT severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Defaultl(fn func() string) {
+	if l.Enabled(SeverityDefault) {
+		_ = l.write(SeverityDefault, fn())
+	}
+}
+
+// Debugl logs a message at DEBUG severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Debugl(fn func() string) {
+	if l.Enabled(SeverityDebug) {
+		_ = l.write(SeverityDebug, fn())
+	}
+}
+
+// Infol logs a message at INFO severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Infol(fn func() string) {
+	if l.Enabled(SeverityInfo) {
+		_ = l.write(SeverityInfo, fn())
+	}
+}
+
+// Noticel logs a message at NOTICE severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Noticel(fn func() string) {
+	if l.Enabled(SeverityNotice) {
+		_ = l.write(SeverityNotice, fn())
+	}
+}
+
+// Warningl logs a message at WARNING severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Warningl(fn func() string) {
+	if l.Enabled(SeverityWarning) {
+		_ = l.write(SeverityWarning, fn())
+	}
+}
+
+// Warnl logs a message at WARNING severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Warnl(fn func() string) {
+	if l.Enabled(SeverityWarning) {
+		_ = l.write(SeverityWarning, fn())
+	}
+}
+
+// Errorl logs a message at ERROR severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Errorl(fn func() string) {
+	if l.Enabled(SeverityError) {
+		_ = l.write(SeverityError, fn())
+	}
+}
+
+// Criticall logs a message at CRITICAL severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Criticall(fn func() string) {
+	if l.Enabled(SeverityCritical) {
+		_ = l.write(SeverityCritical, fn())
+	}
+}
+
+// Alertl logs a message at ALERT severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Alertl(fn func() string) {
+	if l.Enabled(SeverityAlert) {
+		_ = l.write(SeverityAlert, fn())
+	}
+}
+
+// Emergencyl logs a message at EMERGENCY severity. fn is called only when the severity is enabled.
+func (l *ContextLogger) Emergencyl(fn func() string) {
+	if l.Enabled(SeverityEmergency) {
+		_ = l.write(SeverityEmergency, fn())
+	}
+}
@@ -0,0 +1,33 @@
+package stalog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.Severity = SeverityInfo
+	logger := newContextLogger(config, "", "")
+
+	if logger.DebugEnabled() || !logger.Enabled(SeverityWarning) {
+		t.Error("unexpected enabled severities")
+	}
+
+	called := 0
+	logger.Debugl(func() string { called++; return "debug" })
+	logger.Infol(func() string { called++; return "info" })
+	if called != 1 {
+		t.Errorf("fn must be called only for enabled severities: %d", called)
+	}
+	if !bytes.Contains(out.Bytes(), []byte(`"message":"info"`)) || bytes.Contains(out.Bytes(), []byte("debug")) {
+		t.Errorf("unexpected logs: %s", out.String())
+	}
+
+	config.DebugTailSize = 1
+	if !logger.DebugEnabled() {
+		t.Error("DEBUG logs must be enabled for DebugTailSize")
+	}
+}
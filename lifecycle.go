@@ -0,0 +1,158 @@
+package stalog
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// redactedKeys are the segments of the keys whose values are redacted in the config summary and the request bodies
+var redactedKeys = map[string]bool{
+	"secret": true, "secrets": true, "token": true, "tokens": true, "password": true, "passwords": true,
+	"passwd": true, "passphrase": true, "credential": true, "credentials": true,
+}
+
+// secretKeyQualifiers are the segments before "key" of the keys of secret keys (e.g. "api_key", "privateKey"),
+// so that the other keys (e.g. "primary_key") aren't redacted
+var secretKeyQualifiers = map[string]bool{
+	"api": true, "private": true, "secret": true, "access": true, "signing": true, "encryption": true,
+	"client": true, "master": true, "session": true,
+}
+
+// LogServerStarting logs "server starting" with the listen address and the summary of the config
+// by the default logger
+func LogServerStarting(addr string, config *Config) {
+	logger := Default().WithFields(
+		String("addr", addr),
+		Any("config", configSummary(config)),
+	)
+	logger.Skip++
+	logger.Notice("server starting")
+}
+
+// LogServerStopped logs "server stopped" with the reason by the default logger.
+// nil, http.ErrServerClosed and context.Canceled are logged at NOTICE as graceful shutdown, and others at ERROR.
+func LogServerStopped(reason error) {
+	logger := Default()
+	severity := SeverityNotice
+	if reason != nil {
+		logger = logger.WithFields(String("reason", reason.Error()))
+		if reason != http.ErrServerClosed && reason != context.Canceled {
+			severity = SeverityError
+		}
+	}
+
+	// write is called in place of Info etc., so the source location is the caller without changing Skip
+	// (Skip of the shared default logger must not be changed)
+	_ = logger.write(severity, "server stopped")
+}
+
+// configSummary summarizes the config for logs with redacting secret-like values
+func configSummary(config *Config) map[string]interface{} {
+	summary := map[string]interface{}{
+		"projectId":         config.ProjectId,
+		"severity":          config.Severity.String(),
+		"singleStream":      config.SingleStream,
+		"disableRequestLog": config.DisableRequestLog,
+	}
+	if config.Format == FormatConsole {
+		summary["format"] = "console"
+	} else {
+		summary["format"] = "json"
+	}
+
+	if len(config.SamplingBySeverity) > 0 {
+		sampling := make(map[string]float64, len(config.SamplingBySeverity))
+		for severity, rate := range config.SamplingBySeverity {
+			sampling[severity.String()] = rate
+		}
+		summary["sampling"] = sampling
+	}
+
+	if len(config.Labels) > 0 {
+		labels := make(map[string]string, len(config.Labels))
+		for k, v := range config.Labels {
+			labels[k] = redact(k, v)
+		}
+		summary["labels"] = labels
+	}
+
+	if len(config.AdditionalData) > 0 {
+		data := make(map[string]interface{}, len(config.AdditionalData))
+		for k, v := range config.AdditionalData {
+			if redact(k, "") == "" {
+				data[k] = v
+			} else {
+				data[k] = redact(k, "")
+			}
+		}
+		summary["data"] = data
+	}
+
+	return summary
+}
+
+// redact returns "[REDACTED]" if the key looks like a secret, otherwise the value
+func redact(key string, value string) string {
+	if isSecretKey(key) {
+		return "[REDACTED]"
+	}
+
+	return value
+}
+
+// isSecretKey reports whether the key has a segment of redactedKeys, or is "key" or a qualified key (e.g. "apiKey").
+// The segments are split on the non-alphanumeric characters and the case changes, so that the words
+// which only contain them (e.g. "monkey", "keyword") don't match.
+func isSecretKey(key string) bool {
+	segments := keySegments(key)
+	if len(segments) == 1 && segments[0] == "key" {
+		return true
+	}
+
+	for i, segment := range segments {
+		if redactedKeys[segment] {
+			return true
+		}
+		if segment == "key" && i > 0 && secretKeyQualifiers[segments[i-1]] {
+			return true
+		}
+		// the qualified keys without separators (e.g. "x-apikey")
+		if strings.HasSuffix(segment, "key") && secretKeyQualifiers[strings.TrimSuffix(segment, "key")] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keySegments splits the key into the lowercase segments, e.g. "X-API-Key", "xApiKey", "APIKey" and "api_key"
+// into "api" and "key" (with "x")
+func keySegments(key string) []string {
+	var segments []string
+	runes := []rune(key)
+	start := 0
+	split := func(end int) {
+		if end > start {
+			segments = append(segments, strings.ToLower(string(runes[start:end])))
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			split(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			// "apiKey" and the end of the acronym in "APIKey"
+			if unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				split(i)
+				start = i
+			}
+		}
+	}
+	split(len(runes))
+
+	return segments
+}
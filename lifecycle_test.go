@@ -0,0 +1,122 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestLifecycleLogs(t *testing.T) {
+	original := Default()
+	defer defaultLogger.Store(original)
+
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.Labels = map[string]string{"env": "prod", "apiToken": "xxx"}
+	SetDefault(config)
+
+	LogServerStarting(":8080", config)
+	LogServerStopped(http.ErrServerClosed)
+	LogServerStopped(errors.New("listen failed"))
+
+	var logs []contextLog
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		var cLog contextLog
+		if err := decoder.Decode(&cLog); err != nil {
+			t.Fatal(err)
+		}
+		logs = append(logs, cLog)
+	}
+
+	if len(logs) != 3 {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+	if logs[0].Message != "server starting" || logs[0].AdditionalData["addr"] != ":8080" {
+		t.Errorf("unexpected starting log: %+v", logs[0])
+	}
+	if logs[0].SourceLocation == nil || logs[0].SourceLocation.File != "lifecycle_test.go" {
+		t.Errorf("unexpected source location: %+v", logs[0].SourceLocation)
+	}
+
+	summary, _ := logs[0].AdditionalData["config"].(map[string]interface{})
+	labels, _ := summary["labels"].(map[string]interface{})
+	if labels["env"] != "prod" || labels["apiToken"] != "[REDACTED]" {
+		t.Errorf("unexpected labels in summary: %+v", summary)
+	}
+
+	if logs[1].Severity != "NOTICE" || logs[1].AdditionalData["reason"] != http.ErrServerClosed.Error() {
+		t.Errorf("unexpected stopped log: %+v", logs[1])
+	}
+	if logs[2].SourceLocation == nil || logs[2].SourceLocation.File != "lifecycle_test.go" {
+		t.Errorf("unexpected source location: %+v", logs[2].SourceLocation)
+	}
+	if logs[2].Severity != "ERROR" {
+		t.Errorf("unexpected severity: %s", logs[2].Severity)
+	}
+}
+
+func TestLogServerStoppedWithoutReason(t *testing.T) {
+	original := Default()
+	defer defaultLogger.Store(original)
+
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	SetDefault(config)
+
+	LogServerStopped(nil)
+	LogServerStopped(nil)
+	Default().Info("after")
+
+	var logs []contextLog
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		var cLog contextLog
+		if err := decoder.Decode(&cLog); err != nil {
+			t.Fatal(err)
+		}
+		logs = append(logs, cLog)
+	}
+
+	if len(logs) != 3 {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+	for _, log := range logs {
+		if log.SourceLocation == nil || log.SourceLocation.File != "lifecycle_test.go" {
+			t.Errorf("unexpected source location of %q: %+v", log.Message, log.SourceLocation)
+		}
+	}
+	if logs[0].Severity != "NOTICE" || logs[1].Severity != "NOTICE" {
+		t.Errorf("unexpected severities: %s, %s", logs[0].Severity, logs[1].Severity)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	for key, redacted := range map[string]bool{
+		"password":       true,
+		"apiToken":       true,
+		"access_token":   true,
+		"clientSecret":   true,
+		"X-API-Key":      true,
+		"APIKey":         true,
+		"api_key":        true,
+		"x-apikey":       true,
+		"privateKey":     true,
+		"key":            true,
+		"DB_CREDENTIALS": true,
+		"monkey":         false,
+		"keyword":        false,
+		"primary_key":    false,
+		"sortKey":        false,
+		"tokenizer":      false,
+		"user":           false,
+	} {
+		if got := redact(key, "value") == "[REDACTED]"; got != redacted {
+			t.Errorf("%s: got %v, want %v", key, got, redacted)
+		}
+	}
+}
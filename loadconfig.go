@@ -0,0 +1,153 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// fileConfig is the format of the config file for LoadConfig
+type fileConfig struct {
+	ProjectId            string             `json:"projectId" yaml:"projectId"`
+	Severity             string             `json:"severity" yaml:"severity"`
+	Format               string             `json:"format" yaml:"format"`
+	Sampling             map[string]float64 `json:"sampling" yaml:"sampling"`
+	SkipPaths            []string           `json:"skipPaths" yaml:"skipPaths"`
+	SkipMethods          []string           `json:"skipMethods" yaml:"skipMethods"`
+	SkipStatuses         []int              `json:"skipStatuses" yaml:"skipStatuses"`
+	Labels               map[string]string  `json:"labels" yaml:"labels"`
+	MaxEntriesPerRequest int                `json:"maxEntriesPerRequest" yaml:"maxEntriesPerRequest"`
+	DebugTailSize        int                `json:"debugTailSize" yaml:"debugTailSize"`
+	SingleStream         bool               `json:"singleStream" yaml:"singleStream"`
+	DisableRequestLog    bool               `json:"disableRequestLog" yaml:"disableRequestLog"`
+}
+
+// LoadConfig creates a config from the YAML (.yaml, .yml) or JSON file, so that logging can be tuned
+// without recompiling. Fields which are not in the file have the default values of NewConfig,
+// and unknown fields are errors.
+//
+// "${VAR}" in the file is replaced with the environment variable (e.g. "projectId: ${GOOGLE_CLOUD_PROJECT}"),
+// and it is an error if the variable is unset. "$" without braces is kept as it is (e.g. in regexps), and the environment variables STALOG_PROJECT_ID, STALOG_SEVERITY and STALOG_FORMAT override the file.
+//
+//	projectId: my-gcp-project
+//	severity: INFO
+//	format: json # or console
+//	sampling:
+//	  DEBUG: 0.01
+//	skipPaths:
+//	  - /healthz
+//	labels:
+//	  team: backend
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = expandEnv(b)
+	if err != nil {
+		return nil, fmt.Errorf("stalog: failed to load %s: %w", path, err)
+	}
+
+	var fc fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(b, &fc)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&fc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stalog: failed to parse %s: %w", path, err)
+	}
+	fc.overrideByEnv()
+
+	return fc.config()
+}
+
+// envReference matches "${VAR}" in the config file
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces "${VAR}" with the environment variables and returns an error for the unset variables
+func expandEnv(b []byte) ([]byte, error) {
+	var unset []string
+	expanded := envReference.ReplaceAllFunc(b, func(ref []byte) []byte {
+		name := string(envReference.FindSubmatch(ref)[1])
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return []byte(v)
+	})
+	if len(unset) > 0 {
+		return nil, fmt.Errorf("unset environment variables: %s", strings.Join(unset, ", "))
+	}
+
+	return expanded, nil
+}
+
+// overrideByEnv overrides the fields by the environment variables
+func (fc *fileConfig) overrideByEnv() {
+	if v := os.Getenv("STALOG_PROJECT_ID"); v != "" {
+		fc.ProjectId = v
+	}
+	if v := os.Getenv("STALOG_SEVERITY"); v != "" {
+		fc.Severity = v
+	}
+	if v := os.Getenv("STALOG_FORMAT"); v != "" {
+		fc.Format = v
+	}
+}
+
+func (fc *fileConfig) config() (*Config, error) {
+	config := NewConfig(fc.ProjectId)
+	config.SkipPaths = fc.SkipPaths
+	config.SkipMethods = fc.SkipMethods
+	config.SkipStatuses = fc.SkipStatuses
+	config.Labels = fc.Labels
+	config.MaxEntriesPerRequest = fc.MaxEntriesPerRequest
+	config.DebugTailSize = fc.DebugTailSize
+	config.SingleStream = fc.SingleStream
+	config.DisableRequestLog = fc.DisableRequestLog
+
+	switch strings.ToLower(fc.Format) {
+	case "", "json":
+	case "console":
+		config.Format = FormatConsole
+	default:
+		return nil, fmt.Errorf("stalog: unknown format: %s", fc.Format)
+	}
+
+	if fc.Severity != "" {
+		severity, err := ParseSeverity(fc.Severity)
+		if err != nil {
+			return nil, err
+		}
+		config.Severity = severity
+	}
+
+	if len(fc.Sampling) > 0 {
+		config.SamplingBySeverity = make(map[Severity]float64, len(fc.Sampling))
+		for s, rate := range fc.Sampling {
+			severity, err := ParseSeverity(s)
+			if err != nil {
+				return nil, err
+			}
+			config.SamplingBySeverity[severity] = rate
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,179 @@
+package stalog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"config.yaml": `
+projectId: my-gcp-project
+severity: warning
+sampling:
+  DEBUG: 0.01
+skipPaths:
+  - /healthz
+labels:
+  team: backend
+`,
+		"config.json": `{
+  "projectId": "my-gcp-project",
+  "severity": "WARNING",
+  "sampling": {"DEBUG": 0.01},
+  "skipPaths": ["/healthz"],
+  "labels": {"team": "backend"}
+}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config.ProjectId != "my-gcp-project" || config.Severity != SeverityWarning {
+				t.Errorf("unexpected config: %+v", config)
+			}
+			if !cmp.Equal(config.SamplingBySeverity, map[Severity]float64{SeverityDebug: 0.01}) {
+				t.Errorf("unexpected sampling: %v", config.SamplingBySeverity)
+			}
+			if !cmp.Equal(config.SkipPaths, []string{"/healthz"}) || !cmp.Equal(config.Labels, map[string]string{"team": "backend"}) {
+				t.Errorf("unexpected config: %+v", config)
+			}
+			if config.RequestLogOut != os.Stderr || config.ContextLogOut != os.Stdout {
+				t.Error("outputs must be the default")
+			}
+		})
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("projectId: test\nseverity: LOUD\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("unknown severity must be an error")
+	}
+}
+
+func TestLoadConfigUnknownField(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"config.yaml": "projectId: test\nseverty: INFO\n",
+		"config.json": `{"projectId": "test", "severty": "INFO"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: unknown field must be an error", name)
+		}
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for key, value := range map[string]string{"STALOG_TEST_PROJECT": "from-env", "STALOG_SEVERITY": "ERROR"} {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	content := "projectId: ${STALOG_TEST_PROJECT}\nseverity: INFO\nlabels:\n  price: $5\n  pattern: ^/users/$id$\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ProjectId != "from-env" || config.Severity != SeverityError {
+		t.Errorf("unexpected config: %s, %s", config.ProjectId, config.Severity)
+	}
+	// "$" without braces is not expanded
+	if config.Labels["price"] != "$5" || config.Labels["pattern"] != "^/users/$id$" {
+		t.Errorf("unexpected labels: %v", config.Labels)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("projectId: ${STALOG_TEST_UNSET}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "STALOG_TEST_UNSET") {
+		t.Errorf("unset variable must be an error: %v", err)
+	}
+}
+
+func TestLoadConfigFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for content, want := range map[string]Format{
+		"projectId: test\n":                  FormatJSON,
+		"projectId: test\nformat: json\n":    FormatJSON,
+		"projectId: test\nformat: console\n": FormatConsole,
+	} {
+		path := filepath.Join(dir, "config.yaml")
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Format != want {
+			t.Errorf("%q: unexpected format: %d", content, config.Format)
+		}
+	}
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("projectId: test\nformat: xml\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("unknown format must be an error")
+	}
+}
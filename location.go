@@ -0,0 +1,58 @@
+package stalog
+
+import (
+	"net/http"
+)
+
+// ClientLocation is the location of the client
+type ClientLocation struct {
+	Country     string `json:"country,omitempty"`
+	Subdivision string `json:"subdivision,omitempty"`
+	City        string `json:"city,omitempty"`
+}
+
+// ClientLocationResolver resolves the location of the client. It returns nil if it's unknown.
+type ClientLocationResolver func(r *http.Request) *ClientLocation
+
+// HeaderClientLocationResolver resolves the location from the headers provided by Google Cloud.
+//
+// For External HTTP(S) Load Balancing, configure the custom request headers as follows:
+//
+//	X-Client-Region: {client_region}
+//	X-Client-Region-Subdivision: {client_region_subdivision}
+//	X-Client-City: {client_city}
+//
+// The headers of App Engine (X-AppEngine-Country, X-AppEngine-Region, X-AppEngine-City) are used as fallback.
+func HeaderClientLocationResolver(r *http.Request) *ClientLocation {
+	location := &ClientLocation{
+		Country:     firstHeader(r, "X-Client-Region", "X-AppEngine-Country"),
+		Subdivision: firstHeader(r, "X-Client-Region-Subdivision", "X-AppEngine-Region"),
+		City:        firstHeader(r, "X-Client-City", "X-AppEngine-City"),
+	}
+	if *location == (ClientLocation{}) {
+		return nil
+	}
+
+	return location
+}
+
+// firstHeader returns the first known value of the headers.
+// App Engine uses "ZZ" and "?" for unknown values, and the Load Balancer leaves them empty.
+func firstHeader(r *http.Request, keys ...string) string {
+	for _, key := range keys {
+		if v := r.Header.Get(key); v != "" && v != "?" && v != "ZZ" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// clientLocation resolves the location of the client if ClientLocationResolver is set
+func (c *Config) clientLocation(r *http.Request) *ClientLocation {
+	if c.ClientLocationResolver == nil {
+		return nil
+	}
+
+	return c.ClientLocationResolver(r)
+}
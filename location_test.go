@@ -0,0 +1,55 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHeaderClientLocationResolver(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	if location := HeaderClientLocationResolver(r); location != nil {
+		t.Errorf("unexpected location: %+v", location)
+	}
+
+	r.Header.Set("X-Client-Region", "JP")
+	r.Header.Set("X-AppEngine-Region", "13")
+	r.Header.Set("X-AppEngine-City", "?")
+	expected := &ClientLocation{Country: "JP", Subdivision: "13"}
+	if diff := cmp.Diff(expected, HeaderClientLocationResolver(r)); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+}
+
+func TestClientLocationInRequestLog(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Client-Region", "US")
+	r.Header.Set("X-Client-City", "mountain view")
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.ClientLocationResolver = HeaderClientLocationResolver
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	expected := AdditionalData{
+		"clientLocation": map[string]interface{}{"country": "US", "city": "mountain view"},
+	}
+	if diff := cmp.Diff(expected, httpRequestLog.AdditionalData); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+}
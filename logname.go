@@ -0,0 +1,19 @@
+package stalog
+
+// Audit returns a logger which writes audit logs (e.g. "user deleted") with AuditLogName,
+// so that sinks and exclusions can target them independently of other context logs.
+// The logs are grouped under the request log like the other context logs.
+func (l *ContextLogger) Audit() *ContextLogger {
+	child := *l
+	child.logName = l.config.AuditLogName
+	return &child
+}
+
+// contextLogName returns the log name of the logger's context logs
+func (l *ContextLogger) contextLogName() string {
+	if l.logName != "" {
+		return l.logName
+	}
+
+	return l.config.ContextLogName
+}
@@ -0,0 +1,76 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogNames(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestLogName string
+		contextLogName string
+		auditLogName   string
+		wantRequest    string
+		wantContext    string
+		wantAudit      string
+	}{
+		{name: "default"},
+		{
+			name: "all", requestLogName: "requests", contextLogName: "app", auditLogName: "audit",
+			wantRequest: "requests", wantContext: "app", wantAudit: "audit",
+		},
+		{
+			name: "audit falls back to context", requestLogName: "requests", contextLogName: "app",
+			wantRequest: "requests", wantContext: "app", wantAudit: "app",
+		},
+	}
+
+	for _, tt := range tests {
+		requestLogOut := new(bytes.Buffer)
+		contextLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = contextLogOut
+		config.RequestLogName = tt.requestLogName
+		config.ContextLogName = tt.contextLogName
+		config.AuditLogName = tt.auditLogName
+
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := RequestContextLogger(r)
+			logger.Info("context")
+			logger.Audit().Notice("audit")
+			logger.Info("context again")
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		var requestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+			t.Fatal(err)
+		}
+		if requestLog.LogName != tt.wantRequest {
+			t.Errorf("%s: unexpected request log name: %q", tt.name, requestLog.LogName)
+		}
+
+		logNames := map[string][]string{}
+		for _, line := range strings.Split(strings.TrimSpace(contextLogOut.String()), "\n") {
+			var cLog contextLog
+			if err := json.Unmarshal([]byte(line), &cLog); err != nil {
+				t.Fatal(err)
+			}
+			logNames[cLog.Message] = append(logNames[cLog.Message], cLog.LogName)
+		}
+		for _, msg := range []string{"context", "context again"} {
+			if got := logNames[msg]; len(got) != 1 || got[0] != tt.wantContext {
+				t.Errorf("%s: unexpected log name of %q: %q", tt.name, msg, got)
+			}
+		}
+		if got := logNames["audit"]; len(got) != 1 || got[0] != tt.wantAudit {
+			t.Errorf("%s: unexpected audit log name: %q", tt.name, got)
+		}
+	}
+}
@@ -2,11 +2,9 @@ package stalog
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -15,18 +13,32 @@ import (
 	"go.opencensus.io/trace"
 )
 
-// RequestLogging creates the middleware which logs a request log and creates a request-context logger
+// RequestLogging creates the middleware which logs a request log and creates a request-context logger.
+// It panics if the config is invalid.
 func RequestLogging(config *Config) func(http.Handler) http.Handler {
+	config.mustValidate()
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
+			if nested, ok := config.nestedRequest(r); ok {
+				next.ServeHTTP(w, nested)
+				return
+			}
+
 			reserve := NewReserve(config, r)
 
-			wrw := &wrappedResponseWriter{ResponseWriter: w}
+			wrw := &wrappedResponseWriter{ResponseWriter: w, logger: reserve.contextLogger}
+			// logging, which runs after recoverPanic re-panics http.ErrAbortHandler
+			defer reserve.LastHandling(wrw)
 			defer func() {
-				// logging
-				reserve.LastHandling(wrw)
+				if config.Recover {
+					if v := recover(); v != nil {
+						reserve.recoverPanic(wrw, v)
+					}
+				}
 			}()
 
+			reserve.setTraceResponseHeader(w)
 			next.ServeHTTP(wrw, reserve.request)
 		}
 
@@ -34,54 +46,125 @@ func RequestLogging(config *Config) func(http.Handler) http.Handler {
 	}
 }
 
-// RequestLoggingWithEcho creates the middleware which logs a request log and creates a request-context logger
+// RequestLoggingWithEcho creates the middleware which logs a request log and creates a request-context logger.
+// It panics if the config is invalid.
 func RequestLoggingWithEcho(config *Config) echo.MiddlewareFunc {
+	config.mustValidate()
+	routeNames := &echoRouteNames{}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if nested, ok := config.nestedRequest(c.Request()); ok {
+				c.SetRequest(nested)
+				c.Set(EchoContextLoggerKey, RequestContextLogger(nested))
+				return next(c)
+			}
+
 			reserve := NewReserve(config, c.Request())
 
 			wrw := &wrappedResponseWriter{
 				ResponseWriter: c.Response().Writer,
+				logger:         reserve.contextLogger,
 			}
 			wr := echo.NewResponse(wrw, c.Echo())
+			// logging, which runs after recoverPanic re-panics http.ErrAbortHandler
+			defer reserve.LastHandling(wrw)
 			defer func() {
-				// logging
-				reserve.LastHandling(wrw)
+				if config.Recover {
+					if v := recover(); v != nil {
+						reserve.recoverPanic(wrw, v)
+					}
+				}
 			}()
 
+			reserve.setTraceResponseHeader(wrw)
 			c.SetRequest(reserve.request)
 			c.SetResponse(wr)
+			c.Set(EchoContextLoggerKey, reserve.contextLogger)
+
+			err := next(c)
+			if err != nil {
+				// write the error response before the request log to log the status
+				c.Error(err)
+			}
 
-			return next(c)
+			switch err {
+			case echo.ErrNotFound:
+				// c.Path() is the request path when no route matches
+				reserve.unmatched = true
+			case echo.ErrMethodNotAllowed:
+				reserve.unmatched = true
+				reserve.routePattern = c.Path()
+			default:
+				reserve.routePattern = c.Path()
+				reserve.routeParams = echoParams(c)
+				if name := routeNames.handlerName(c); reserve.contextLogger.handlerName() == "" && !isAnonymousFunc(name) {
+					reserve.contextLogger.setHandlerName(name)
+				}
+			}
+			return err
 		}
 	}
 }
 
-// RequestLoggingWithFunc for WebHook
+// RequestLoggingWithFunc for WebHook.
+// It panics if the config is invalid like RequestLogging.
 func RequestLoggingWithFunc(config *Config, w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	config.mustValidate()
+	if nested, ok := config.nestedRequest(r); ok {
+		next.ServeHTTP(w, nested)
+		return
+	}
+
 	reserve := NewReserve(config, r)
 
-	wrw := &wrappedResponseWriter{ResponseWriter: w}
+	wrw := &wrappedResponseWriter{ResponseWriter: w, logger: reserve.contextLogger}
+	// logging, which runs after recoverPanic re-panics http.ErrAbortHandler
+	defer reserve.LastHandling(wrw)
 	defer func() {
-		// logging
-		reserve.LastHandling(wrw)
+		if config.Recover {
+			if v := recover(); v != nil {
+				reserve.recoverPanic(wrw, v)
+			}
+		}
 	}()
 
-	next.ServeHTTP(w, reserve.request)
+	reserve.setTraceResponseHeader(w)
+	next.ServeHTTP(wrw, reserve.request)
 }
 
+// Reserve is the state of a request between NewReserve and LastHandling, which the middlewares use.
+// Use Tracker to group logs on other servers.
 type Reserve struct {
 	before        time.Time
 	config        *Config
 	contextLogger *ContextLogger
 	request       *http.Request
 	traces        string
+	span          *trace.Span
+	routePattern  string
+	routeParams   map[string]string
+	unmatched     bool
+	body          *captureBody
+	multipart     *multipartBody
 }
 
+// NewReserve extracts the trace of the request and creates the request-context logger
 func NewReserve(config *Config, r *http.Request) *Reserve {
 	before := time.Now()
 
-	traceId := getTraceId(r)
+	var span *trace.Span
+	var traceId, spanId string
+	var sampled bool
+	if config.StartSpan {
+		var ctx context.Context
+		ctx, span = startServerSpan(config, r)
+		sc := span.SpanContext()
+		traceId, spanId, sampled = sc.TraceID.String(), sc.SpanID.String(), sc.IsSampled()
+		r = r.WithContext(ctx)
+	} else {
+		traceId, spanId, sampled = getTraceId(config, r)
+	}
 	if traceId == "" {
 		// there is no span yet, so create one
 		var ctx context.Context
@@ -89,48 +172,147 @@ func NewReserve(config *Config, r *http.Request) *Reserve {
 		r = r.WithContext(ctx)
 	}
 
-	traces := fmt.Sprintf("projects/%s/traces/%s", config.ProjectId, traceId)
-
-	contextLogger := &ContextLogger{
-		out:            config.ContextLogOut,
-		Trace:          traces,
-		Severity:       config.Severity,
-		AdditionalData: config.AdditionalData,
-		loggedSeverity: make([]Severity, 0, 10),
-		Skip:           config.Skip,
+	traces := config.traceResource(r, traceId)
+
+	contextLogger := newContextLogger(config, traces, traceId)
+	contextLogger.request = r
+	contextLogger.spanId = spanId
+	contextLogger.traceSampled = sampled
+	contextLogger.extractLabels(r)
+	contextLogger.extractBaggage(r)
+	if config.SeverityResolver != nil {
+		if severity := config.SeverityResolver(r); severity != config.Severity {
+			contextLogger.Severity = severity
+			contextLogger.severityResolved = true
+		}
+	}
+	if config.TenantExtractor != nil {
+		contextLogger.state.tenant = config.TenantExtractor(r)
 	}
-	ctx := context.WithValue(r.Context(), ContextLoggerKey, contextLogger)
+	ctx := ContextWithLogger(r.Context(), contextLogger)
 
-	return &Reserve{
+	rv := &Reserve{
 		before:        before,
 		config:        config,
 		contextLogger: contextLogger,
 		request:       r.WithContext(ctx),
 		traces:        traces,
+		span:          span,
 	}
+	if config.CaptureRequestBody > 0 && r.Body != nil && r.Body != http.NoBody {
+		rv.body = &captureBody{ReadCloser: r.Body, limit: config.CaptureRequestBody}
+		rv.request.Body = rv.body
+	}
+	if config.LogMultipartSummary && r.Body != nil && r.Body != http.NoBody {
+		if rv.multipart = newMultipartBody(rv.request); rv.multipart != nil {
+			rv.request.Body = rv.multipart
+		}
+	}
+
+	return rv
 }
 
+// LastHandling writes the request log after the handler returns
 func (rv *Reserve) LastHandling(wrw *wrappedResponseWriter) {
 	elapsed := time.Since(rv.before)
-	maxSeverity := rv.contextLogger.maxSeverity()
-	err := writeRequestLog(rv.request, rv.config, wrw.status, wrw.responseSize, elapsed, rv.traces, maxSeverity)
+	rv.endServerSpan(wrw.status, elapsed)
+	rv.contextLogger.flushSuppressed()
+	if rv.multipart != nil {
+		rv.multipart.finish()
+	}
+	grpcCode, isGRPC := grpcStatus(rv.request, wrw.Header())
+	if isGRPC {
+		rv.contextLogger.state.raise(rv.config.grpcSeverity(grpcCode))
+	}
+	if rv.config.RouteSummary != nil {
+		severity := rv.contextLogger.MaxSeverity()
+		if s := statusSeverity(wrw.status); s > severity {
+			severity = s
+		}
+		rv.config.RouteSummary.record(rv.request.Method, rv.summaryRoute(), wrw.status, severity, elapsed)
+	}
+	if rv.skipRequestLog(wrw.status) {
+		return
+	}
+
+	flushContextLog(rv.config)
+	maxSeverity := rv.contextLogger.MaxSeverity()
+	requestLog := newRequestLog(rv.request, rv.config, wrw.status, wrw.responseSize, elapsed, rv.traces, maxSeverity)
+	requestLog.SpanID = rv.contextLogger.spanId
+	requestLog.Labels = rv.contextLogger.currentLabels()
+	bodySize := wrw.compressionSizes(requestLog)
+	if rv.config.IncludeHeadersInSize {
+		requestLog.HTTPRequest.RequestSize = fmt.Sprintf("%d", requestSizeWithHeaders(rv.request))
+		requestLog.HTTPRequest.ResponseSize = fmt.Sprintf("%d", responseSizeWithHeaders(rv.request.Proto, wrw.status, wrw.Header(), bodySize))
+	}
+	if isPreflight(rv.request) {
+		preflight(requestLog, maxSeverity)
+	}
+	requestLog.AdditionalData = mergeData(requestLog.AdditionalData, wrw.streamData(rv.before))
+	if rv.routePattern != "" {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, rv.routeData())
+	}
+	requestLog.AdditionalData = mergeData(requestLog.AdditionalData, rv.fallthroughData(wrw))
+	requestLog.AdditionalData = mergeData(requestLog.AdditionalData, rv.contextLogger.timingsData())
+	requestLog.AdditionalData = mergeData(requestLog.AdditionalData, rv.contextLogger.subrequestsData())
+	if isGRPC {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, AdditionalData{"grpcStatus": grpcCode})
+	}
+	if rv.body != nil {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, rv.body.requestBodyData(rv.request.Header.Get("Content-Type"), rv.config.CaptureTextRequestBody))
+	}
+	if rv.multipart != nil {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, rv.multipart.multipartData())
+	}
+	requestLog.AdditionalData = mergeData(requestLog.AdditionalData, wrw.errorBodyData())
+	if name := rv.contextLogger.handlerName(); name != "" {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, AdditionalData{"handler": name})
+	}
+	if rv.config.LogLatencyBreakdown {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, wrw.latencyBreakdown(rv.before, elapsed))
+	}
+	if ua := rv.config.userAgent(rv.request); ua != nil {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, AdditionalData{"userAgent": ua})
+	}
+	if location := rv.config.clientLocation(rv.request); location != nil {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, AdditionalData{"clientLocation": location})
+	}
+	if info := rv.config.tlsInfo(rv.request); info != nil {
+		requestLog.AdditionalData = mergeData(requestLog.AdditionalData, AdditionalData{"tls": info})
+	}
+	requestLog.TraceSampled = rv.contextLogger.traceSampled
+	err := writeTenantRequestLog(rv.config, requestLog, rv.contextLogger.tenant())
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		rv.config.reportError(err)
 	}
 }
 
-func getTraceId(r *http.Request) string {
+func getTraceId(config *Config, r *http.Request) (traceId string, spanId string, sampled bool) {
+	continued := config.continueTrace(r)
+	if config.TraceExtractor != nil && continued {
+		if traceId, spanId, sampled, ok := config.TraceExtractor(r); ok {
+			if traceId, spanId, ok := normalizeIncomingTrace(traceId, spanId); ok {
+				return traceId, spanId, sampled
+			}
+		}
+	}
+
 	span := trace.FromContext(r.Context())
 	if span != nil {
-		return span.SpanContext().TraceID.String()
+		sc := span.SpanContext()
+		return sc.TraceID.String(), sc.SpanID.String(), sc.IsSampled()
 	}
 
-	httpFormat := &propagation.HTTPFormat{}
-	if sc, ok := httpFormat.SpanContextFromRequest(r); ok {
-		return sc.TraceID.String()
+	if continued {
+		httpFormat := &propagation.HTTPFormat{}
+		if sc, ok := httpFormat.SpanContextFromRequest(r); ok {
+			if traceId, spanId, ok := normalizeIncomingTrace(sc.TraceID.String(), sc.SpanID.String()); ok {
+				return traceId, spanId, sc.IsSampled()
+			}
+		}
 	}
 
-	return ""
+	return "", "", false
 }
 
 func generateTraceId(r *http.Request) (string, context.Context) {
@@ -141,21 +323,47 @@ func generateTraceId(r *http.Request) (string, context.Context) {
 
 type wrappedResponseWriter struct {
 	http.ResponseWriter
-	status       int
-	responseSize int
+	logger           *ContextLogger
+	status           int
+	responseSize     int
+	uncompressedSize int
+	firstWriteAt     time.Time
+	firstByteAt      time.Time
+	streamed         bool
+	errorBody        []byte
 }
 
 func (w *wrappedResponseWriter) WriteHeader(status int) {
-	w.status = status
+	w.setStatus(status)
 	w.ResponseWriter.WriteHeader(status)
 }
 
+func (w *wrappedResponseWriter) setStatus(status int) {
+	if w.firstWriteAt.IsZero() {
+		w.firstWriteAt = time.Now()
+		w.setServerTiming(w.firstWriteAt)
+	}
+	w.status = status
+	if w.logger != nil {
+		w.logger.setStatus(status)
+	}
+}
+
 func (w *wrappedResponseWriter) Write(b []byte) (int, error) {
 	if w.status == 0 {
-		w.status = http.StatusOK
+		w.setStatus(http.StatusOK)
+	}
+	if len(b) > 0 {
+		if w.firstByteAt.IsZero() {
+			w.firstByteAt = time.Now()
+		} else {
+			// the body is written in pieces
+			w.streamed = true
+		}
 	}
 	n, err := w.ResponseWriter.Write(b)
 	w.responseSize += n
+	w.captureErrorBody(b[:n])
 	return n, err
 }
 
@@ -177,18 +385,33 @@ type HTTPRequest struct {
 }
 
 type HTTPRequestLog struct {
-	Time           string         `json:"time"`
-	Trace          string         `json:"logging.googleapis.com/trace"`
-	Severity       string         `json:"severity"`
-	HTTPRequest    HTTPRequest    `json:"httpRequest"`
-	AdditionalData AdditionalData `json:"data,omitempty"`
+	Time           string            `json:"time"`
+	Trace          string            `json:"logging.googleapis.com/trace"`
+	SpanID         string            `json:"logging.googleapis.com/spanId,omitempty"`
+	TraceSampled   bool              `json:"logging.googleapis.com/trace_sampled,omitempty"`
+	LogName        string            `json:"logging.googleapis.com/logName,omitempty"`
+	Severity       string            `json:"severity"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
+	HTTPRequest    HTTPRequest       `json:"httpRequest"`
+	ServiceContext *ServiceContext   `json:"serviceContext,omitempty"`
+	AdditionalData AdditionalData    `json:"data,omitempty"`
 }
 
-func writeRequestLog(r *http.Request, config *Config, status int, responseSize int, elapsed time.Duration, trace string, severity Severity) error {
-	requestLog := &HTTPRequestLog{
-		Time:     time.Now().Format(time.RFC3339Nano),
+// BuildRequestLog creates the request log with the same fields as the middlewares,
+// so that custom servers and adapters for other frameworks can emit compatible request logs.
+// traceId is formatted as "projects/[PROJECT_ID]/traces/[TRACE_ID]" with the project of the trace.
+func BuildRequestLog(config *Config, r *http.Request, status int, responseSize int, elapsed time.Duration, traceId string, severity Severity) *HTTPRequestLog {
+	traces := config.traceResource(r, traceId)
+	return newRequestLog(r, config, status, responseSize, elapsed, traces, severity)
+}
+
+func newRequestLog(r *http.Request, config *Config, status int, responseSize int, elapsed time.Duration, trace string, severity Severity) *HTTPRequestLog {
+	return &HTTPRequestLog{
+		Time:     config.logTime(),
 		Trace:    trace,
+		LogName:  config.RequestLogName,
 		Severity: severity.String(),
+		Labels:   config.labels(),
 		HTTPRequest: HTTPRequest{
 			RequestMethod:                  r.Method,
 			RequestUrl:                     r.URL.RequestURI(),
@@ -203,12 +426,30 @@ func writeRequestLog(r *http.Request, config *Config, status int, responseSize i
 			CacheLookup:                    false,
 			CacheHit:                       false,
 			CacheValidatedWithOriginServer: false,
-			Protocol:                       r.Proto,
+			Protocol:                       config.protocol(r),
 		},
+		ServiceContext: config.serviceContext(),
 		AdditionalData: config.AdditionalData,
 	}
+}
 
-	jsonByte, err := json.Marshal(requestLog)
+func writeRequestLog(config *Config, requestLog *HTTPRequestLog) error {
+	return writeTenantRequestLog(config, requestLog, "")
+}
+
+// writeTenantRequestLog writes the request log and attributes it to the tenant in TenantUsage
+func writeTenantRequestLog(config *Config, requestLog *HTTPRequestLog, tenant string) error {
+	if config.Format == FormatConsole {
+		b := requestLog.console()
+		_, err := config.afterContextLogs(config.requestLogOutFor(requestLog)).Write(b)
+		if err == nil {
+			config.TenantUsage.record(tenant, len(b))
+		}
+		return err
+	}
+
+	requestLog.AdditionalData = limitDataDepth(requestLog.AdditionalData)
+	jsonByte, err := config.marshalLog(requestLog, &requestLog.AdditionalData)
 	if err != nil {
 		return err
 	}
@@ -216,11 +457,19 @@ func writeRequestLog(r *http.Request, config *Config, status int, responseSize i
 	// append \n
 	jsonByte = append(jsonByte, 0xa)
 
-	_, err = config.RequestLogOut.Write(jsonByte)
+	_, err = config.afterContextLogs(config.requestLogOutFor(requestLog)).Write(jsonByte)
+	if err == nil {
+		config.TenantUsage.record(tenant, len(jsonByte))
+	}
 	return err
 }
 
 func getRemoteIP(r *http.Request) string {
+	// RemoteAddr can be IPv6 (e.g. "[::1]:443" of QUIC connections)
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
 	parts := strings.Split(r.RemoteAddr, ":")
 	return parts[0]
 }
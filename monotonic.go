@@ -0,0 +1,42 @@
+package stalog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// lastLogTime is the time of the last log in Unix nanoseconds for MonotonicTime
+var lastLogTime int64
+
+// logTime returns the time of a new log in RFC3339 with nanoseconds
+func (c *Config) logTime() string {
+	now := time.Now()
+	if c.MonotonicTime {
+		now = monotonicTime(now)
+	}
+
+	return now.Format(time.RFC3339Nano)
+}
+
+// logTime returns the time of a new log of the logger, which is the timestamp of the logger if it is set
+func (l *ContextLogger) logTime() string {
+	if !l.timestamp.IsZero() {
+		return l.timestamp.Format(time.RFC3339Nano)
+	}
+
+	return l.config.logTime()
+}
+
+// monotonicTime returns now, or 1ns after the time of the last log in the process if now isn't after it
+func monotonicTime(now time.Time) time.Time {
+	for {
+		last := atomic.LoadInt64(&lastLogTime)
+		t := now.UnixNano()
+		if t <= last {
+			t = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastLogTime, last, t) {
+			return time.Unix(0, t).In(now.Location())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package stalog
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMonotonicTime(t *testing.T) {
+	now := time.Now()
+	first := monotonicTime(now)
+	second := monotonicTime(now)
+	third := monotonicTime(now.Add(-time.Second))
+	if !second.After(first) || !third.After(second) || second.Sub(first) != time.Nanosecond {
+		t.Errorf("not monotonic: %s, %s, %s", first, second, third)
+	}
+
+	config := NewConfig("test")
+	config.MonotonicTime = true
+	prev, _ := time.Parse(time.RFC3339Nano, config.logTime())
+	for i := 0; i < 100; i++ {
+		next, _ := time.Parse(time.RFC3339Nano, config.logTime())
+		if !next.After(prev) {
+			t.Fatalf("not monotonic: %s, %s", prev, next)
+		}
+		prev = next
+	}
+
+	// the time after the last log is kept as it is
+	defer atomic.StoreInt64(&lastLogTime, 0)
+	later := now.Add(time.Hour)
+	if got := monotonicTime(later); !got.Equal(later) {
+		t.Errorf("got %s, want %s", got, later)
+	}
+}
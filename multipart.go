@@ -0,0 +1,95 @@
+package stalog
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+)
+
+// multipartPart is the summary of a part of the multipart request
+type multipartPart struct {
+	Name        string `json:"name"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+// multipartBody parses the multipart body read by the handler in the background,
+// and counts the sizes of the parts without keeping their contents
+type multipartBody struct {
+	io.ReadCloser
+	pw    *io.PipeWriter
+	done  chan struct{}
+	parts []multipartPart
+}
+
+// newMultipartBody returns the body which summarizes the parts, or nil if the request isn't multipart/form-data
+func newMultipartBody(r *http.Request) *multipartBody {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil
+	}
+
+	pr, pw := io.Pipe()
+	b := &multipartBody{ReadCloser: r.Body, pw: pw, done: make(chan struct{})}
+	go b.parse(multipart.NewReader(pr, params["boundary"]), pr)
+
+	return b
+}
+
+func (b *multipartBody) parse(mr *multipart.Reader, pr *io.PipeReader) {
+	defer close(b.done)
+
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			// stop the writes of the rest
+			_ = pr.CloseWithError(err)
+			return
+		}
+
+		size, _ := io.Copy(ioutil.Discard, part)
+		b.parts = append(b.parts, multipartPart{
+			Name:        part.FormName(),
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Size:        size,
+		})
+	}
+}
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = b.pw.Write(p[:n])
+	}
+	if err != nil {
+		_ = b.pw.Close()
+	}
+
+	return n, err
+}
+
+// finish stops parsing and waits for the parser, even if the handler hasn't read the whole body
+func (b *multipartBody) finish() {
+	_ = b.pw.Close()
+	<-b.done
+}
+
+// multipartData returns the summary of the parts read by the handler for the request log's data
+func (b *multipartBody) multipartData() AdditionalData {
+	b.finish()
+
+	var total int64
+	for _, p := range b.parts {
+		total += p.Size
+	}
+
+	return AdditionalData{"multipart": map[string]interface{}{
+		"parts":     b.parts,
+		"count":     len(b.parts),
+		"totalSize": total,
+	}}
+}
@@ -0,0 +1,73 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogMultipartSummary(t *testing.T) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	_ = mw.WriteField("title", "holiday")
+	fw, _ := mw.CreateFormFile("photo", "beach.jpg")
+	_, _ = fw.Write(bytes.Repeat([]byte{0xff}, 1000))
+	_ = mw.Close()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.LogMultipartSummary = true
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+		}
+		if r.FormValue("title") != "holiday" {
+			t.Errorf("got %s", r.FormValue("title"))
+		}
+	}))
+	r, _ := http.NewRequest("POST", "/upload", bytes.NewReader(body.Bytes()))
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var requestLog struct {
+		Data struct {
+			Multipart struct {
+				Parts     []multipartPart `json:"parts"`
+				Count     int             `json:"count"`
+				TotalSize int64           `json:"totalSize"`
+			} `json:"multipart"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+		t.Fatal(err)
+	}
+	m := requestLog.Data.Multipart
+	if m.Count != 2 || m.TotalSize != 1007 || m.Parts[0].Name != "title" || m.Parts[0].Size != 7 ||
+		m.Parts[1].Name != "photo" || m.Parts[1].Filename != "beach.jpg" || m.Parts[1].ContentType != "application/octet-stream" ||
+		m.Parts[1].Size != 1000 {
+		t.Errorf("got %+v", m)
+	}
+	if strings.Contains(requestLogOut.String(), "holiday") {
+		t.Errorf("the content is logged: %s", requestLogOut.String())
+	}
+
+	// the handler which doesn't read the body
+	requestLogOut.Reset()
+	handler = RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 10))
+	}))
+	r, _ = http.NewRequest("POST", "/upload", bytes.NewReader(body.Bytes()))
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if !strings.Contains(requestLogOut.String(), `"multipart":{"count":0`) {
+		t.Errorf("got %s", requestLogOut.String())
+	}
+}
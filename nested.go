@@ -0,0 +1,60 @@
+package stalog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// NestedPolicy is the behavior of the middleware when the request already has the request-context logger,
+// e.g. when the middleware is applied to both the gateway router and the subrouter
+type NestedPolicy int
+
+const (
+	// NestedReuse reuses the logger of the outer middleware, and only the outer middleware writes the request log
+	NestedReuse NestedPolicy = iota
+	// NestedChildOperation creates a child logger of the outer logger with a new operation ID,
+	// so that the logs of the inner router are distinguishable. Only the outer middleware writes the request log.
+	NestedChildOperation
+	// NestedSeparate creates another logger and writes another request log as if there was no outer middleware
+	NestedSeparate
+)
+
+// nestedRequest returns the request for the handler if the request already has the logger of an outer middleware.
+// It returns false if the middleware should log the request by itself.
+func (c *Config) nestedRequest(r *http.Request) (*http.Request, bool) {
+	outer := RequestContextLogger(r)
+	logger, ok := c.nestedLogger(outer)
+	if !ok {
+		return nil, false
+	}
+	if logger == outer {
+		return r, true
+	}
+
+	return r.WithContext(ContextWithLogger(r.Context(), logger)), true
+}
+
+// nestedLogger returns the logger for the handler if outer is the logger of an outer middleware.
+// It returns false if the middleware should log the request by itself.
+func (c *Config) nestedLogger(outer *ContextLogger) (*ContextLogger, bool) {
+	if outer == nil {
+		return nil, false
+	}
+
+	switch c.Nested {
+	case NestedReuse:
+		return outer, true
+	case NestedChildOperation:
+		return outer.Child(newOperationID()), true
+	default:
+		return nil, false
+	}
+}
+
+// newOperationID generates a random operation ID
+func newOperationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
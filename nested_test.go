@@ -0,0 +1,63 @@
+package stalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNested(t *testing.T) {
+	for name, middleware := range map[string]func(*Config) func(http.Handler) http.Handler{
+		"RequestLogging":      RequestLogging,
+		"RequestLoggingHTTP3": RequestLoggingHTTP3,
+	} {
+		testNested(t, name, middleware)
+	}
+}
+
+func testNested(t *testing.T, name string, middleware func(*Config) func(http.Handler) http.Handler) {
+	for _, tt := range []struct {
+		policy      NestedPolicy
+		requestLogs int
+		sameLogger  bool
+		operation   bool
+	}{
+		{NestedReuse, 1, true, false},
+		{NestedChildOperation, 1, false, true},
+		{NestedSeparate, 2, false, false},
+	} {
+		requestLogOut := new(bytes.Buffer)
+		contextLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = contextLogOut
+		config.Nested = tt.policy
+
+		var outer, inner *ContextLogger
+		innerHandler := middleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inner = RequestContextLogger(r)
+			inner.Warning("inner")
+		}))
+		handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			outer = RequestContextLogger(r)
+			innerHandler.ServeHTTP(w, r)
+		}))
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		if n := strings.Count(requestLogOut.String(), "\n"); n != tt.requestLogs {
+			t.Errorf("%s %d: got %d request logs", name, tt.policy, n)
+		}
+		if (outer == inner) != tt.sameLogger {
+			t.Errorf("%s %d: unexpected logger", name, tt.policy)
+		}
+		if strings.Contains(contextLogOut.String(), `"logging.googleapis.com/operation"`) != tt.operation {
+			t.Errorf("%s %d: unexpected operation: %s", name, tt.policy, contextLogOut.String())
+		}
+		if tt.policy != NestedSeparate && outer.MaxSeverity() != SeverityWarning {
+			t.Errorf("%s %d: severity isn't rolled up: %s", name, tt.policy, outer.MaxSeverity())
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package stalog
+
+// Operation is the operation which the log belongs to. More details:
+// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#LogEntryOperation
+type Operation struct {
+	ID       string `json:"id"`
+	Producer string `json:"producer,omitempty"`
+	First    bool   `json:"first,omitempty"`
+	Last     bool   `json:"last,omitempty"`
+}
+
+// Child returns a logger for the sub-task of the request like fan-out work.
+// Its logs share the trace of the request and have the operation ID of the sub-task,
+// so that each sub-task is navigable in Logs Explorer. Severities of its logs are
+// rolled up into the request log.
+func (l *ContextLogger) Child(operationId string) *ContextLogger {
+	child := *l
+	child.operation = &Operation{ID: operationId}
+	return &child
+}
@@ -0,0 +1,41 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestChild(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	logger := newContextLogger(config, "projects/test/traces/1", "1")
+
+	logger.Child("task-1").Errorf("failed")
+	logger.Infof("done")
+
+	logs := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+
+	var child, parent contextLog
+	if err := json.Unmarshal([]byte(logs[0]), &child); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(logs[1]), &parent); err != nil {
+		t.Fatal(err)
+	}
+
+	if child.Operation == nil || child.Operation.ID != "task-1" || child.Trace != parent.Trace {
+		t.Errorf("unexpected child log: %s", logs[0])
+	}
+	if parent.Operation != nil {
+		t.Errorf("unexpected parent log: %s", logs[1])
+	}
+	if logger.MaxSeverity() != SeverityError {
+		t.Errorf("severity of the child must be rolled up: %s", logger.MaxSeverity())
+	}
+}
@@ -0,0 +1,57 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// stableFieldOrder is the order of the first fields of the logs with Config.StableOrder
+var stableFieldOrder = []string{"time", "severity", "message"}
+
+// stableOrder reorders the top-level fields of the encoded log: "time", "severity" and "message" first,
+// then the other fields sorted by the key, and dataKey last. The nested objects are sorted by encoding/json.
+func stableOrder(b []byte, dataKey string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return stableFieldRank(keys[i], dataKey) < stableFieldRank(keys[j], dataKey) ||
+			(stableFieldRank(keys[i], dataKey) == stableFieldRank(keys[j], dataKey) && keys[i] < keys[j])
+	})
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(b)))
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(fields[k])
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// stableFieldRank returns the rank of the key in the order of stableOrder
+func stableFieldRank(key string, dataKey string) int {
+	for i, k := range stableFieldOrder {
+		if k == key {
+			return i
+		}
+	}
+	if key == dataKey {
+		return len(stableFieldOrder) + 1
+	}
+
+	return len(stableFieldOrder)
+}
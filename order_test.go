@@ -0,0 +1,50 @@
+package stalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStableOrder(t *testing.T) {
+	contextLogOut := new(bytes.Buffer)
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = contextLogOut
+	config.RequestLogOut = requestLogOut
+	config.StableOrder = true
+	config.PromoteFields = []string{"service"}
+	config.AdditionalData = AdditionalData{"service": "api", "zone": "a", "b": map[string]interface{}{"y": 1, "x": 2}}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Info("hello")
+	}))
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	line := contextLogOut.String()
+	if !strings.HasPrefix(line, `{"time":`) {
+		t.Errorf("time isn't first: %s", line)
+	}
+	order := []string{`"time":`, `"severity":"INFO"`, `"message":"hello"`, `"logging.googleapis.com/sourceLocation":`,
+		`"logging.googleapis.com/trace":`, `"service":"api"`, `"data":{"b":{"x":2,"y":1},"zone":"a"}}`}
+	last := -1
+	for _, s := range order {
+		i := strings.Index(line, s)
+		if i <= last {
+			t.Errorf("%s is out of order: %s", s, line)
+		}
+		last = i
+	}
+	if !strings.HasSuffix(line, "}\n") {
+		t.Errorf("not a line: %q", line)
+	}
+
+	line = requestLogOut.String()
+	if !strings.HasPrefix(line, `{"time":`) || strings.Index(line, `"httpRequest":`) > strings.Index(line, `"logging.googleapis.com/trace":`) {
+		t.Errorf("out of order: %s", line)
+	}
+}
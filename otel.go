@@ -0,0 +1,105 @@
+package stalog
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// OTelRecord is a log record of the OpenTelemetry Logs Bridge API (go.opentelemetry.io/otel/log).
+// The otel/log module requires a newer Go than this module, so the implementation of log.Logger is
+// in the separate module github.com/gcp-kit/stalog/otellog, which forwards each record to OTelLogger.Emit.
+type OTelRecord struct {
+	Timestamp      time.Time
+	SeverityNumber int
+	SeverityText   string
+	Body           interface{}
+	Attributes     map[string]interface{}
+}
+
+// OTelLogger emits the records of the OpenTelemetry Logs Bridge API as context logs.
+// The records are grouped by the trace of the request-context logger in the context,
+// so the logs of instrumented libraries appear in the same stream as the logs of the application.
+type OTelLogger struct {
+	name     string
+	fallback *ContextLogger
+	skip     int
+}
+
+// NewOTelLogger creates the bridge for the instrumentation scope name.
+// The records emitted without request-context logger in the context are written by the logger created from the config.
+func NewOTelLogger(config *Config, name string) *OTelLogger {
+	return &OTelLogger{
+		name:     name,
+		fallback: newDefaultLogger(config),
+	}
+}
+
+// WithSkip returns the copy of the bridge which skips more frames for the source location,
+// so that the wrappers of Emit (e.g. the implementation of log.Logger) report their callers
+func (l *OTelLogger) WithSkip(skip int) *OTelLogger {
+	child := *l
+	child.skip += skip
+	return &child
+}
+
+// Enabled reports whether the record at the severity number would be written for the context
+func (l *OTelLogger) Enabled(ctx context.Context, severityNumber int) bool {
+	return OTelSeverity(severityNumber) >= l.logger(ctx).minSeverity()
+}
+
+// Emit writes the record as a context log at the timestamp of the record (or the current time if it is zero).
+// The attributes are added to data, and the scope name is added as "logger".
+func (l *OTelLogger) Emit(ctx context.Context, record OTelRecord) {
+	fields := make([]Field, 0, len(record.Attributes)+1)
+	for k, v := range record.Attributes {
+		fields = append(fields, Any(k, v))
+	}
+	if l.name != "" {
+		fields = append(fields, String("logger", l.name))
+	}
+
+	logger := l.logger(ctx).WithFields(fields...)
+	logger.Skip += l.skip
+	logger.timestamp = record.Timestamp
+
+	var msg string
+	switch body := record.Body.(type) {
+	case nil:
+	case string:
+		msg = body
+	default:
+		msg = fmt.Sprint(body)
+	}
+
+	_ = logger.write(OTelSeverity(record.SeverityNumber), msg)
+}
+
+// logger returns the request-context logger in the context or the fallback logger
+func (l *OTelLogger) logger(ctx context.Context) *ContextLogger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(ContextLoggerKey).(*ContextLogger); ok {
+			return logger
+		}
+	}
+
+	return l.fallback
+}
+
+// OTelSeverity converts the severity number of OpenTelemetry (1-24) to Severity
+func OTelSeverity(severityNumber int) Severity {
+	switch {
+	case severityNumber <= 0:
+		return SeverityDefault
+	case severityNumber <= 8: // TRACE, DEBUG
+		return SeverityDebug
+	case severityNumber <= 12: // INFO
+		return SeverityInfo
+	case severityNumber <= 16: // WARN
+		return SeverityWarning
+	case severityNumber <= 20: // ERROR
+		return SeverityError
+	default: // FATAL
+		return SeverityCritical
+	}
+}
@@ -0,0 +1,87 @@
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOTelLogger(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = out
+	config.Severity = SeverityInfo
+
+	bridge := NewOTelLogger(config, "github.com/example/lib")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bridge.Enabled(r.Context(), 5) {
+			t.Error("DEBUG should be disabled")
+		}
+		bridge.Emit(r.Context(), OTelRecord{
+			SeverityNumber: 17,
+			Body:           "query failed",
+			Attributes:     map[string]interface{}{"db": "users"},
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	RequestLogging(config)(handler).ServeHTTP(w, r)
+
+	var cLog contextLog
+	if err := json.Unmarshal(out.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "ERROR" || cLog.Message != "query failed" {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+	if cLog.Trace != "projects/test/traces/105445aa7843bc8bf206b12000100000" {
+		t.Errorf("unexpected trace: %s", cLog.Trace)
+	}
+	if cLog.SourceLocation == nil || cLog.SourceLocation.File != "otel_test.go" {
+		t.Errorf("unexpected source location: %+v", cLog.SourceLocation)
+	}
+	if cLog.AdditionalData["db"] != "users" || cLog.AdditionalData["logger"] != "github.com/example/lib" {
+		t.Errorf("unexpected data: %+v", cLog.AdditionalData)
+	}
+
+	// without request-context logger
+	out.Reset()
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	bridge.Emit(context.Background(), OTelRecord{Timestamp: timestamp, SeverityNumber: 9, Body: 42})
+	cLog = contextLog{}
+	if err := json.Unmarshal(out.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "INFO" || cLog.Message != "42" || cLog.Trace != "" {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+	if cLog.Time != "2024-01-02T03:04:05.000000006Z" {
+		t.Errorf("the timestamp of the record must be used: %s", cLog.Time)
+	}
+}
+
+func TestOTelSeverity(t *testing.T) {
+	tests := map[int]Severity{
+		0:  SeverityDefault,
+		1:  SeverityDebug,
+		8:  SeverityDebug,
+		9:  SeverityInfo,
+		13: SeverityWarning,
+		17: SeverityError,
+		21: SeverityCritical,
+		24: SeverityCritical,
+	}
+	for n, want := range tests {
+		if got := OTelSeverity(n); got != want {
+			t.Errorf("%d: got %s, want %s", n, got, want)
+		}
+	}
+}
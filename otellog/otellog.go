@@ -0,0 +1,102 @@
+// Package otellog implements the OpenTelemetry Logs Bridge API (go.opentelemetry.io/otel/log) with stalog,
+// so that the logs of the libraries instrumented with OpenTelemetry are written as context logs
+// grouped by the trace of the request.
+//
+//	provider := otellog.NewLoggerProvider(config)
+//	global.SetLoggerProvider(provider) // go.opentelemetry.io/otel/log/global
+package otellog
+
+import (
+	"context"
+
+	"github.com/gcp-kit/stalog"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+)
+
+// LoggerProvider creates Logger for each instrumentation scope
+type LoggerProvider struct {
+	embedded.LoggerProvider
+
+	config *stalog.Config
+}
+
+// NewLoggerProvider creates LoggerProvider which writes the logs by the config
+func NewLoggerProvider(config *stalog.Config) *LoggerProvider {
+	return &LoggerProvider{config: config}
+}
+
+// Logger returns Logger for the instrumentation scope name
+func (p *LoggerProvider) Logger(name string, options ...log.LoggerOption) log.Logger {
+	return NewLogger(p.config, name)
+}
+
+// Logger writes the records as context logs by stalog.OTelLogger
+type Logger struct {
+	embedded.Logger
+
+	bridge *stalog.OTelLogger
+}
+
+// NewLogger creates Logger for the instrumentation scope name
+func NewLogger(config *stalog.Config, name string) *Logger {
+	// Emit of Logger is the extra frame between the caller and stalog.OTelLogger.Emit
+	return &Logger{bridge: stalog.NewOTelLogger(config, name).WithSkip(1)}
+}
+
+// Emit writes the record as a context log
+func (l *Logger) Emit(ctx context.Context, record log.Record) {
+	attrs := make(map[string]interface{}, record.AttributesLen()+1)
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = value(kv.Value)
+		return true
+	})
+	if name := record.EventName(); name != "" {
+		attrs["eventName"] = name
+	}
+
+	l.bridge.Emit(ctx, stalog.OTelRecord{
+		Timestamp:      record.Timestamp(),
+		SeverityNumber: int(record.Severity()),
+		SeverityText:   record.SeverityText(),
+		Body:           value(record.Body()),
+		Attributes:     attrs,
+	})
+}
+
+// Enabled reports whether the record at the severity would be written
+func (l *Logger) Enabled(ctx context.Context, param log.EnabledParameters) bool {
+	return l.bridge.Enabled(ctx, int(param.Severity))
+}
+
+// value converts the value of the record to the value for encoding/json
+func value(v log.Value) interface{} {
+	switch v.Kind() {
+	case log.KindBool:
+		return v.AsBool()
+	case log.KindFloat64:
+		return v.AsFloat64()
+	case log.KindInt64:
+		return v.AsInt64()
+	case log.KindString:
+		return v.AsString()
+	case log.KindBytes:
+		return v.AsBytes()
+	case log.KindSlice:
+		values := v.AsSlice()
+		s := make([]interface{}, len(values))
+		for i, e := range values {
+			s[i] = value(e)
+		}
+		return s
+	case log.KindMap:
+		kvs := v.AsMap()
+		m := make(map[string]interface{}, len(kvs))
+		for _, kv := range kvs {
+			m[kv.Key] = value(kv.Value)
+		}
+		return m
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package otellog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gcp-kit/stalog"
+	"go.opentelemetry.io/otel/log"
+)
+
+// entry is the fields of the context log checked in the tests
+type entry struct {
+	Time           string                 `json:"time"`
+	Severity       string                 `json:"severity"`
+	Message        string                 `json:"message"`
+	Trace          string                 `json:"logging.googleapis.com/trace"`
+	SourceLocation *stalog.SourceLocation `json:"logging.googleapis.com/sourceLocation"`
+	Data           map[string]interface{} `json:"data"`
+}
+
+func TestLogger(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := stalog.NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = out
+	config.Severity = stalog.SeverityInfo
+
+	var logger log.Logger = NewLoggerProvider(config).Logger("github.com/example/lib")
+
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if logger.Enabled(r.Context(), log.EnabledParameters{Severity: log.SeverityDebug}) {
+			t.Error("DEBUG should be disabled")
+		}
+
+		var record log.Record
+		record.SetTimestamp(timestamp)
+		record.SetSeverity(log.SeverityError)
+		record.SetBody(log.StringValue("query failed"))
+		record.AddAttributes(
+			log.String("db", "users"),
+			log.Int("rows", 3),
+			log.Map("query", log.Bool("cached", false)),
+		)
+		logger.Emit(r.Context(), record)
+	})
+
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	stalog.RequestLogging(config)(handler).ServeHTTP(httptest.NewRecorder(), r)
+
+	var e entry
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Severity != "ERROR" || e.Message != "query failed" || e.Time != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected log: %+v", e)
+	}
+	if e.Trace != "projects/test/traces/105445aa7843bc8bf206b12000100000" {
+		t.Errorf("unexpected trace: %s", e.Trace)
+	}
+	if e.SourceLocation == nil || e.SourceLocation.File != "otellog_test.go" {
+		t.Errorf("unexpected source location: %+v", e.SourceLocation)
+	}
+	if e.Data["db"] != "users" || e.Data["rows"] != float64(3) || e.Data["logger"] != "github.com/example/lib" {
+		t.Errorf("unexpected data: %+v", e.Data)
+	}
+	if query, _ := e.Data["query"].(map[string]interface{}); query["cached"] != false {
+		t.Errorf("unexpected data: %+v", e.Data)
+	}
+}
+
+func TestLoggerWithoutRequest(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := stalog.NewConfig("test")
+	config.ContextLogOut = out
+
+	var record log.Record
+	record.SetSeverity(log.SeverityInfo)
+	record.SetBody(log.Int64Value(42))
+	before := time.Now()
+	NewLogger(config, "").Emit(context.Background(), record)
+
+	var e entry
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Severity != "INFO" || e.Message != "42" || e.Trace != "" {
+		t.Errorf("unexpected log: %+v", e)
+	}
+	if tm, err := time.Parse(time.RFC3339Nano, e.Time); err != nil || tm.Before(before) {
+		t.Errorf("the current time must be used without the timestamp: %s", e.Time)
+	}
+}
@@ -0,0 +1,52 @@
+package stalog
+
+import (
+	"sync"
+	"time"
+)
+
+// periodic calls the function at every interval in the background until close.
+// It is the lifecycle of the reporters (e.g. RouteSummary and Heartbeat).
+type periodic struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// startPeriodic calls tick at every interval. last is called once on close if it isn't nil.
+func startPeriodic(interval time.Duration, tick func(), last func()) *periodic {
+	p := &periodic{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go p.run(interval, tick, last)
+
+	return p
+}
+
+func (p *periodic) run(interval time.Duration, tick func(), last func()) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			tick()
+		case <-p.stop:
+			if last != nil {
+				last()
+			}
+			return
+		}
+	}
+}
+
+// close stops calling the function and waits for the last call
+func (p *periodic) close() {
+	p.once.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
@@ -0,0 +1,20 @@
+package stalog
+
+import (
+	"net/http"
+)
+
+// isPreflight reports whether the request is a CORS preflight request
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// preflight marks the request log of the CORS preflight request with "is_preflight" and lowers it to DEBUG
+func preflight(requestLog *HTTPRequestLog, maxSeverity Severity) {
+	if maxSeverity < SeverityDebug {
+		requestLog.Severity = SeverityDebug.String()
+	}
+	requestLog.AdditionalData = mergeData(requestLog.AdditionalData, AdditionalData{"is_preflight": true})
+}
@@ -0,0 +1,50 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	newPreflight := func() *http.Request {
+		r, _ := http.NewRequest("OPTIONS", "/foo", nil)
+		r.Header.Set("Origin", "https://example.com")
+		r.Header.Set("Access-Control-Request-Method", "POST")
+		return r
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	RequestLogging(config)(handler).ServeHTTP(httptest.NewRecorder(), newPreflight())
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.Severity != "DEBUG" || httpRequestLog.AdditionalData["is_preflight"] != true {
+		t.Errorf("unexpected request log: %+v", httpRequestLog)
+	}
+
+	requestLogOut.Reset()
+	config.SkipPreflight = true
+	RequestLogging(config)(handler).ServeHTTP(httptest.NewRecorder(), newPreflight())
+	if requestLogOut.Len() != 0 {
+		t.Errorf("unexpected request log: %s", requestLogOut.String())
+	}
+
+	// a plain OPTIONS request is not a preflight
+	r, _ := http.NewRequest("OPTIONS", "/foo", nil)
+	RequestLogging(config)(handler).ServeHTTP(httptest.NewRecorder(), r)
+	if requestLogOut.Len() == 0 {
+		t.Error("request log must be written")
+	}
+}
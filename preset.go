@@ -0,0 +1,89 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Format is the output format of logs
+type Format int
+
+const (
+	// FormatJSON writes logs as JSON lines for Cloud Logging
+	FormatJSON Format = iota
+	// FormatConsole writes logs as human-readable lines for local development
+	FormatConsole
+)
+
+// NewDevelopmentConfig creates a config for local development.
+// All logs including DEBUG are written to stdout in FormatConsole with source locations.
+func NewDevelopmentConfig() *Config {
+	config := NewConfig("")
+	config.Format = FormatConsole
+	config.Severity = SeverityDebug
+	config.RequestLogOut = os.Stdout
+	config.ContextLogOut = os.Stdout
+	config.SingleStream = true
+	return config
+}
+
+// NewProductionConfig creates a config for production.
+// Logs at INFO or more severe are written in FormatJSON, and only ERROR or more severe logs have source locations.
+func NewProductionConfig(projectId string) *Config {
+	config := NewConfig(projectId)
+	config.Format = FormatJSON
+	config.Severity = SeverityInfo
+	config.SourceLocationSeverity = SeverityError
+	return config
+}
+
+// console formats the context log like "2006-01-02T15:04:05.999999999Z07:00 INFO main.go:21 message {"key":"value"}"
+func (log *contextLog) console() []byte {
+	buf := new(bytes.Buffer)
+	_, _ = fmt.Fprintf(buf, "%s %-9s", log.Time, log.Severity)
+	if log.SourceLocation != nil {
+		_, _ = fmt.Fprintf(buf, " %s:%s", log.SourceLocation.File, log.SourceLocation.Line)
+	}
+	_, _ = fmt.Fprintf(buf, " %s", log.Message)
+	writeConsoleData(buf, log.AdditionalData)
+	if log.StackTrace != "" {
+		_, _ = fmt.Fprintf(buf, "\n%s", log.StackTrace)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// console formats the request log like "2006-01-02T15:04:05.999999999Z07:00 INFO GET /foo 200 3B 0.000304s"
+func (log *HTTPRequestLog) console() []byte {
+	buf := new(bytes.Buffer)
+	r := log.HTTPRequest
+	_, _ = fmt.Fprintf(buf, "%s %-9s %s %s %d %sB %s", log.Time, log.Severity, r.RequestMethod, r.RequestUrl, r.Status, r.ResponseSize, r.Latency)
+	writeConsoleData(buf, log.AdditionalData)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// consoleSummary formats the summary log of jobs and messages like "2006-01-02T15:04:05.999999999Z07:00 INFO job finished: name 0.000304s"
+func consoleSummary(time string, severity string, message string, latency string, data AdditionalData) []byte {
+	buf := new(bytes.Buffer)
+	_, _ = fmt.Fprintf(buf, "%s %-9s %s", time, severity, message)
+	if latency != "" {
+		_, _ = fmt.Fprintf(buf, " %s", latency)
+	}
+	writeConsoleData(buf, data)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func writeConsoleData(buf *bytes.Buffer, data AdditionalData) {
+	if len(data) == 0 {
+		return
+	}
+
+	if b, err := json.Marshal(data); err == nil {
+		buf.WriteByte(' ')
+		buf.Write(bytes.TrimSpace(b))
+	}
+}
@@ -0,0 +1,67 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDevelopmentConfig(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	out := new(bytes.Buffer)
+	config := NewDevelopmentConfig()
+	config.RequestLogOut = out
+	config.ContextLogOut = out
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).WithFields(String("user", "alice")).Debugf("hello")
+		_, _ = w.Write([]byte("OK\n"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	logs := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+	if !regexp.MustCompile(`^\S+ DEBUG +preset_test\.go:\d+ hello {"user":"alice"}$`).MatchString(logs[0]) {
+		t.Errorf("unexpected context log: %s", logs[0])
+	}
+	if !regexp.MustCompile(`^\S+ DEBUG +GET /foo 200 3B \S+s$`).MatchString(logs[1]) {
+		t.Errorf("unexpected request log: %s", logs[1])
+	}
+}
+
+func TestProductionConfig(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewProductionConfig("test")
+	config.ContextLogOut = out
+	logger := newContextLogger(config, "", "")
+
+	logger.Debugf("debug")
+	logger.Infof("info")
+	logger.Errorf("error")
+
+	logs := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("unexpected logs: %s", out.String())
+	}
+
+	var info, errorLog contextLog
+	if err := json.Unmarshal([]byte(logs[0]), &info); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(logs[1]), &errorLog); err != nil {
+		t.Fatal(err)
+	}
+	if info.SourceLocation != nil {
+		t.Errorf("INFO log must not have the source location: %s", logs[0])
+	}
+	if errorLog.SourceLocation == nil {
+		t.Errorf("ERROR log must have the source location: %s", logs[1])
+	}
+}
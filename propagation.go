@@ -0,0 +1,47 @@
+package stalog
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.opencensus.io/trace"
+)
+
+// TraceAttributes returns the message attributes which carry the trace context of the request
+// in both "X-Cloud-Trace-Context" and W3C "traceparent" forms.
+// Set them to Pub/Sub messages (or headers of other queues) so that subscribers can join the same trace and log group.
+// It returns nil if the context has no trace.
+func TraceAttributes(ctx context.Context) map[string]string {
+	var traceId, spanId string
+	var sampled bool
+	if span := trace.FromContext(ctx); span != nil {
+		sc := span.SpanContext()
+		traceId, spanId, sampled = sc.TraceID.String(), sc.SpanID.String(), sc.IsSampled()
+	} else if logger, ok := ctx.Value(ContextLoggerKey).(*ContextLogger); ok {
+		traceId, spanId, sampled = logger.traceId, logger.spanId, logger.traceSampled
+	}
+
+	if traceId == "" {
+		return nil
+	}
+	if spanId == "" {
+		spanId = "0000000000000001"
+	}
+
+	// X-Cloud-Trace-Context uses the decimal span ID
+	sid, err := strconv.ParseUint(spanId, 16, 64)
+	if err != nil {
+		return nil
+	}
+
+	options, flags := 0, "00"
+	if sampled {
+		options, flags = 1, "01"
+	}
+
+	return map[string]string{
+		"X-Cloud-Trace-Context": fmt.Sprintf("%s/%d;o=%d", traceId, sid, options),
+		"traceparent":           fmt.Sprintf("00-%s-%s-%s", traceId, spanId, flags),
+	}
+}
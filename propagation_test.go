@@ -0,0 +1,28 @@
+package stalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTraceAttributes(t *testing.T) {
+	config := NewConfig("test")
+	logger := newContextLogger(config, "projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736")
+	logger.spanId = "00f067aa0ba902b7"
+	logger.traceSampled = true
+	ctx := context.WithValue(context.Background(), ContextLoggerKey, logger)
+
+	expected := map[string]string{
+		"X-Cloud-Trace-Context": "4bf92f3577b34da6a3ce929d0e0e4736/67667974448284343;o=1",
+		"traceparent":           "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	if got := TraceAttributes(ctx); !cmp.Equal(got, expected) {
+		t.Errorf("diff: %s", cmp.Diff(got, expected))
+	}
+
+	if got := TraceAttributes(context.Background()); got != nil {
+		t.Errorf("unexpected attributes: %v", got)
+	}
+}
@@ -0,0 +1,31 @@
+package stalog
+
+import (
+	"net/http"
+	"strings"
+)
+
+// protocol returns the protocol for the request log.
+// HTTP/2 is distinguished by TLS ("HTTP/2.0") and cleartext ("h2c"), and gRPC by the content type.
+// HTTP/3 is reported as "HTTP/3".
+func (c *Config) protocol(r *http.Request) string {
+	if c.ProtocolFunc != nil {
+		if protocol := c.ProtocolFunc(r); protocol != "" {
+			return protocol
+		}
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "application/grpc-web"):
+		return "gRPC-Web"
+	case strings.HasPrefix(contentType, "application/grpc"):
+		return "gRPC"
+	case r.ProtoMajor == 2 && r.TLS == nil:
+		return "h2c"
+	case r.ProtoMajor == 3:
+		return "HTTP/3"
+	default:
+		return r.Proto
+	}
+}
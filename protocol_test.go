@@ -0,0 +1,57 @@
+package stalog
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestProtocol(t *testing.T) {
+	newRequest := func(major int, secure bool, contentType string) *http.Request {
+		r, _ := http.NewRequest("POST", "/foo", nil)
+		r.ProtoMajor, r.ProtoMinor = major, 0
+		r.Proto = "HTTP/1.1"
+		if major == 2 {
+			r.Proto = "HTTP/2.0"
+		}
+		if secure {
+			r.TLS = &tls.ConnectionState{}
+		}
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		return r
+	}
+
+	config := NewConfig("test")
+	tests := []struct {
+		r        *http.Request
+		expected string
+	}{
+		{r: newRequest(1, false, ""), expected: "HTTP/1.1"},
+		{r: newRequest(2, true, ""), expected: "HTTP/2.0"},
+		{r: newRequest(2, false, ""), expected: "h2c"},
+		{r: newRequest(2, false, "application/grpc+proto"), expected: "gRPC"},
+		{r: newRequest(1, false, "application/grpc-web-text"), expected: "gRPC-Web"},
+	}
+	for _, tt := range tests {
+		if protocol := config.protocol(tt.r); protocol != tt.expected {
+			t.Errorf("expected %s, but got %s", tt.expected, protocol)
+		}
+	}
+
+	config.ProtocolFunc = func(r *http.Request) string {
+		if r.Header.Get("Upgrade") == "websocket" {
+			return "websocket"
+		}
+		return ""
+	}
+	r := newRequest(1, false, "")
+	if protocol := config.protocol(r); protocol != "HTTP/1.1" {
+		t.Errorf("unexpected fallback: %s", protocol)
+	}
+	r.Header.Set("Upgrade", "websocket")
+	if protocol := config.protocol(r); protocol != "websocket" {
+		t.Errorf("unexpected protocol: %s", protocol)
+	}
+}
@@ -0,0 +1,265 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// defaultPubSubEndpoint is the endpoint of Pub/Sub API when PubSubWriterOptions.Endpoint is empty
+const defaultPubSubEndpoint = "https://pubsub.googleapis.com"
+
+// pubSubTimeout is the timeout for each publish request
+const pubSubTimeout = 10 * time.Second
+
+// the limits of a publish request of Pub/Sub API
+const (
+	pubSubMaxMessages = 1000
+	pubSubMaxBytes    = 10 * 1000 * 1000
+)
+
+// PubSubWriterOptions is the options for NewPubSubWriter
+type PubSubWriterOptions struct {
+	// Client sends the publish requests with the credentials (e.g. the client of golang.org/x/oauth2/google).
+	// If nil, the access token of the default service account is got from the metadata server,
+	// except with Endpoint where the requests are sent without credentials (e.g. to the emulator).
+	Client *http.Client
+
+	// Endpoint of Pub/Sub API, e.g. the address of the emulator (default: "https://pubsub.googleapis.com")
+	Endpoint string
+
+	// CountThreshold publishes the batch when it has this many messages (default: 100, max: 1000)
+	CountThreshold int
+
+	// ByteThreshold publishes the batch when the request would be larger than this size (default: 1MB, max: 10MB)
+	ByteThreshold int
+
+	// DelayThreshold publishes the batch after this delay from the first message in it (default: 10ms)
+	DelayThreshold time.Duration
+}
+
+// PubSubWriter publishes each log in JSON format as a message to a topic of Cloud Pub/Sub,
+// for the pipelines which post-process the logs (e.g. SIEM or custom analytics) independent of Cloud Logging sinks.
+// The severity, the trace and the span ID of the log are attached as the attributes of the message
+// ("severity", "trace", "spanId"), so that subscriptions can filter them.
+//
+// The messages are batched into a publish request by the thresholds of the options like the Pub/Sub client library,
+// and Flush publishes the batch (the middlewares call it before the request log if it is ContextLogOut).
+// The errors of the publishes by DelayThreshold are returned by the next Flush or Close.
+// A publish by CountThreshold or ByteThreshold blocks the Write, so wrap it with AsyncWriter not to block handlers.
+type PubSubWriter struct {
+	url    string
+	client *http.Client
+	token  *metadataToken
+	opts   PubSubWriterOptions
+
+	mu    sync.Mutex
+	batch []pubSubMessage
+	size  int
+	timer *time.Timer
+	err   error
+}
+
+// NewPubSubWriter creates PubSubWriter which publishes the logs to "projects/<projectId>/topics/<topic>"
+func NewPubSubWriter(projectId string, topic string, opts PubSubWriterOptions) *PubSubWriter {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = defaultPubSubEndpoint
+	}
+	if opts.CountThreshold <= 0 {
+		opts.CountThreshold = 100
+	} else if opts.CountThreshold > pubSubMaxMessages {
+		opts.CountThreshold = pubSubMaxMessages
+	}
+	if opts.ByteThreshold <= 0 {
+		opts.ByteThreshold = 1000 * 1000
+	} else if opts.ByteThreshold > pubSubMaxBytes {
+		opts.ByteThreshold = pubSubMaxBytes
+	}
+	if opts.DelayThreshold <= 0 {
+		opts.DelayThreshold = 10 * time.Millisecond
+	}
+
+	w := &PubSubWriter{
+		url:    endpoint + "/v1/projects/" + url.PathEscape(projectId) + "/topics/" + url.PathEscape(topic) + ":publish",
+		client: opts.Client,
+		opts:   opts,
+	}
+	if w.client == nil {
+		w.client = &http.Client{Timeout: pubSubTimeout}
+		if opts.Endpoint == "" {
+			w.token = &metadataToken{}
+		}
+	}
+
+	return w
+}
+
+// pubSubAttributes is the fields of the logs which are attached as the attributes
+type pubSubAttributes struct {
+	Severity string `json:"severity"`
+	Trace    string `json:"logging.googleapis.com/trace"`
+	SpanID   string `json:"logging.googleapis.com/spanId"`
+}
+
+// pubSubMessage is PubsubMessage of Pub/Sub API. Data is encoded in base64 by encoding/json.
+type pubSubMessage struct {
+	Data       []byte            `json:"data"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// pubSubRequestOverhead is the size of the request body without the messages: {"messages":[]}
+const pubSubRequestOverhead = len(`{"messages":[]}`)
+
+// Write adds a log as a message to the batch
+func (w *PubSubWriter) Write(p []byte) (int, error) {
+	data := make([]byte, len(bytes.TrimRight(p, "\n")))
+	copy(data, p)
+
+	message := pubSubMessage{Data: data}
+	var attrs pubSubAttributes
+	if json.Unmarshal(data, &attrs) == nil {
+		message.Attributes = map[string]string{}
+		for key, value := range map[string]string{"severity": attrs.Severity, "trace": attrs.Trace, "spanId": attrs.SpanID} {
+			if value != "" {
+				message.Attributes[key] = value
+			}
+		}
+	}
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		return 0, err
+	}
+	// the messages are separated by commas
+	size := len(encoded) + 1
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.batch) > 0 && pubSubRequestOverhead+w.size+size > w.opts.ByteThreshold {
+		if err := w.publishLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	w.batch = append(w.batch, message)
+	w.size += size
+	if len(w.batch) >= w.opts.CountThreshold || pubSubRequestOverhead+w.size >= w.opts.ByteThreshold {
+		if err := w.publishLocked(); err != nil {
+			return 0, err
+		}
+	} else if w.timer == nil {
+		w.timer = time.AfterFunc(w.opts.DelayThreshold, w.publishByDelay)
+	}
+
+	return len(p), nil
+}
+
+// Flush publishes the batch, and returns the error of the publishes by DelayThreshold since the last Flush
+func (w *PubSubWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.publishLocked()
+	if w.err != nil {
+		err, w.err = w.err, nil
+	}
+
+	return err
+}
+
+// Close publishes the batch. The writer can't be used after Close.
+func (w *PubSubWriter) Close() error {
+	return w.Flush()
+}
+
+// publishByDelay publishes the batch by DelayThreshold
+func (w *PubSubWriter) publishByDelay() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.publishLocked(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// publishLocked publishes the batch in a request. w.mu must be held.
+func (w *PubSubWriter) publishLocked() error {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	batch := w.batch
+	w.batch, w.size = nil, 0
+
+	body, err := json.Marshal(map[string][]pubSubMessage{"messages": batch})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != nil {
+		token, err := w.token.get()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stalog: failed to publish to Pub/Sub: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("stalog: failed to publish %d messages to Pub/Sub: %s", len(batch), resp.Status)
+	}
+
+	return nil
+}
+
+// metadataToken caches the access token of the default service account from the metadata server
+type metadataToken struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// get returns the cached token, or gets a new token if it expires within a minute
+func (t *metadataToken) get() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Until(t.expires) > time.Minute {
+		return t.token, nil
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	b := getMetadata("instance/service-accounts/default/token")
+	if err := json.Unmarshal([]byte(b), &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("stalog: failed to get the access token from the metadata server")
+	}
+
+	t.token = token.AccessToken
+	t.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return t.token, nil
+}
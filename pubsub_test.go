@@ -0,0 +1,224 @@
+package stalog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPubSubWriter(t *testing.T) {
+	var published []pubSubMessage
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		var body struct {
+			Messages []pubSubMessage `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		published = append(published, body.Messages...)
+		_, _ = w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	defer server.Close()
+
+	w := NewPubSubWriter("my-project", "logs", PubSubWriterOptions{Client: server.Client(), Endpoint: server.URL})
+
+	config := NewConfig("my-project")
+	config.ContextLogOut = w
+	logger := newContextLogger(config, "projects/my-project/traces/abc", "abc")
+	logger.Warning("hello")
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/v1/projects/my-project/topics/logs:publish" || auth != "" {
+		t.Errorf("unexpected request: %s (Authorization: %q)", path, auth)
+	}
+	if len(published) != 1 {
+		t.Fatalf("unexpected messages: %+v", published)
+	}
+
+	var log contextLog
+	if err := json.Unmarshal(published[0].Data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Message != "hello" {
+		t.Errorf("unexpected log: %+v", log)
+	}
+	attrs := published[0].Attributes
+	if attrs["severity"] != "WARNING" || attrs["trace"] != "projects/my-project/traces/abc" {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+	if _, ok := attrs["spanId"]; ok {
+		t.Errorf("empty attributes must be omitted: %v", attrs)
+	}
+}
+
+func TestPubSubWriterMetadataToken(t *testing.T) {
+	var tokens int
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/token" || r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		tokens++
+		_, _ = w.Write([]byte(`{"access_token":"secret","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer metadata.Close()
+
+	original, ok := os.LookupEnv("GCE_METADATA_HOST")
+	os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadata.URL, "http://"))
+	defer func() {
+		if ok {
+			os.Setenv("GCE_METADATA_HOST", original)
+		} else {
+			os.Unsetenv("GCE_METADATA_HOST")
+		}
+	}()
+
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		_, _ = ioutil.ReadAll(r.Body)
+		if len(auths) > 1 {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	// the token is got only for the default endpoint
+	w := NewPubSubWriter("my-project", "logs", PubSubWriterOptions{})
+	w.url = server.URL + "/v1/projects/my-project/topics/logs:publish"
+	if _, err := w.Write([]byte(`{"message":"a"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`{"message":"b"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err == nil {
+		t.Error("error status must be an error")
+	}
+
+	if tokens != 1 || len(auths) != 2 || auths[0] != "Bearer secret" || auths[1] != "Bearer secret" {
+		t.Errorf("unexpected tokens: %d, %q", tokens, auths)
+	}
+}
+
+func TestPubSubWriterBatch(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the metadata server must not be requested with Endpoint: %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer metadata.Close()
+
+	original, ok := os.LookupEnv("GCE_METADATA_HOST")
+	os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadata.URL, "http://"))
+	defer func() {
+		if ok {
+			os.Setenv("GCE_METADATA_HOST", original)
+		} else {
+			os.Unsetenv("GCE_METADATA_HOST")
+		}
+	}()
+
+	var mu sync.Mutex
+	var batches []int
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []pubSubMessage `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		batches = append(batches, len(body.Messages))
+		auths = append(auths, r.Header.Get("Authorization"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	// the emulator without Client
+	w := NewPubSubWriter("my-project", "logs", PubSubWriterOptions{Endpoint: server.URL, CountThreshold: 2, DelayThreshold: time.Hour})
+	for _, msg := range []string{"a", "b", "c"} {
+		if _, err := w.Write([]byte(`{"message":"` + msg + `"}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	if !reflect.DeepEqual(batches, []int{2, 1}) || auths[0] != "" {
+		t.Errorf("unexpected batches: %v (Authorization: %q)", batches, auths)
+	}
+	batches = nil
+	mu.Unlock()
+
+	// the batch is published by DelayThreshold without Flush
+	w = NewPubSubWriter("my-project", "logs", PubSubWriterOptions{Endpoint: server.URL, DelayThreshold: time.Millisecond})
+	for _, msg := range []string{"a", "b"} {
+		if _, err := w.Write([]byte(`{"message":"` + msg + `"}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		published := len(batches)
+		mu.Unlock()
+		if published > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the batch must be published by DelayThreshold")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	if !reflect.DeepEqual(batches, []int{2}) {
+		t.Errorf("unexpected batches: %v", batches)
+	}
+	mu.Unlock()
+}
+
+func TestPubSubWriterByteThreshold(t *testing.T) {
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []pubSubMessage `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		batches = append(batches, len(body.Messages))
+	}))
+	defer server.Close()
+
+	// each message is about 1KB in base64
+	w := NewPubSubWriter("my-project", "logs", PubSubWriterOptions{Client: server.Client(), Endpoint: server.URL, ByteThreshold: 2500, DelayThreshold: time.Hour})
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte(strings.Repeat("x", 750) + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(batches, []int{2, 2, 1}) {
+		t.Errorf("unexpected batches: %v", batches)
+	}
+}
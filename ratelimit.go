@@ -0,0 +1,108 @@
+package stalog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// processRateLimiter limits context logs per call site across all requests
+var processRateLimiter = &rateLimiter{buckets: map[string]*tokenBucket{}}
+
+type tokenBucket struct {
+	tokens          float64
+	last            time.Time
+	suppressed      int
+	suppressedLevel Severity
+}
+
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// rateLimitKey returns the key of the call site for the rate limit of the config.
+// The function name is qualified by the package path unlike the short file name, so the call sites in the files
+// with the same name in different packages don't share the bucket, and the configs with different limits don't either.
+func rateLimitKey(config *Config, location *SourceLocation) string {
+	site := location.Function
+	if site == "" {
+		site = location.File
+	}
+
+	return fmt.Sprintf("%s:%s/%g/%d", site, location.Line, config.RateLimit, config.RateLimitBurst)
+}
+
+// allow reports whether a log for the key can be written now.
+// When it is allowed after some logs were suppressed, it also returns the number
+// of the suppressed logs and their highest severity.
+func (rl *rateLimiter) allow(key string, severity Severity, rate float64, burst int, now time.Time) (bool, int, Severity) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		if b.suppressed == 0 || severity > b.suppressedLevel {
+			b.suppressedLevel = severity
+		}
+		b.suppressed++
+		return false, 0, SeverityDefault
+	}
+	b.tokens--
+
+	suppressed, level := b.suppressed, b.suppressedLevel
+	b.suppressed = 0
+	return true, suppressed, level
+}
+
+// allow counts the log against MaxEntriesPerRequest
+func (l *ContextLogger) allow(severity Severity) bool {
+	if l.config.MaxEntriesPerRequest <= 0 {
+		return true
+	}
+
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	if l.state.entries < l.config.MaxEntriesPerRequest {
+		l.state.entries++
+		return true
+	}
+
+	if l.state.suppressed == 0 || severity > l.state.suppressedLevel {
+		l.state.suppressedLevel = severity
+	}
+	l.state.suppressed++
+	countDropped(dropMaxEntriesPerRequest)
+	return false
+}
+
+// flushSuppressed writes the summary of the logs suppressed by MaxEntriesPerRequest
+func (l *ContextLogger) flushSuppressed() {
+	l.state.mu.Lock()
+	suppressed, level := l.state.suppressed, l.state.suppressedLevel
+	l.state.suppressed = 0
+	l.state.mu.Unlock()
+
+	if suppressed == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("%d messages suppressed (MaxEntriesPerRequest: %d)", suppressed, l.config.MaxEntriesPerRequest)
+	_ = l.output(l.newLog(level, SourceLocation{}, msg))
+}
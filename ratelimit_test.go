@@ -0,0 +1,99 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxEntriesPerRequest(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/foo", func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		for i := 0; i < 5; i++ {
+			logger.Infof("loop %d", i)
+		}
+		logger.Errorf("failed")
+	})
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.MaxEntriesPerRequest = 2
+	handler := RequestLogging(config)(mux)
+	handler.ServeHTTP(w, r)
+
+	logs := strings.Split(strings.TrimSuffix(contextLogOut.String(), "\n"), "\n")
+	if len(logs) != 3 {
+		t.Fatalf("unexpected number of logs: %d", len(logs))
+	}
+
+	var summary contextLog
+	if err := json.Unmarshal([]byte(logs[2]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Severity != "ERROR" {
+		t.Errorf("unexpected severity: %s", summary.Severity)
+	}
+	if !strings.HasPrefix(summary.Message, "4 messages suppressed") {
+		t.Errorf("unexpected message: %s", summary.Message)
+	}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.Severity != "ERROR" {
+		t.Errorf("unexpected request log severity: %s", httpRequestLog.Severity)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	rl := &rateLimiter{buckets: map[string]*tokenBucket{}}
+	now := time.Now()
+
+	if ok, _, _ := rl.allow("a", SeverityInfo, 1, 2, now); !ok {
+		t.Error("first log must be allowed")
+	}
+	if ok, _, _ := rl.allow("a", SeverityInfo, 1, 2, now); !ok {
+		t.Error("second log must be allowed by burst")
+	}
+	if ok, _, _ := rl.allow("a", SeverityWarning, 1, 2, now); ok {
+		t.Error("third log must be suppressed")
+	}
+	if ok, _, _ := rl.allow("b", SeverityInfo, 1, 2, now); !ok {
+		t.Error("another key must be allowed")
+	}
+
+	ok, suppressed, level := rl.allow("a", SeverityInfo, 1, 2, now.Add(time.Second))
+	if !ok || suppressed != 1 || level != SeverityWarning {
+		t.Errorf("unexpected result: ok=%v, suppressed=%d, level=%s", ok, suppressed, level)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	config := NewConfig("test")
+	config.RateLimit = 1
+
+	a := rateLimitKey(config, &SourceLocation{File: "handler.go", Line: "10", Function: "example.com/a.Handle"})
+	b := rateLimitKey(config, &SourceLocation{File: "handler.go", Line: "10", Function: "example.com/b.Handle"})
+	if a == b {
+		t.Errorf("the call sites in different packages must have different keys: %s", a)
+	}
+
+	other := NewConfig("test")
+	other.RateLimit = 100
+	if c := rateLimitKey(other, &SourceLocation{File: "handler.go", Line: "10", Function: "example.com/a.Handle"}); a == c {
+		t.Errorf("the configs with different limits must have different keys: %s", a)
+	}
+}
@@ -0,0 +1,69 @@
+package stalog
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// reportedErrorEventType makes Error Reporting pick up the log regardless of its message
+const reportedErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// recoverPanic logs the recovered panic value and responds 500 Internal Server Error if nothing is written yet.
+// http.ErrAbortHandler is re-panicked to abort the response as net/http expects.
+func (rv *Reserve) recoverPanic(wrw *wrappedResponseWriter, v interface{}) {
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	rv.contextLogger.writePanic(v, debug.Stack())
+
+	if wrw.status == 0 {
+		wrw.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// writePanic logs the panic value at CRITICAL severity.
+// errors and strings become the message, and other values are rendered into data.
+func (l *ContextLogger) writePanic(v interface{}, stack []byte) {
+	l.state.logged(SeverityCritical)
+
+	data := AdditionalData{}
+	var msg string
+	switch value := v.(type) {
+	case error:
+		msg = value.Error()
+		data["error"] = value.Error()
+		data["errorType"] = fmt.Sprintf("%T", value)
+	case string:
+		msg = value
+	default:
+		msg = fmt.Sprint(value)
+		data["panic"] = fmt.Sprintf("%#v", value)
+	}
+
+	log := l.newLog(SeverityCritical, SourceLocation{}, msg)
+	log.AdditionalData = mergeData(log.AdditionalData, data)
+	// Error Reporting expects the stack trace in the format of Go's panic output
+	log.StackTrace = fmt.Sprintf("panic: %s\n\n%s", msg, stack)
+	log.Type = reportedErrorEventType
+
+	_ = l.output(log)
+}
+
+// mergeData returns a new AdditionalData which has both fields (extra overwrites base)
+func mergeData(base AdditionalData, extra AdditionalData) AdditionalData {
+	if len(extra) == 0 {
+		return base
+	}
+
+	merged := make(AdditionalData, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+
+	return merged
+}
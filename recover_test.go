@@ -0,0 +1,123 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	type point struct{ X, Y int }
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		message string
+		data    AdditionalData
+	}{
+		{"error", errors.New("boom"), "boom", AdditionalData{"error": "boom", "errorType": "*errors.errorString"}},
+		{"string", "boom", "boom", nil},
+		{"other", point{1, 2}, "{1 2}", AdditionalData{"panic": "stalog.point{X:1, Y:2}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/foo", nil)
+			w := httptest.NewRecorder()
+
+			requestLogOut := new(bytes.Buffer)
+			contextLogOut := new(bytes.Buffer)
+
+			config := NewConfig("test")
+			config.RequestLogOut = requestLogOut
+			config.ContextLogOut = contextLogOut
+			config.Recover = true
+			handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(tt.value)
+			}))
+			handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("unexpected status: %d", w.Code)
+			}
+
+			var cLog contextLog
+			if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+				t.Fatal(err)
+			}
+			if cLog.Severity != "CRITICAL" || cLog.Message != tt.message {
+				t.Errorf("unexpected log: %s", contextLogOut.String())
+			}
+			if len(cLog.AdditionalData) != len(tt.data) {
+				t.Errorf("unexpected data: %v", cLog.AdditionalData)
+			}
+			for k, v := range tt.data {
+				if cLog.AdditionalData[k] != v {
+					t.Errorf("unexpected data: %v", cLog.AdditionalData)
+				}
+			}
+			if !strings.HasPrefix(cLog.StackTrace, "panic: "+tt.message+"\n\ngoroutine ") {
+				t.Errorf("unexpected stack trace: %s", cLog.StackTrace)
+			}
+
+			var httpRequestLog HTTPRequestLog
+			if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+				t.Fatal(err)
+			}
+			if httpRequestLog.Severity != "CRITICAL" || httpRequestLog.HTTPRequest.Status != http.StatusInternalServerError {
+				t.Errorf("unexpected request log: %s", requestLogOut.String())
+			}
+		})
+	}
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	middlewares := map[string]func(config *Config, next http.Handler) http.Handler{
+		"RequestLogging":      func(config *Config, next http.Handler) http.Handler { return RequestLogging(config)(next) },
+		"RequestLoggingHTTP3": func(config *Config, next http.Handler) http.Handler { return RequestLoggingHTTP3(config)(next) },
+		"RequestLoggingWithFunc": func(config *Config, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				RequestLoggingWithFunc(config, w, r, next.ServeHTTP)
+			})
+		},
+	}
+
+	for name, middleware := range middlewares {
+		requestLogOut := new(bytes.Buffer)
+		contextLogOut := new(bytes.Buffer)
+
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = contextLogOut
+		config.Recover = true
+		handler := middleware(config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			RequestContextLogger(r).Warning("aborting")
+			panic(http.ErrAbortHandler)
+		}))
+
+		func() {
+			defer func() {
+				if v := recover(); v != http.ErrAbortHandler {
+					t.Errorf("%s: http.ErrAbortHandler must be re-panicked: %v", name, v)
+				}
+			}()
+			r, _ := http.NewRequest("GET", "/foo", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+		}()
+
+		if !strings.Contains(contextLogOut.String(), "aborting") || strings.Contains(contextLogOut.String(), "CRITICAL") {
+			t.Errorf("%s: unexpected context logs: %s", name, contextLogOut.String())
+		}
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatalf("%s: the request log must be written: %v", name, err)
+		}
+		if httpRequestLog.Severity != "WARNING" {
+			t.Errorf("%s: unexpected request log: %s", name, requestLogOut.String())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package stalog
+
+import (
+	"context"
+	"time"
+)
+
+// ReplayOptions is the options for Replay
+type ReplayOptions struct {
+	// Speed is the rate of the replay to the original timing (e.g. 2 replays twice as fast).
+	// The logs are written without waiting if 0.
+	Speed float64
+
+	// KeepTime keeps the original time of the logs. The logs are written with the time of the replay by default.
+	KeepTime bool
+}
+
+// Replay writes the entries (e.g. read by Decode or the Decoder of NewDecoderForConfig) to the outputs of the config, keeping the relative timing of the entries,
+// to test the configuration of the outputs and Route, or to generate the load on AsyncWriter and the backends.
+// It returns ctx.Err() if ctx is done before all entries are written.
+func Replay(ctx context.Context, config *Config, entries []Entry, opts ReplayOptions) error {
+	logger := newDefaultLogger(config)
+
+	start := time.Now()
+	var origin time.Time
+	for i := range entries {
+		entry := &entries[i]
+
+		if opts.Speed > 0 && !entry.Time.IsZero() {
+			if origin.IsZero() {
+				origin = entry.Time
+			}
+			wait := time.Until(start.Add(time.Duration(float64(entry.Time.Sub(origin)) / opts.Speed)))
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		t := time.Now()
+		if opts.KeepTime && !entry.Time.IsZero() {
+			t = entry.Time
+		}
+
+		var err error
+		if entry.RequestLog {
+			err = writeRequestLog(config, entry.requestLog(t))
+		} else {
+			err = logger.output(entry.contextLog(t))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// requestLog converts the entry to the request log
+func (e *Entry) requestLog(t time.Time) *HTTPRequestLog {
+	requestLog := &HTTPRequestLog{
+		Time:           t.Format(time.RFC3339Nano),
+		Trace:          e.Trace,
+		SpanID:         e.SpanID,
+		Severity:       e.Severity.String(),
+		Labels:         e.Labels,
+		AdditionalData: e.Data,
+	}
+	if e.HTTPRequest != nil {
+		requestLog.HTTPRequest = *e.HTTPRequest
+	}
+
+	return requestLog
+}
+
+// contextLog converts the entry to the context log
+func (e *Entry) contextLog(t time.Time) *contextLog {
+	log := &contextLog{
+		Time:           t.Format(time.RFC3339Nano),
+		Trace:          e.Trace,
+		SpanID:         e.SpanID,
+		SourceLocation: e.SourceLocation,
+		Severity:       e.Severity.String(),
+		Labels:         e.Labels,
+		Message:        e.Message,
+		Seq:            e.Seq,
+		AdditionalData: e.Data,
+	}
+	if e.HTTPRequest != nil {
+		log.HTTPRequest = &contextRequest{
+			RequestMethod: e.HTTPRequest.RequestMethod,
+			RequestUrl:    e.HTTPRequest.RequestUrl,
+			Status:        e.HTTPRequest.Status,
+		}
+	}
+
+	return log
+}
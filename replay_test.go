@@ -0,0 +1,57 @@
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	input := strings.Join([]string{
+		`{"time":"2020-01-02T03:04:05Z","logging.googleapis.com/trace":"projects/p/traces/t1","severity":"INFO","message":"first","data":{"user":"alice"}}`,
+		`{"time":"2020-01-02T03:04:05.1Z","logging.googleapis.com/trace":"projects/p/traces/t1","severity":"ERROR","httpRequest":{"requestMethod":"GET","requestUrl":"/foo","status":500}}`,
+	}, "\n")
+	entries, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contextLogOut := new(bytes.Buffer)
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = contextLogOut
+	config.RequestLogOut = requestLogOut
+
+	start := time.Now()
+	if err := Replay(context.Background(), config, entries, ReplayOptions{Speed: 2, KeepTime: true}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("replayed too fast: %s", elapsed)
+	}
+
+	replayed, err := Decode(io.MultiReader(contextLogOut, requestLogOut))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 2 {
+		t.Fatalf("got %d entries", len(replayed))
+	}
+	if c := replayed[0]; c.RequestLog || c.Message != "first" || c.Data["user"] != "alice" || !c.Time.Equal(entries[0].Time) {
+		t.Errorf("unexpected context log: %+v", c)
+	}
+	if r := replayed[1]; !r.RequestLog || r.Severity != SeverityError || r.HTTPRequest.Status != http.StatusInternalServerError ||
+		r.Trace != "projects/p/traces/t1" {
+		t.Errorf("unexpected request log: %+v", r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Replay(ctx, config, entries, ReplayOptions{}); err != context.Canceled {
+		t.Errorf("got %v", err)
+	}
+}
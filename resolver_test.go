@@ -0,0 +1,46 @@
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSeverityResolver(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = out
+	config.Severity = SeverityInfo
+	config.SeverityResolver = func(r *http.Request) Severity {
+		if r.Header.Get("X-User") == "debug-me" {
+			return SeverityDebug
+		}
+		return config.Severity
+	}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Debug(r.Header.Get("X-User"))
+	}))
+	for _, user := range []string{"debug-me", "someone"} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-User", user)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var messages []string
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var cLog contextLog
+		if err := json.Unmarshal(scanner.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, cLog.Message)
+	}
+	if len(messages) != 1 || messages[0] != "debug-me" {
+		t.Errorf("unexpected logs: %q", messages)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build go1.21
+// +build go1.21
+
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseController(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	errs := make(chan error, 4)
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		errs <- rc.SetReadDeadline(time.Now().Add(time.Second))
+		errs <- rc.SetWriteDeadline(time.Now().Add(time.Second))
+		errs <- rc.EnableFullDuplex()
+
+		_, _ = w.Write([]byte("data\n"))
+		errs <- rc.Flush()
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	_ = res.Body.Close()
+	if err != nil || line != "data\n" {
+		t.Fatalf("unexpected body: %q, %v", line, err)
+	}
+
+	// wait for the request log
+	server.Close()
+
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("ResponseController must work through the middleware: %v", err)
+		}
+	}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.HTTPRequest.ResponseSize != "5" {
+		t.Errorf("unexpected response size: %s", httpRequestLog.HTTPRequest.ResponseSize)
+	}
+}
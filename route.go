@@ -0,0 +1,56 @@
+package stalog
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// echoParams returns the path parameters of the matched route
+func echoParams(c echo.Context) map[string]string {
+	names, values := c.ParamNames(), c.ParamValues()
+	if len(names) == 0 {
+		return nil
+	}
+
+	params := make(map[string]string, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			params[name] = values[i]
+		}
+	}
+
+	return params
+}
+
+// routeData returns the route pattern and the path parameters for the request log's data
+func (rv *Reserve) routeData() AdditionalData {
+	data := AdditionalData{"route": rv.routePattern}
+	if len(rv.routeParams) > 0 {
+		data["params"] = rv.routeParams
+	}
+
+	return data
+}
+
+// fallthroughData returns the detail of 404/405 responses which the router served without any user handler.
+// For net/http routers, the responses without the route pattern and the handler name (see Name) are regarded as such.
+func (rv *Reserve) fallthroughData(wrw *wrappedResponseWriter) AdditionalData {
+	if wrw.status != http.StatusNotFound && wrw.status != http.StatusMethodNotAllowed {
+		return nil
+	}
+	if !rv.unmatched && (rv.routePattern != "" || rv.contextLogger.handlerName() != "") {
+		return nil
+	}
+
+	detail := map[string]string{
+		"reason": http.StatusText(wrw.status),
+		"method": rv.request.Method,
+		"path":   rv.request.URL.Path,
+	}
+	if allow := wrw.Header().Get("Allow"); allow != "" {
+		detail["allow"] = allow
+	}
+
+	return AdditionalData{"fallthrough": detail}
+}
@@ -0,0 +1,129 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+)
+
+func TestEchoRouteData(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	e := echo.New()
+	e.Use(RequestLoggingWithEcho(config))
+	e.GET("/users/:id/posts/:post", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	r, _ := http.NewRequest("GET", "/users/1/posts/abc", nil)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	expected := AdditionalData{
+		"route":  "/users/:id/posts/:post",
+		"params": map[string]interface{}{"id": "1", "post": "abc"},
+	}
+	if diff := cmp.Diff(expected, httpRequestLog.AdditionalData); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+}
+
+func TestFallthrough(t *testing.T) {
+	newConfig := func(out *bytes.Buffer) *Config {
+		config := NewConfig("test")
+		config.RequestLogOut = out
+		config.ContextLogOut = new(bytes.Buffer)
+		return config
+	}
+	decode := func(t *testing.T, out *bytes.Buffer) HTTPRequestLog {
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(out.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		return httpRequestLog
+	}
+
+	t.Run("net/http", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		mux := http.NewServeMux()
+		mux.Handle("/users", Name("ListUsers", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		})))
+		handler := RequestLogging(newConfig(out))(mux)
+
+		r, _ := http.NewRequest("GET", "/nope", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		expected := AdditionalData{
+			"fallthrough": map[string]interface{}{"reason": "Not Found", "method": "GET", "path": "/nope"},
+		}
+		log := decode(t, out)
+		if diff := cmp.Diff(expected, log.AdditionalData); diff != "" || log.HTTPRequest.Status != http.StatusNotFound {
+			t.Errorf("status: %d, (-expected +actual)\n%s", log.HTTPRequest.Status, diff)
+		}
+
+		// 404 from the user handler
+		out.Reset()
+		r, _ = http.NewRequest("GET", "/users", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		if data := decode(t, out).AdditionalData; data["fallthrough"] != nil {
+			t.Errorf("unexpected data: %+v", data)
+		}
+	})
+
+	t.Run("echo", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		e := echo.New()
+		e.Use(RequestLoggingWithEcho(newConfig(out)))
+		e.GET("/users/:id", getUser)
+
+		tests := []struct {
+			method   string
+			path     string
+			status   int
+			expected AdditionalData
+		}{
+			{
+				method: "GET",
+				path:   "/nope",
+				status: http.StatusNotFound,
+				expected: AdditionalData{
+					"fallthrough": map[string]interface{}{"reason": "Not Found", "method": "GET", "path": "/nope"},
+				},
+			},
+			{
+				method: "POST",
+				path:   "/users/1",
+				status: http.StatusMethodNotAllowed,
+				expected: AdditionalData{
+					"route":       "/users/:id",
+					"fallthrough": map[string]interface{}{"reason": "Method Not Allowed", "method": "POST", "path": "/users/1"},
+				},
+			},
+		}
+		for _, tt := range tests {
+			out.Reset()
+			r, _ := http.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			log := decode(t, out)
+			if log.HTTPRequest.Status != tt.status || w.Code != tt.status {
+				t.Errorf("%s %s: unexpected status: %d (response: %d)", tt.method, tt.path, log.HTTPRequest.Status, w.Code)
+			}
+			if diff := cmp.Diff(tt.expected, log.AdditionalData); diff != "" {
+				t.Errorf("%s %s: (-expected +actual)\n%s", tt.method, tt.path, diff)
+			}
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package stalog
+
+import (
+	"io"
+	"time"
+)
+
+// Entry is the summary of a log for Config.Route, and the log parsed by Decoder.
+// Time, SpanID, SourceLocation, HTTPRequest and Seq are only set by Decoder.
+type Entry struct {
+	Time     time.Time
+	Severity Severity
+	Message  string
+	// Trace is "projects/[PROJECT_ID]/traces/[TRACE_ID]" or empty
+	Trace          string
+	SpanID         string
+	SourceLocation *SourceLocation
+	Labels         map[string]string
+	Data           AdditionalData
+	// HTTPRequest is the request of the request log, or the trimmed request of the context log
+	HTTPRequest *HTTPRequest
+	// Seq is "seq" of the context log with Config.Sequence
+	Seq int64
+	// RequestLog is true for the request logs, and false for the context logs
+	RequestLog bool
+}
+
+// route returns the writer for the entry by Config.Route, or out if Route is nil or returns nil
+func (c *Config) route(entry *Entry, out io.Writer) io.Writer {
+	if c.Route == nil {
+		return out
+	}
+
+	if w := c.Route(entry); w != nil {
+		return w
+	}
+
+	return out
+}
+
+// contextLogOut returns the writer for the context log
+func (l *ContextLogger) contextLogOut(log *contextLog) io.Writer {
+	if l.config.Route == nil {
+		return l.out
+	}
+
+	severity, _ := ParseSeverity(log.Severity)
+	return l.config.route(&Entry{
+		Severity: severity,
+		Message:  log.Message,
+		Trace:    log.Trace,
+		Labels:   log.Labels,
+		Data:     log.AdditionalData,
+	}, l.out)
+}
+
+// requestLogOutFor returns the writer for the request log
+func (c *Config) requestLogOutFor(requestLog *HTTPRequestLog) io.Writer {
+	if c.Route == nil {
+		return c.requestLogOut()
+	}
+
+	severity, _ := ParseSeverity(requestLog.Severity)
+	return c.route(&Entry{
+		Severity:   severity,
+		Trace:      requestLog.Trace,
+		Labels:     requestLog.Labels,
+		Data:       requestLog.AdditionalData,
+		RequestLog: true,
+	}, c.requestLogOut())
+}
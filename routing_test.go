@@ -0,0 +1,54 @@
+package stalog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoute(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	requestLogOut := new(bytes.Buffer)
+	audit := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.ContextLogOut = stdout
+	config.RequestLogOut = requestLogOut
+	config.Route = func(entry *Entry) io.Writer {
+		if entry.Data["logger"] == "audit" || (entry.RequestLog && entry.Severity >= SeverityError) {
+			return audit
+		}
+		return nil
+	}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		logger.Info("normal")
+		logger.WithFields(String("logger", "audit")).Notice("user deleted")
+		if r.URL.Path == "/fail" {
+			logger.Error("failed")
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	for _, path := range []string{"/ok", "/fail"} {
+		r, _ := http.NewRequest("GET", path, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if n := strings.Count(stdout.String(), `"message":"normal"`); n != 2 || strings.Contains(stdout.String(), "user deleted") {
+		t.Errorf("unexpected context logs: %s", stdout.String())
+	}
+	if n := strings.Count(audit.String(), `"message":"user deleted"`); n != 2 {
+		t.Errorf("unexpected audit logs: %s", audit.String())
+	}
+	if !strings.Contains(audit.String(), `"requestUrl":"/fail"`) || strings.Contains(requestLogOut.String(), `"requestUrl":"/fail"`) {
+		t.Errorf("the request log of the error should be routed: %s", audit.String())
+	}
+	if !strings.Contains(requestLogOut.String(), `"requestUrl":"/ok"`) {
+		t.Errorf("unexpected request logs: %s", requestLogOut.String())
+	}
+}
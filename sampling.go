@@ -0,0 +1,57 @@
+package stalog
+
+import (
+	"hash/fnv"
+	"strconv"
+)
+
+// sampled reports whether the log at the severity is kept by SamplingBySeverity.
+// The decision is derived from the trace ID, so all logs of a request are kept or dropped together.
+func (l *ContextLogger) sampled(severity Severity) bool {
+	if severity == SeverityDebug && l.config.SampleDebugByTraceFlag && l.traceSampled {
+		return true
+	}
+	sampling := l.config.samplingBySeverity()
+	if sampling == nil {
+		return true
+	}
+
+	rate, ok := sampling[severity]
+	if !ok {
+		return true
+	}
+
+	return SampledByTraceID(l.traceId, rate)
+}
+
+// SampledByTraceID reports whether the request of the trace is sampled at the rate.
+// The decision only depends on the trace ID, so services in other languages can make the same decision
+// by comparing the lower 64 bits of the trace ID as an unsigned integer divided by 2^64 with the rate.
+func SampledByTraceID(traceId string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	return traceFraction(traceId) < rate
+}
+
+// traceFraction maps the trace ID to a number in [0, 1).
+// Trace IDs are random 32 hex digits, so the lower 64 bits are used as they are.
+func traceFraction(traceId string) float64 {
+	var n uint64
+	if len(traceId) == 32 {
+		if v, err := strconv.ParseUint(traceId[16:], 16, 64); err == nil {
+			n = v
+		}
+	}
+	if n == 0 {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(traceId))
+		n = h.Sum64()
+	}
+
+	return float64(n>>11) / (1 << 53)
+}
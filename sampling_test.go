@@ -0,0 +1,79 @@
+package stalog
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestSamplingBySeverity(t *testing.T) {
+	config := NewConfig("test")
+	config.Severity = SeverityDebug
+	config.SamplingBySeverity = map[Severity]float64{
+		SeverityDebug: 0.5,
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		out := new(bytes.Buffer)
+		traceId := fmt.Sprintf("%016x%016x", rnd.Uint64(), rnd.Uint64())
+		config.ContextLogOut = out
+		logger := newContextLogger(config, "", traceId)
+
+		logger.Debug("1")
+		logger.Debug("2")
+		logger.Warning("3")
+
+		switch bytes.Count(out.Bytes(), []byte("\n")) {
+		case 3:
+			kept++
+		case 1:
+		default:
+			t.Fatalf("debug logs of the same trace must be sampled together: %s", out.String())
+		}
+	}
+
+	if kept < 400 || kept > 600 {
+		t.Errorf("unexpected sampling rate: %d/1000", kept)
+	}
+}
+
+func TestSampledByTraceID(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 1000; i++ {
+		traceId := fmt.Sprintf("%016x%016x", rnd.Uint64(), rnd.Uint64())
+
+		// a downstream service with the higher rate keeps all requests sampled by the upstream
+		if SampledByTraceID(traceId, 0.1) && !SampledByTraceID(traceId, 0.5) {
+			t.Fatalf("inconsistent decision for %s", traceId)
+		}
+		if SampledByTraceID(traceId, 0.3) != SampledByTraceID(traceId, 0.3) {
+			t.Fatalf("non-deterministic decision for %s", traceId)
+		}
+	}
+
+	// the lower 64 bits are compared with the rate
+	if !SampledByTraceID("ffffffffffffffff0000000000000001", 0.01) || SampledByTraceID("0000000000000000ffffffffffffffff", 0.99) {
+		t.Error("unexpected decision")
+	}
+}
+
+func TestSampleDebugByTraceFlag(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.Severity = SeverityDebug
+	config.SamplingBySeverity = map[Severity]float64{SeverityDebug: 0}
+	config.SampleDebugByTraceFlag = true
+
+	logger := newContextLogger(config, "", "105445aa7843bc8bf206b12000100000")
+	logger.Debug("dropped")
+	logger.traceSampled = true
+	logger.Debug("kept")
+
+	if bytes.Count(out.Bytes(), []byte("\n")) != 1 || !bytes.Contains(out.Bytes(), []byte("kept")) {
+		t.Errorf("unexpected logs: %s", out.String())
+	}
+}
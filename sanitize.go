@@ -0,0 +1,150 @@
+package stalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDataDepth is the max depth of the maps and the slices in AdditionalData.
+// The deeper values are replaced with truncatedValue so that encoding them doesn't exhaust the stack.
+const maxDataDepth = 32
+
+const truncatedValue = "[truncated: too deep]"
+
+// sanitizeMessage replaces the invalid UTF-8 sequences in the message with U+FFFD.
+// The control characters are escaped by encoding/json, so the JSON line is never broken by the message.
+func sanitizeMessage(msg string) string {
+	if utf8.ValidString(msg) {
+		return msg
+	}
+
+	return strings.ToValidUTF8(msg, "�")
+}
+
+// limitDataDepth returns the data whose maps and slices are nested up to maxDataDepth.
+// The data is returned as it is unless it is too deep.
+func limitDataDepth(data AdditionalData) AdditionalData {
+	if data == nil || !tooDeep(map[string]interface{}(data), maxDataDepth) {
+		return data
+	}
+
+	return AdditionalData(truncateDepth(map[string]interface{}(data), maxDataDepth).(map[string]interface{}))
+}
+
+// tooDeep reports whether the maps and the slices in v are nested deeper than depth
+func tooDeep(v interface{}, depth int) bool {
+	switch v := v.(type) {
+	case AdditionalData:
+		return tooDeep(map[string]interface{}(v), depth)
+	case map[string]interface{}:
+		if depth == 0 {
+			return true
+		}
+		for _, e := range v {
+			if tooDeep(e, depth-1) {
+				return true
+			}
+		}
+	case []interface{}:
+		if depth == 0 {
+			return true
+		}
+		for _, e := range v {
+			if tooDeep(e, depth-1) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// truncateDepth copies v replacing the values deeper than depth with truncatedValue
+func truncateDepth(v interface{}, depth int) interface{} {
+	switch v := v.(type) {
+	case AdditionalData:
+		return truncateDepth(map[string]interface{}(v), depth)
+	case map[string]interface{}:
+		if depth == 0 {
+			return truncatedValue
+		}
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = truncateDepth(e, depth-1)
+		}
+		return m
+	case []interface{}:
+		if depth == 0 {
+			return truncatedValue
+		}
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = truncateDepth(e, depth-1)
+		}
+		return s
+	}
+
+	return v
+}
+
+// unmarshalableValue is the placeholder of the values which can't be encoded to JSON
+const unmarshalableValue = "[unmarshalable]"
+
+// reportError reports the error by OnError, or prints it to stderr
+func (c *Config) reportError(err error) {
+	recordError(err)
+	if c.OnError != nil {
+		c.OnError(err)
+		return
+	}
+
+	_, _ = fmt.Fprintln(os.Stderr, err.Error())
+}
+
+// marshalReplacing encodes the log whose AdditionalData is data. If data has values which can't be encoded
+// (e.g. channels, funcs and cycles), they are replaced with the placeholder and the error is reported by OnError,
+// so that the rest of the log is kept.
+func (c *Config) marshalReplacing(log interface{}, data *AdditionalData) ([]byte, error) {
+	b, err := json.Marshal(log)
+	if err == nil || data == nil || len(*data) == 0 {
+		return b, err
+	}
+
+	*data = AdditionalData(replaceUnmarshalable(map[string]interface{}(*data), maxDataDepth).(map[string]interface{}))
+	c.reportError(fmt.Errorf("stalog: data has values which can't be encoded: %w", err))
+
+	return json.Marshal(log)
+}
+
+// replaceUnmarshalable copies v replacing the values which can't be encoded with unmarshalableValue.
+// The maps and the slices are searched up to depth to replace only the broken values.
+func replaceUnmarshalable(v interface{}, depth int) interface{} {
+	if _, err := json.Marshal(v); err == nil {
+		return v
+	}
+	if depth == 0 {
+		return unmarshalableValue
+	}
+
+	switch v := v.(type) {
+	case AdditionalData:
+		return replaceUnmarshalable(map[string]interface{}(v), depth)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = replaceUnmarshalable(e, depth-1)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = replaceUnmarshalable(e, depth-1)
+		}
+		return s
+	}
+
+	return unmarshalableValue
+}
@@ -0,0 +1,40 @@
+//go:build go1.18
+// +build go1.18
+
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// FuzzContextLog checks that each log is exactly one valid JSON object and a newline
+func FuzzContextLog(f *testing.F) {
+	f.Add("hello", "key", "value")
+	f.Add("line 1\nline 2\r\n\t", "\x00", "  ")
+	f.Add("\xff\xfe", "\xc3", "</script>")
+
+	f.Fuzz(func(t *testing.T, msg string, key string, value string) {
+		out := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.ContextLogOut = out
+		config.Severity = SeverityDefault
+
+		logger := newDefaultLogger(config)
+		logger.WithFields(String(key, value), Any("nested", []interface{}{map[string]interface{}{key: value}})).Info(msg)
+		logger.Warning(msg)
+
+		for _, line := range bytes.SplitAfter(out.Bytes(), []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			if line[len(line)-1] != '\n' || !json.Valid(line) {
+				t.Fatalf("broken line: %q", line)
+			}
+		}
+		if n := bytes.Count(out.Bytes(), []byte("\n")); n != 2 {
+			t.Fatalf("got %d lines: %q", n, out.Bytes())
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeMessage(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+
+	logger := newDefaultLogger(config)
+	logger.Info("bad \xff utf-8\nand\x00control ")
+
+	line := out.String()
+	if strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "\n") {
+		t.Fatalf("not a line: %q", line)
+	}
+
+	var log struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(line), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Message != "bad � utf-8\nand\x00control " {
+		t.Errorf("got %q", log.Message)
+	}
+}
+
+func TestLimitDataDepth(t *testing.T) {
+	deep := map[string]interface{}{"leaf": "value"}
+	for i := 0; i < 100; i++ {
+		deep = map[string]interface{}{"child": []interface{}{deep}}
+	}
+
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	newDefaultLogger(config).WithFields(Any("deep", deep)).Info("deep")
+
+	if !json.Valid(out.Bytes()) {
+		t.Fatalf("invalid JSON: %s", out.String())
+	}
+	if !strings.Contains(out.String(), truncatedValue) || strings.Contains(out.String(), "leaf") {
+		t.Errorf("not truncated: %s", out.String())
+	}
+
+	shallow := AdditionalData{"a": map[string]interface{}{"b": 1}}
+	if got := limitDataDepth(shallow); len(got) != 1 || got["a"].(map[string]interface{})["b"] != 1 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestUnmarshalableData(t *testing.T) {
+	type node struct {
+		Next *node
+	}
+	cycle := &node{}
+	cycle.Next = cycle
+
+	contextLogOut := new(bytes.Buffer)
+	requestLogOut := new(bytes.Buffer)
+	var errs []error
+	config := NewConfig("test")
+	config.ContextLogOut = contextLogOut
+	config.RequestLogOut = requestLogOut
+	config.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	config.AdditionalData = AdditionalData{"ch": make(chan int)}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).WithFields(
+			String("user", "alice"),
+			Any("fn", func() {}),
+			Any("cycle", cycle),
+			Any("nested", map[string]interface{}{"ok": 1, "nan": math.NaN()}),
+		).Info("kept")
+	}))
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var contextLog struct {
+		Message string                 `json:"message"`
+		Data    map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(contextLogOut.Bytes(), &contextLog); err != nil {
+		t.Fatal(err)
+	}
+	if contextLog.Message != "kept" || contextLog.Data["user"] != "alice" || contextLog.Data["fn"] != unmarshalableValue ||
+		contextLog.Data["cycle"] != unmarshalableValue || contextLog.Data["ch"] != unmarshalableValue {
+		t.Errorf("got %+v", contextLog)
+	}
+	if nested := contextLog.Data["nested"].(map[string]interface{}); nested["ok"] != 1.0 || nested["nan"] != unmarshalableValue {
+		t.Errorf("got %+v", nested)
+	}
+
+	var requestLog struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+		t.Fatal(err)
+	}
+	if requestLog.Data["ch"] != unmarshalableValue {
+		t.Errorf("got %+v", requestLog)
+	}
+
+	if len(errs) != 2 {
+		t.Errorf("got %v", errs)
+	}
+}
@@ -0,0 +1,50 @@
+package stalog
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	out := new(bytes.Buffer)
+	other := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.RequestLogOut = ioutil.Discard
+	config.Sequence = true
+	config.Route = func(entry *Entry) io.Writer {
+		if entry.Severity >= SeverityWarning {
+			return other
+		}
+		return nil
+	}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		logger.Info("first")
+		logger.WithFields(String("key", "value")).Warning("second")
+		logger.Info("third")
+	}))
+	for i := 0; i < 2; i++ {
+		out.Reset()
+		other.Reset()
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		entries, err := Decode(io.MultiReader(out, other))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]int64{}
+		for _, entry := range entries {
+			got[entry.Message] = entry.Seq
+		}
+		if got["first"] != 1 || got["second"] != 2 || got["third"] != 3 {
+			t.Errorf("got %v", got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package stalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timing is a segment of the request measured by Timing
+type timing struct {
+	name     string
+	duration time.Duration
+}
+
+// Timing starts measuring the segment of the request (e.g. "db") and returns the function to stop it.
+// The segments are added to the "timings" field of the request log's data, and to the Server-Timing
+// header if ServerTiming is enabled and the segment is finished before the response is written.
+//
+//	defer logger.Timing("db")()
+func (l *ContextLogger) Timing(name string) func() {
+	start := time.Now()
+	return func() {
+		l.state.mu.Lock()
+		defer l.state.mu.Unlock()
+
+		l.state.timings = append(l.state.timings, timing{name: name, duration: time.Since(start)})
+	}
+}
+
+// timingsData returns the segments measured by Timing for the request log's data
+func (l *ContextLogger) timingsData() AdditionalData {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	if len(l.state.timings) == 0 {
+		return nil
+	}
+
+	timings := make(map[string]string, len(l.state.timings))
+	for _, t := range l.state.timings {
+		timings[t.name] = fmt.Sprintf("%fs", t.duration.Seconds())
+	}
+
+	return AdditionalData{"timings": timings}
+}
+
+// serverTiming formats the Server-Timing header with the segments and the handler duration until now as "app"
+func (l *ContextLogger) serverTiming(now time.Time) string {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	metrics := make([]string, 0, len(l.state.timings)+1)
+	for _, t := range l.state.timings {
+		metrics = append(metrics, formatServerTiming(t.name, t.duration))
+	}
+	metrics = append(metrics, formatServerTiming("app", now.Sub(l.state.start)))
+
+	return strings.Join(metrics, ", ")
+}
+
+// formatServerTiming formats the metric with the duration in milliseconds
+func formatServerTiming(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.3f", name, float64(d)/float64(time.Millisecond))
+}
+
+// setServerTiming adds the Server-Timing header before the response header is written
+func (w *wrappedResponseWriter) setServerTiming(now time.Time) {
+	if w.logger == nil || !w.logger.config.ServerTiming {
+		return
+	}
+
+	w.Header().Add("Server-Timing", w.logger.serverTiming(now))
+}
@@ -0,0 +1,49 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestServerTiming(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		logger.Timing("db")()
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	for _, enabled := range []bool{true, false} {
+		out := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = out
+		config.ContextLogOut = new(bytes.Buffer)
+		config.ServerTiming = enabled
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		RequestLogging(config)(handler).ServeHTTP(w, r)
+
+		header := w.Header().Get("Server-Timing")
+		if enabled {
+			if !regexp.MustCompile(`^db;dur=\d+\.\d{3}, app;dur=\d+\.\d{3}$`).MatchString(header) {
+				t.Errorf("unexpected Server-Timing: %s", header)
+			}
+		} else if header != "" {
+			t.Errorf("unexpected Server-Timing: %s", header)
+		}
+
+		// the segments are always in the request log
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(out.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		timings, ok := httpRequestLog.AdditionalData["timings"].(map[string]interface{})
+		if !ok || timings["db"] == nil {
+			t.Errorf("unexpected data: %+v", httpRequestLog.AdditionalData)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package stalog
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// requestSizeWithHeaders estimates the size of the request including the request line and the headers
+// as the Load Balancer reports. The unknown body size is counted as 0.
+func requestSizeWithHeaders(r *http.Request) int64 {
+	// "GET /foo HTTP/1.1\r\n"
+	size := int64(len(r.Method) + 1 + len(r.URL.RequestURI()) + 1 + len(r.Proto) + 2)
+	if r.Host != "" {
+		// "Host: example.com\r\n" (net/http moves it from the headers)
+		size += int64(len("Host") + 2 + len(r.Host) + 2)
+	}
+	size += headerSize(r.Header)
+	if r.ContentLength > 0 {
+		size += r.ContentLength
+	}
+
+	return size
+}
+
+// responseSizeWithHeaders estimates the size of the response including the status line and the headers
+func responseSizeWithHeaders(proto string, status int, header http.Header, bodySize int) int64 {
+	// "HTTP/1.1 200 OK\r\n"
+	size := int64(len(fmt.Sprintf("%s %03d %s", proto, status, http.StatusText(status))) + 2)
+	size += headerSize(header)
+
+	return size + int64(bodySize)
+}
+
+// headerSize returns the size of "Key: Value\r\n" lines and the blank line
+func headerSize(header http.Header) int64 {
+	var size int64
+	for k, vs := range header {
+		for _, v := range vs {
+			size += int64(len(k) + 2 + len(v) + 2)
+		}
+	}
+
+	return size + 2
+}
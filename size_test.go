@@ -0,0 +1,42 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIncludeHeadersInSize(t *testing.T) {
+	r := httptest.NewRequest("POST", "http://example.com/foo", strings.NewReader("abc"))
+	r.Header.Set("X-A", "b")
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.IncludeHeadersInSize = true
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+
+	// "POST /foo HTTP/1.1\r\n" + "Host: example.com\r\n" + "X-A: b\r\n" + "\r\n" + "abc"
+	if httpRequestLog.HTTPRequest.RequestSize != "52" {
+		t.Errorf("unexpected request size: %s", httpRequestLog.HTTPRequest.RequestSize)
+	}
+	// "HTTP/1.1 200 OK\r\n" + "Content-Type: text/plain\r\n" + "\r\n" + "ok"
+	if httpRequestLog.HTTPRequest.ResponseSize != "47" {
+		t.Errorf("unexpected response size: %s", httpRequestLog.HTTPRequest.ResponseSize)
+	}
+}
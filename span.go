@@ -0,0 +1,109 @@
+package stalog
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opencensus.io/exporter/stackdriver/propagation"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
+)
+
+// startServerSpan starts the server span for the request.
+// It continues the span in the context or the trace from the request header if they exist.
+func startServerSpan(config *Config, r *http.Request) (context.Context, *trace.Span) {
+	name := r.URL.Path
+	kind := trace.WithSpanKind(trace.SpanKindServer)
+
+	continued := config.continueTrace(r)
+	if config.TraceExtractor != nil && continued {
+		if traceId, spanId, sampled, ok := config.TraceExtractor(r); ok {
+			if sc, ok := parseSpanContext(traceId, spanId, sampled); ok && validTraceId(sc.TraceID.String()) {
+				return trace.StartSpanWithRemoteParent(r.Context(), name, sc, kind)
+			}
+		}
+	}
+
+	if trace.FromContext(r.Context()) != nil {
+		return trace.StartSpan(r.Context(), name, kind)
+	}
+
+	if continued {
+		httpFormat := &propagation.HTTPFormat{}
+		if sc, ok := httpFormat.SpanContextFromRequest(r); ok && validTraceId(sc.TraceID.String()) {
+			return trace.StartSpanWithRemoteParent(r.Context(), name, sc, kind)
+		}
+	}
+
+	return trace.StartSpan(r.Context(), name, kind)
+}
+
+// parseSpanContext parses the hex trace ID (32 digits) and span ID (16 digits)
+func parseSpanContext(traceId string, spanId string, sampled bool) (trace.SpanContext, bool) {
+	var sc trace.SpanContext
+
+	tid, err := hex.DecodeString(traceId)
+	if err != nil || len(tid) != len(sc.TraceID) {
+		return sc, false
+	}
+	copy(sc.TraceID[:], tid)
+
+	sid, err := hex.DecodeString(spanId)
+	if err != nil || len(sid) != len(sc.SpanID) {
+		return sc, false
+	}
+	copy(sc.SpanID[:], sid)
+
+	if sampled {
+		sc.TraceOptions = 1
+	}
+
+	return sc, true
+}
+
+// endServerSpan ends the server span with the attributes of the response
+func (rv *Reserve) endServerSpan(status int, elapsed time.Duration) {
+	if rv.span == nil {
+		return
+	}
+
+	rv.span.AddAttributes(
+		trace.StringAttribute(ochttp.MethodAttribute, rv.request.Method),
+		trace.StringAttribute(ochttp.PathAttribute, rv.request.URL.Path),
+		trace.StringAttribute("http.route", rv.route()),
+		trace.Int64Attribute(ochttp.StatusCodeAttribute, int64(status)),
+		trace.StringAttribute("http.latency", fmt.Sprintf("%fs", elapsed.Seconds())),
+	)
+	rv.span.SetStatus(ochttp.TraceStatus(status, http.StatusText(status)))
+	rv.span.End()
+}
+
+// route returns the route pattern of the request if the framework provides it, or the path
+func (rv *Reserve) route() string {
+	if rv.routePattern != "" {
+		return rv.routePattern
+	}
+
+	return rv.request.URL.Path
+}
+
+// annotateSpan mirrors the log to the span in the request context as an annotation,
+// so that it is visible in the trace waterfall
+func (l *ContextLogger) annotateSpan(severity Severity, location SourceLocation, msg string) {
+	if !l.config.MirrorLogsToSpan || severity < SeverityWarning || l.request == nil {
+		return
+	}
+
+	span := trace.FromContext(l.request.Context())
+	if span == nil || !span.IsRecordingEvents() {
+		return
+	}
+
+	span.Annotate([]trace.Attribute{
+		trace.StringAttribute("severity", severity.String()),
+		trace.StringAttribute("sourceLocation", location.File+":"+location.Line),
+	}, msg)
+}
@@ -0,0 +1,102 @@
+package stalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+type testExporter struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (e *testExporter) ExportSpan(s *trace.SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, s)
+}
+
+func TestStartSpan(t *testing.T) {
+	exporter := &testExporter{}
+	trace.RegisterExporter(exporter)
+	defer trace.UnregisterExporter(exporter)
+
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.StartSpan = true
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	handler.ServeHTTP(w, r)
+
+	if len(exporter.spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(exporter.spans))
+	}
+
+	span := exporter.spans[0]
+	if span.TraceID.String() != "105445aa7843bc8bf206b12000100000" {
+		t.Errorf("the span must continue the incoming trace: %s", span.TraceID)
+	}
+	if span.SpanKind != trace.SpanKindServer || span.Name != "/foo" {
+		t.Errorf("unexpected span: kind=%d, name=%s", span.SpanKind, span.Name)
+	}
+	if span.Attributes["http.status_code"] != int64(http.StatusNotFound) || span.Attributes["http.route"] != "/foo" {
+		t.Errorf("unexpected attributes: %v", span.Attributes)
+	}
+	if span.Status.Code != trace.StatusCodeNotFound {
+		t.Errorf("unexpected status: %v", span.Status)
+	}
+	if !bytes.Contains(requestLogOut.Bytes(), []byte("traces/105445aa7843bc8bf206b12000100000")) {
+		t.Errorf("the request log must have the same trace: %s", requestLogOut.String())
+	}
+}
+
+func TestMirrorLogsToSpan(t *testing.T) {
+	exporter := &testExporter{}
+	trace.RegisterExporter(exporter)
+	defer trace.UnregisterExporter(exporter)
+
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	w := httptest.NewRecorder()
+
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = new(bytes.Buffer)
+	config.StartSpan = true
+	config.MirrorLogsToSpan = true
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		logger.Infof("info")
+		logger.Warnf("warning")
+		logger.Errorf("error")
+	}))
+	handler.ServeHTTP(w, r)
+
+	if len(exporter.spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(exporter.spans))
+	}
+
+	annotations := exporter.spans[0].Annotations
+	if len(annotations) != 2 {
+		t.Fatalf("unexpected annotations: %v", annotations)
+	}
+	if annotations[0].Message != "warning" || annotations[0].Attributes["severity"] != "WARNING" {
+		t.Errorf("unexpected annotation: %v", annotations[0])
+	}
+	if annotations[1].Message != "error" || annotations[1].Attributes["severity"] != "ERROR" {
+		t.Errorf("unexpected annotation: %v", annotations[1])
+	}
+}
@@ -2,13 +2,14 @@
 package stalog
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -29,6 +30,338 @@ type Config struct {
 
 	// nest level for runtime.Caller (default: 2)
 	Skip int
+
+	// Maximum number of context logs per request (0 means unlimited).
+	// Logs over the limit are suppressed and summarized when the request is finished.
+	MaxEntriesPerRequest int
+
+	// Process-wide rate limit of context logs per call site in entries per second (0 means unlimited).
+	// Suppressed logs are summarized when the call site is allowed to log again.
+	RateLimit float64
+
+	// Burst size of RateLimit (default: 1)
+	RateLimitBurst int
+
+	// Process-wide budget of context logs in bytes per ByteBudgetPeriod (0 means unlimited).
+	// Once it is exceeded, only WARNING and more severe logs are written until the window resets,
+	// and a NOTICE about the throttling is written, which protects the billing during log storms.
+	ByteBudget int64
+
+	// Window of ByteBudget (default: 1 minute)
+	ByteBudgetPeriod time.Duration
+
+	// Sampling rate of context logs for each severity (e.g. SeverityDebug: 0.01).
+	// Severities which are not in the map are always logged.
+	// The decision is made by the trace ID (see SampledByTraceID), so all logs of a sampled request are kept together
+	// and services with the same rate make the same decision for the request.
+	SamplingBySeverity map[Severity]float64
+
+	// Number of the latest DEBUG logs kept per request even when they are below Severity (0 means disabled).
+	// The kept logs are written only when an ERROR or more severe log is written later in the same request.
+	DebugTailSize int
+
+	// Recover panics in handlers, log them with the stack trace and respond 500 Internal Server Error
+	Recover bool
+
+	// Add the ID of the goroutine which writes the log to context logs
+	GoroutineID bool
+
+	// Labels which are automatically added to all logs (e.g. EnrichHostname | EnrichZone).
+	// Values from the metadata server are fetched in the background from the first log and omitted outside GCP.
+	Enrich Enrich
+
+	// Service name and version for all logs, which is required by Error Reporting
+	ServiceContext ServiceContext
+
+	// Log names of request logs, context logs and audit logs (see ContextLogger.Audit)
+	// emitted as "logging.googleapis.com/logName" field,
+	// so that sinks and exclusions can target each stream on agents which support it (default: empty).
+	// Audit logs use ContextLogName if AuditLogName is empty.
+	RequestLogName string
+	ContextLogName string
+	AuditLogName   string
+
+	// Write request logs to ContextLogOut as well, so both logs are in one stream in the order of writing.
+	// It is useful on platforms like Cloud Run where stdout and stderr are collected in the same way
+	// and two file descriptors may reorder the logs.
+	SingleStream bool
+
+	// Add the trimmed httpRequest (method, URL and status if it is already written) to context logs.
+	// It is useful when request logs are not available.
+	EmbedHTTPRequestInContextLogs bool
+
+	// Don't write request logs, for environments which already log requests (e.g. App Engine).
+	// Context logs are still correlated by the trace.
+	DisableRequestLog bool
+
+	// Response header name to echo the trace ID (e.g. "X-Trace-Id"), so that clients can refer to the logs.
+	// Empty means disabled.
+	TraceResponseHeader string
+
+	// Format of the TraceResponseHeader value (default: the trace ID itself). See also TraceURL.
+	TraceResponseFormat func(projectId, traceId string) string
+
+	// Start a server span for each request with the status, route and latency attributes.
+	// Spans are exported by the exporters registered with trace.RegisterExporter of OpenCensus.
+	StartSpan bool
+
+	// Mirror WARNING or more severe context logs to the span in the request context as annotations
+	MirrorLogsToSpan bool
+
+	// Extract the trace context from the request instead of the built-in extraction
+	// (e.g. B3TraceExtractor or custom headers). When ok is false, the built-in extraction is used.
+	TraceExtractor TraceExtractor
+
+	// Labels for all logs ("logging.googleapis.com/labels")
+	Labels map[string]string
+
+	// URL paths of the requests which don't need request logs (e.g. "/healthz").
+	// Context logs in the requests are still written.
+	SkipPaths []string
+
+	// Settings which override Severity, SamplingBySeverity and SkipPaths without restart (see WatchConfig)
+	Dynamic *ConfigWatcher
+
+	// SeverityResolver returns the minimum severity of context logs for each request instead of Severity,
+	// so that the verbosity can be driven by a feature-flag system for specific users or percentages.
+	// Return Severity of the config to keep the default, which follows WatchConfig.
+	SeverityResolver func(r *http.Request) Severity
+
+	// Output format of logs (default: FormatJSON)
+	Format Format
+
+	// Minimum severity of context logs which have the source location (default: SeverityDefault, all logs)
+	SourceLocationSeverity Severity
+
+	// Parse the User-Agent into the "userAgent" field of the request log's data
+	ParseUserAgent bool
+
+	// Parser of the User-Agent (default: DefaultUserAgentParser)
+	UserAgentParser UserAgentParser
+
+	// Resolve the location of the client into the "clientLocation" field of the request log's data
+	// (e.g. HeaderClientLocationResolver)
+	ClientLocationResolver ClientLocationResolver
+
+	// Record the TLS version, the cipher suite and SNI into the "tls" field of the request log's data
+	LogTLS bool
+
+	// Record the subject of the client certificate too with LogTLS (for mTLS)
+	LogClientCertificate bool
+
+	// Determine the protocol of the request log for exotic setups.
+	// When it returns "", the built-in detection (HTTP/1.1, HTTP/2.0, h2c, HTTP/3, gRPC, gRPC-Web) is used.
+	ProtocolFunc func(r *http.Request) string
+
+	// Include the estimated size of the request/status line and the headers in requestSize and responseSize
+	// to reconcile with the Load Balancer's request logs
+	IncludeHeadersInSize bool
+
+	// Record the time until the first WriteHeader/Write and the time after it into the request log's data
+	// to distinguish slow handlers from slow clients
+	LogLatencyBreakdown bool
+
+	// Write request logs only when the max severity of the request or the severity derived from the status
+	// (5xx: ERROR, 4xx: WARNING) reaches it (default: SeverityDefault, all requests)
+	RequestLogMinSeverity Severity
+
+	// Skip request logs of 2xx/3xx requests which have no context logs at or above Severity
+	QuietRequestLog bool
+
+	// HTTP methods of the requests which don't need request logs (e.g. "OPTIONS", "HEAD").
+	// Requests with ERROR or more severe logs or 5xx status are still logged.
+	SkipMethods []string
+
+	// Status codes of the requests which don't need request logs (e.g. 404 from scanners).
+	// Requests with ERROR or more severe logs are still logged.
+	SkipStatuses []int
+
+	// Skip request logs of CORS preflight requests, which are logged at DEBUG with "is_preflight" by default.
+	// Requests with ERROR or more severe logs or 5xx status are still logged.
+	SkipPreflight bool
+
+	// Template of the error response body of WriteError (default: the status and the trace ID in plain text)
+	ErrorTemplate ErrorTemplate
+
+	// Content-Type of the error response rendered by ErrorTemplate
+	ErrorContentType string
+
+	// Add the stack trace to the error logs of WriteError for Error Reporting
+	ErrorStackTrace bool
+
+	// Keys of the labels which are propagated to downstream services by InjectContext
+	// and accepted from upstream services by the middlewares (e.g. "tenant", "requestId").
+	// Like the trace, the labels are accepted only if TracePolicy continues the trace of the request.
+	PropagateLabels []string
+
+	// Keys of the W3C Baggage entries which become labels of the request,
+	// and are propagated to downstream services by Transport
+	BaggageLabels []string
+
+	// Project ID of the traces when it differs from ProjectId, the project of the logs (default: ProjectId)
+	TraceProjectID string
+
+	// Resolve the project ID of the trace per request. When it returns "", TraceProjectID is used.
+	TraceProjectResolver func(r *http.Request) string
+
+	// Ignore the trace headers from clients (including TraceExtractor) and always start new traces,
+	// e.g. for public-facing services. Malformed trace IDs are always replaced with new ones.
+	// It is the shorthand of TracePolicy: AlwaysNewTrace, and takes precedence over TracePolicy.
+	IgnoreTraceHeaders bool
+
+	// Decide whether the trace from the request headers is continued per request
+	// (ContinueTrace, AlwaysNewTrace or ContinueTraceIfTrusted; default: ContinueTrace)
+	TracePolicy TracePolicy
+
+	// Keep DEBUG logs of the requests whose trace is sampled by the upstream (e.g. "o=1" or the flag of "traceparent")
+	// regardless of SamplingBySeverity, so that all services follow the decision of the first service
+	SampleDebugByTraceFlag bool
+
+	// Add the Server-Timing header with the handler duration until the response is written ("app")
+	// and the segments measured by Timing
+	ServerTiming bool
+
+	// Aggregate the requests per route and log the summaries periodically (see NewRouteSummary)
+	RouteSummary *RouteSummary
+
+	// TenantExtractor returns the tenant of the request (e.g. from a header or the host),
+	// to which the logs of the request are attributed in TenantUsage
+	TenantExtractor func(r *http.Request) string
+
+	// Count the entries and the bytes of the logs per tenant and report them periodically (see NewTenantUsage)
+	TenantUsage *TenantUsage
+
+	// Route decides the writer of each log (e.g. by the label, the severity or data["logger"]).
+	// ContextLogOut or RequestLogOut is used if it returns nil.
+	Route func(entry *Entry) io.Writer
+
+	// OnError is called with the errors of encoding and writing the logs, e.g. the values of data
+	// which can't be encoded to JSON (default: printed to stderr)
+	OnError func(err error)
+
+	// Key of AdditionalData in the logs (default: "data")
+	DataKey string
+
+	// Keys of AdditionalData which are written at the top level of the logs instead of in DataKey,
+	// so that log-based metrics and BigQuery schemas expecting the top-level fields keep working.
+	// The keys must not collide with the fields of the logs like "severity" and "message".
+	PromoteFields []string
+
+	// Write the fields in the stable order regardless of the version of this package: "time", "severity" and "message" first,
+	// then the other fields sorted by the key, and DataKey last with the sorted keys.
+	// It's useful for golden-file tests and line-oriented parsers, but it costs an extra pass per log.
+	StableOrder bool
+
+	// Make the time of the logs strictly increasing in the process by nudging the time which isn't after
+	// the last log by 1ns, so that the order of the logs with the same time in Logs Explorer matches the order of writing
+	MonotonicTime bool
+
+	// Add "seq", the number of the context log in the request starting from 1, so that consumers can reconstruct
+	// the order of the logs written to multiple writers
+	Sequence bool
+
+	// Behavior of the middleware when an outer middleware has already created the request-context logger
+	// (NestedReuse, NestedChildOperation or NestedSeparate; default: NestedReuse)
+	Nested NestedPolicy
+
+	// Validate each log by ValidateEntry and panic if it's invalid, for the tests and the CI runs of the service
+	// to catch the misconfiguration (e.g. TraceExtractor returning malformed traces) before deploy
+	Strict bool
+
+	// Severities of the gRPC status codes by the name (e.g. "NotFound") for the gRPC requests served by the middlewares,
+	// which override DefaultGRPCSeverities. The severity of the request log is the higher of it and the context logs.
+	GRPCSeverities map[string]Severity
+
+	// Max bytes of the request body read by the handler to be added to the request log as "requestBody" (default: 0, disabled).
+	// Form-encoded and JSON bodies are parsed with redacting secret-like keys, and the other bodies are skipped
+	// unless CaptureTextRequestBody.
+	CaptureRequestBody int
+
+	// Keep the XML and text request bodies as strings with CaptureRequestBody (default: false, skipped).
+	// They can't be redacted, so enable it only if the bodies have no secrets.
+	CaptureTextRequestBody bool
+
+	// Add "multipart", the names, the file names, the content types and the sizes of the parts of multipart/form-data
+	// requests read by the handler, to the request log. The contents of the parts are never logged.
+	LogMultipartSummary bool
+
+	// Max bytes of the response body of 5xx to be added to the request log as "responseErrorBody" (default: 0, disabled).
+	// The bodies of other statuses aren't captured.
+	CaptureErrorResponse int
+}
+
+// traceProject returns the project ID of the trace of the request. r is nil outside HTTP requests.
+func (c *Config) traceProject(r *http.Request) string {
+	if c.TraceProjectResolver != nil && r != nil {
+		if projectId := c.TraceProjectResolver(r); projectId != "" {
+			return projectId
+		}
+	}
+	if c.TraceProjectID != "" {
+		return c.TraceProjectID
+	}
+
+	return c.ProjectId
+}
+
+// traceResource formats the trace ID as "projects/[PROJECT_ID]/traces/[TRACE_ID]"
+func (c *Config) traceResource(r *http.Request, traceId string) string {
+	return fmt.Sprintf("projects/%s/traces/%s", c.traceProject(r), traceId)
+}
+
+// labels returns Labels with the enrichment labels
+func (c *Config) labels() map[string]string {
+	enriched := enrichLabels(c.Enrich)
+	if len(c.Labels) == 0 {
+		return enriched
+	}
+
+	labels := make(map[string]string, len(c.Labels)+len(enriched))
+	for k, v := range enriched {
+		labels[k] = v
+	}
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// skipPath reports whether the request log for the path is skipped
+func (c *Config) skipPath(path string) bool {
+	for _, p := range c.skipPaths() {
+		if p == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestLogOut returns the output for request logs
+func (c *Config) requestLogOut() io.Writer {
+	if c.SingleStream {
+		return c.ContextLogOut
+	}
+
+	return c.RequestLogOut
+}
+
+// ServiceContext identifies the service of the logs. More details:
+// https://cloud.google.com/error-reporting/reference/rest/v1beta1/ServiceContext
+type ServiceContext struct {
+	Service string `json:"service"`
+	Version string `json:"version,omitempty"`
+}
+
+// serviceContext returns the service context to be logged, or nil if it is not configured
+func (c *Config) serviceContext() *ServiceContext {
+	if c.ServiceContext.Service == "" {
+		return nil
+	}
+
+	sc := c.ServiceContext
+	return &sc
 }
 
 // NewConfig creates a config with default settings.
@@ -59,6 +392,17 @@ const (
 	SeverityEmergency
 )
 
+// ParseSeverity parses the text representation of the severity like "INFO" (case insensitive)
+func ParseSeverity(s string) (Severity, error) {
+	for severity := SeverityDefault; severity <= SeverityEmergency; severity += 100 {
+		if strings.EqualFold(s, severity.String()) {
+			return severity, nil
+		}
+	}
+
+	return SeverityDefault, fmt.Errorf("stalog: unknown severity: %s", s)
+}
+
 // String returns text representation for the severity
 func (s Severity) String() string {
 	switch s {
@@ -92,12 +436,31 @@ type SourceLocation struct {
 }
 
 type contextLog struct {
-	Time           string         `json:"time"`
-	Trace          string         `json:"logging.googleapis.com/trace"`
-	SourceLocation SourceLocation `json:"logging.googleapis.com/sourceLocation"`
-	Severity       string         `json:"severity"`
-	Message        string         `json:"message"`
-	AdditionalData AdditionalData `json:"data,omitempty"`
+	Time           string            `json:"time"`
+	Trace          string            `json:"logging.googleapis.com/trace,omitempty"`
+	SpanID         string            `json:"logging.googleapis.com/spanId,omitempty"`
+	TraceSampled   bool              `json:"logging.googleapis.com/trace_sampled,omitempty"`
+	LogName        string            `json:"logging.googleapis.com/logName,omitempty"`
+	SourceLocation *SourceLocation   `json:"logging.googleapis.com/sourceLocation,omitempty"`
+	Severity       string            `json:"severity"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
+	Message        string            `json:"message"`
+	StackTrace     string            `json:"stack_trace,omitempty"`
+	Type           string            `json:"@type,omitempty"`
+	GoroutineID    uint64            `json:"goroutineId,omitempty"`
+	WorkerID       string            `json:"workerId,omitempty"`
+	Operation      *Operation        `json:"logging.googleapis.com/operation,omitempty"`
+	ServiceContext *ServiceContext   `json:"serviceContext,omitempty"`
+	HTTPRequest    *contextRequest   `json:"httpRequest,omitempty"`
+	Seq            int64             `json:"seq,omitempty"`
+	AdditionalData AdditionalData    `json:"data,omitempty"`
+}
+
+// contextRequest is the trimmed HTTPRequest for context logs
+type contextRequest struct {
+	RequestMethod string `json:"requestMethod"`
+	RequestUrl    string `json:"requestUrl"`
+	Status        int    `json:"status,omitempty"`
 }
 
 // ContextLogger is the logger which is combined with the request
@@ -106,15 +469,81 @@ type ContextLogger struct {
 	Trace          string
 	Severity       Severity
 	AdditionalData AdditionalData
-	loggedSeverity []Severity
 	Skip           int
+
+	config       *Config
+	traceId      string
+	spanId       string
+	traceSampled bool
+	workerId     string
+	operation    *Operation
+	encodedData  []byte
+	labels       map[string]string
+	request      *http.Request
+	subrequest   *subrequest
+	state        *loggerState
+	// timestamp is the time of the logs instead of the current time (e.g. the timestamp of OTel records)
+	timestamp time.Time
+	// logName is the log name of the logs instead of ContextLogName (e.g. AuditLogName)
+	logName string
+	// stackTrace is attached to the logs as the error for Error Reporting (e.g. by WriteError)
+	stackTrace string
+	// initialSeverity is Severity when the logger is created. While Severity isn't overridden
+	// (by SeverityResolver or the user), the severity of the config is read for each log to follow WatchConfig.
+	initialSeverity  Severity
+	severityResolved bool
+}
+
+// loggerState is the state of the request shared by the logger and its derived loggers
+type loggerState struct {
+	maxSeverity     int64 // accessed atomically, placed first for 64-bit alignment
+	logCount        int64 // accessed atomically
+	seq             int64 // accessed atomically
+	mu              sync.Mutex
+	entries         int
+	suppressed      int
+	suppressedLevel Severity
+	debugTail       []*contextLog
+	debugTailNext   int
+	status          int
+	start           time.Time
+	timings         []timing
+	subrequests     []subrequestResult
+	labels          map[string]string
+	handlerName     string
+	tenant          string
+}
+
+func newContextLogger(config *Config, trace string, traceId string) *ContextLogger {
+	severity := config.severity()
+	return &ContextLogger{
+		out:             config.ContextLogOut,
+		Trace:           trace,
+		Severity:        severity,
+		AdditionalData:  config.AdditionalData,
+		Skip:            config.Skip,
+		config:          config,
+		traceId:         traceId,
+		labels:          config.labels(),
+		state:           &loggerState{start: time.Now()},
+		initialSeverity: severity,
+	}
+}
+
+// minSeverity returns the minimum severity of the logs: Severity if it's overridden,
+// otherwise the current severity of the config
+func (l *ContextLogger) minSeverity() Severity {
+	if l.severityResolved || l.Severity != l.initialSeverity {
+		return l.Severity
+	}
+
+	return l.config.severity()
 }
 
 // RequestContextLogger gets request-context logger for the request.
 // You must use `RequestLogging` middleware in advance for this function to work.
 func RequestContextLogger(r *http.Request) *ContextLogger {
-	v, _ := r.Context().Value(ContextLoggerKey).(*ContextLogger)
-	return v
+	return LoggerFromContext(r.Context())
 }
 
 // Default logs a message at DEFAULT severity
@@ -268,15 +697,70 @@ func (l *ContextLogger) Emergencyln(args ...interface{}) {
 }
 
 func (l *ContextLogger) write(severity Severity, msg string) error {
-	if severity < l.Severity {
+	return l.writeAt(severity, nil, msg)
+}
+
+// writeAt writes the log at the source location, or at the caller of write if location is nil
+func (l *ContextLogger) writeAt(severity Severity, location *SourceLocation, msg string) error {
+	// the source location is resolved lazily because runtime.Caller is expensive
+	// (write is the extra frame)
+	if severity < l.minSeverity() {
+		if severity == SeverityDebug && l.config.DebugTailSize > 0 {
+			if location == nil {
+				caller := sourceLocation(l.Skip + 1)
+				location = &caller
+			}
+			l.keepDebugTail(l.newLog(severity, *location, msg))
+		}
+		return nil
+	}
+
+	l.state.logged(severity)
+	l.subrequest.logged(severity)
+
+	if !l.sampled(severity) {
+		countDropped(dropSampling)
+		return nil
+	}
+
+	if location == nil {
+		caller := sourceLocation(l.Skip + 1)
+		location = &caller
+	}
+
+	if !l.allow(severity) {
 		return nil
 	}
 
-	l.loggedSeverity = append(l.loggedSeverity, severity)
+	if l.config.RateLimit > 0 {
+		key := rateLimitKey(l.config, location)
+		ok, suppressed, level := processRateLimiter.allow(key, severity, l.config.RateLimit, l.config.RateLimitBurst, time.Now())
+		if !ok {
+			countDropped(dropRateLimit)
+			return nil
+		}
+		if suppressed > 0 {
+			_ = l.output(l.newLog(level, *location, fmt.Sprintf("%d similar messages suppressed", suppressed)))
+		}
+	}
 
-	// get source location
+	if !l.allowByteBudget(severity) {
+		return nil
+	}
+
+	if severity >= SeverityError {
+		l.flushDebugTail()
+	}
+
+	l.annotateSpan(severity, *location, msg)
+
+	return l.output(l.newLog(severity, *location, msg))
+}
+
+// sourceLocation gets the source location like runtime.Caller(skip) called by the caller of this function
+func sourceLocation(skip int) SourceLocation {
 	var location SourceLocation
-	if pc, file, line, ok := runtime.Caller(l.Skip); ok {
+	if pc, file, line, ok := runtime.Caller(skip + 1); ok {
 		if function := runtime.FuncForPC(pc); function != nil {
 			location.Function = function.Name()
 		}
@@ -285,35 +769,121 @@ func (l *ContextLogger) write(severity Severity, msg string) error {
 		location.File = parts[len(parts)-1] // use short file name
 	}
 
+	return location
+}
+
+func (l *ContextLogger) newLog(severity Severity, location SourceLocation, msg string) *contextLog {
 	log := &contextLog{
-		Time:           time.Now().Format(time.RFC3339Nano),
+		Time:           l.logTime(),
 		Trace:          l.Trace,
-		SourceLocation: location,
+		SpanID:         l.spanId,
+		TraceSampled:   l.traceSampled,
+		LogName:        l.contextLogName(),
 		Severity:       severity.String(),
-		Message:        msg,
+		Labels:         l.currentLabels(),
+		Message:        sanitizeMessage(msg),
+		WorkerID:       l.workerId,
+		Operation:      l.operation,
+		ServiceContext: l.config.serviceContext(),
 		AdditionalData: l.AdditionalData,
 	}
+	if location.File != "" && severity >= l.config.SourceLocationSeverity {
+		log.SourceLocation = &location
+	}
+	if l.stackTrace != "" {
+		log.StackTrace = l.stackTrace
+		log.Type = reportedErrorEventType
+	}
+	if l.config.EmbedHTTPRequestInContextLogs && l.request != nil {
+		l.state.mu.Lock()
+		status := l.state.status
+		l.state.mu.Unlock()
+
+		log.HTTPRequest = &contextRequest{
+			RequestMethod: l.request.Method,
+			RequestUrl:    l.request.URL.RequestURI(),
+			Status:        status,
+		}
+	}
+	if l.config.GoroutineID {
+		log.GoroutineID = goroutineID()
+	}
+	if l.config.Sequence {
+		log.Seq = atomic.AddInt64(&l.state.seq, 1)
+	}
+
+	return log
+}
+
+func (l *ContextLogger) output(log *contextLog) error {
+	out := l.contextLogOut(log)
+	if l.config.Format == FormatConsole {
+		b := log.console()
+		if _, err := out.Write(b); err != nil {
+			l.config.reportError(err)
+			return err
+		}
+		l.config.TenantUsage.record(l.tenant(), len(b))
+		l.countByteBudget(len(b))
+		return nil
+	}
+
+	jsonByte, err := l.marshal(log)
+	if err != nil {
+		l.config.reportError(err)
+		return err
+	}
+
+	// append \n
+	jsonByte = append(jsonByte, 0xa)
+
+	if _, err = out.Write(jsonByte); err != nil {
+		l.config.reportError(err)
+		return err
+	}
+	l.config.TenantUsage.record(l.tenant(), len(jsonByte))
+	l.countByteBudget(len(jsonByte))
+	return nil
+}
 
-	jsonByte, err := json.Marshal(log)
+// writeJSON writes the log as a line of JSON. data is AdditionalData of the log.
+func writeJSON(config *Config, out io.Writer, log interface{}, data *AdditionalData) error {
+	jsonByte, err := config.marshalLog(log, data)
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err.Error())
 		return err
 	}
 
 	// append \n
 	jsonByte = append(jsonByte, 0xa)
 
-	_, err = l.out.Write(jsonByte)
+	_, err = out.Write(jsonByte)
 	return err
 }
 
-func (l *ContextLogger) maxSeverity() Severity {
-	max := SeverityDefault
-	for _, s := range l.loggedSeverity {
-		if s > max {
-			max = s
+func (l *ContextLogger) setStatus(status int) {
+	l.state.mu.Lock()
+	l.state.status = status
+	l.state.mu.Unlock()
+}
+
+// MaxSeverity returns the max severity of the logs written in the request so far.
+// Applications can use it to know whether the request had errors.
+func (l *ContextLogger) MaxSeverity() Severity {
+	return Severity(atomic.LoadInt64(&l.state.maxSeverity))
+}
+
+// logged counts the log and updates the max severity without locks
+func (s *loggerState) logged(severity Severity) {
+	atomic.AddInt64(&s.logCount, 1)
+	s.raise(severity)
+}
+
+// raise raises the max severity of the request without counting a log (e.g. by the gRPC status)
+func (s *loggerState) raise(severity Severity) {
+	for {
+		current := atomic.LoadInt64(&s.maxSeverity)
+		if int64(severity) <= current || atomic.CompareAndSwapInt64(&s.maxSeverity, current, int64(severity)) {
+			return
 		}
 	}
-
-	return max
 }
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -177,3 +178,235 @@ func TestNoContextLog(t *testing.T) {
 		t.Errorf("context log exists: %s", contextLogOut.String())
 	}
 }
+
+func TestServiceContext(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.ServiceContext = ServiceContext{Service: "foo", Version: "1.0"}
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Errorf("error")
+	}))
+	handler.ServeHTTP(w, r)
+
+	expected := &ServiceContext{Service: "foo", Version: "1.0"}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(httpRequestLog.ServiceContext, expected) {
+		t.Errorf("diff: %s", cmp.Diff(httpRequestLog.ServiceContext, expected))
+	}
+
+	var cLog contextLog
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(cLog.ServiceContext, expected) {
+		t.Errorf("diff: %s", cmp.Diff(cLog.ServiceContext, expected))
+	}
+}
+
+func TestSingleStream(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.SingleStream = true
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Infof("1")
+		RequestContextLogger(r).Infof("2")
+	}))
+	handler.ServeHTTP(w, r)
+
+	if requestLogOut.Len() != 0 {
+		t.Errorf("request log must not be written to RequestLogOut: %s", requestLogOut.String())
+	}
+
+	logs := strings.Split(strings.TrimSuffix(contextLogOut.String(), "\n"), "\n")
+	if len(logs) != 3 {
+		t.Fatalf("unexpected logs: %s", contextLogOut.String())
+	}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal([]byte(logs[2]), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.HTTPRequest.RequestUrl != "/foo" {
+		t.Errorf("the last log must be the request log: %s", logs[2])
+	}
+}
+
+func TestEmbedHTTPRequestInContextLogs(t *testing.T) {
+	r, _ := http.NewRequest("POST", "/foo?bar=baz", nil)
+	w := httptest.NewRecorder()
+
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = contextLogOut
+	config.EmbedHTTPRequestInContextLogs = true
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Infof("before")
+		w.WriteHeader(http.StatusCreated)
+		RequestContextLogger(r).Infof("after")
+	}))
+	handler.ServeHTTP(w, r)
+
+	logs := strings.Split(strings.TrimSuffix(contextLogOut.String(), "\n"), "\n")
+	expected := []*contextRequest{
+		{RequestMethod: "POST", RequestUrl: "/foo?bar=baz"},
+		{RequestMethod: "POST", RequestUrl: "/foo?bar=baz", Status: http.StatusCreated},
+	}
+	if len(logs) != len(expected) {
+		t.Fatalf("unexpected logs: %s", contextLogOut.String())
+	}
+	for idx, log := range logs {
+		var cLog contextLog
+		if err := json.Unmarshal([]byte(log), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(cLog.HTTPRequest, expected[idx]) {
+			t.Errorf("diff: %s", cmp.Diff(cLog.HTTPRequest, expected[idx]))
+		}
+	}
+}
+
+func TestDisableRequestLog(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.DisableRequestLog = true
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := RequestContextLogger(r)
+		logger.Infof("1")
+		if logger.MaxSeverity() != SeverityInfo {
+			t.Errorf("unexpected max severity: %s", logger.MaxSeverity())
+		}
+	}))
+	handler.ServeHTTP(w, r)
+
+	if requestLogOut.Len() != 0 {
+		t.Errorf("request log exists: %s", requestLogOut.String())
+	}
+	if contextLogOut.Len() == 0 {
+		t.Error("context log doesn't exist")
+	}
+}
+
+func TestRequestLoggingWithFuncResponse(t *testing.T) {
+	r, _ := http.NewRequest("POST", "/hook", nil)
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	RequestLoggingWithFunc(config, w, r, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	})
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	// the status and the size written by next are logged
+	if httpRequestLog.HTTPRequest.Status != http.StatusCreated || httpRequestLog.HTTPRequest.ResponseSize != "7" {
+		t.Errorf("unexpected httpRequest: %+v", httpRequestLog.HTTPRequest)
+	}
+	if w.Code != http.StatusCreated || w.Body.String() != "created" {
+		t.Errorf("unexpected response: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestTraceExtractor(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-My-Trace", "4bf92f3577b34da6a3ce929d0e0e4736")
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.TraceExtractor = func(r *http.Request) (string, string, bool, bool) {
+		traceId := r.Header.Get("X-My-Trace")
+		return traceId, "00f067aa0ba902b7", true, traceId != ""
+	}
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Infof("1")
+	}))
+	handler.ServeHTTP(w, r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	var cLog contextLog
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, got := range [][3]interface{}{
+		{httpRequestLog.Trace, httpRequestLog.SpanID, httpRequestLog.TraceSampled},
+		{cLog.Trace, cLog.SpanID, cLog.TraceSampled},
+	} {
+		expected := [3]interface{}{"projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true}
+		if got != expected {
+			t.Errorf("unexpected trace: %v", got)
+		}
+	}
+}
+
+func TestBuildRequestLog(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo?bar=1", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+
+	config := NewConfig("test")
+	config.Labels = map[string]string{"env": "test"}
+
+	log := BuildRequestLog(config, r, http.StatusOK, 10, time.Second, "105445aa7843bc8bf206b12000100000", SeverityInfo)
+	if log.Trace != "projects/test/traces/105445aa7843bc8bf206b12000100000" {
+		t.Errorf("unexpected trace: %s", log.Trace)
+	}
+	if log.Severity != "INFO" || log.Labels["env"] != "test" {
+		t.Errorf("unexpected log: %+v", log)
+	}
+
+	expected := HTTPRequest{
+		RequestMethod: "GET",
+		RequestUrl:    "/foo?bar=1",
+		RequestSize:   "0",
+		Status:        http.StatusOK,
+		ResponseSize:  "10",
+		RemoteIP:      "192.0.2.1",
+		ServerIP:      log.HTTPRequest.ServerIP,
+		Latency:       "1.000000s",
+		Protocol:      "HTTP/1.1",
+	}
+	if diff := cmp.Diff(expected, log.HTTPRequest); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+}
@@ -0,0 +1,76 @@
+package stalog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Flush sends the buffered data to the client if the underlying ResponseWriter supports it
+func (w *wrappedResponseWriter) Flush() {
+	if w.status == 0 {
+		w.setStatus(http.StatusOK)
+	}
+	w.streamed = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, so that http.ResponseController (Go 1.20+)
+// can use its features such as SetReadDeadline and EnableFullDuplex through the middleware
+func (w *wrappedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ReadFrom counts the bytes copied by io.Copy, keeping the underlying io.ReaderFrom (e.g. sendfile) effective
+func (w *wrappedResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.setStatus(http.StatusOK)
+	}
+	w.streamed = true
+
+	rf, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		// hide ReadFrom from io.Copy to avoid the recursion
+		return io.Copy(writerOnly{w}, r)
+	}
+
+	// r is passed as is, because the underlying ReadFrom uses sendfile only for *os.File.
+	// The first byte is sent right after ReadFrom starts, so the time is recorded here.
+	if w.firstByteAt.IsZero() {
+		w.firstByteAt = time.Now()
+	}
+	n, err := rf.ReadFrom(r)
+	w.responseSize += int(n)
+	return n, err
+}
+
+type writerOnly struct {
+	io.Writer
+}
+
+// streamData returns the time to first byte of the streamed response (flushed, copied by io.Copy or written in pieces)
+func (w *wrappedResponseWriter) streamData(before time.Time) AdditionalData {
+	if !w.streamed || w.firstByteAt.IsZero() {
+		return nil
+	}
+
+	return AdditionalData{"timeToFirstByte": fmt.Sprintf("%fs", w.firstByteAt.Sub(before).Seconds())}
+}
+
+// latencyBreakdown splits the latency at the first WriteHeader/Write.
+// A long timeToFirstWrite means a slow handler, and a long writeLatency means a slow client or a large response.
+func (w *wrappedResponseWriter) latencyBreakdown(before time.Time, elapsed time.Duration) AdditionalData {
+	breakdown := map[string]string{
+		"total": fmt.Sprintf("%fs", elapsed.Seconds()),
+	}
+	if !w.firstWriteAt.IsZero() {
+		firstWrite := w.firstWriteAt.Sub(before)
+		breakdown["timeToFirstWrite"] = fmt.Sprintf("%fs", firstWrite.Seconds())
+		breakdown["writeLatency"] = fmt.Sprintf("%fs", (elapsed - firstWrite).Seconds())
+	}
+
+	return AdditionalData{"latency": breakdown}
+}
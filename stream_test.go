@@ -0,0 +1,153 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamedResponse(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte("data: ping\n\n"))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		// LimitReader hides WriterTo of strings.Reader to use ReadFrom of the ResponseWriter
+		_, _ = io.Copy(w, io.LimitReader(strings.NewReader(strings.Repeat("a", 100000)), 100000))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	server := httptest.NewServer(RequestLogging(config)(mux))
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		size     string
+		streamed bool
+	}{
+		{path: "/events", size: "36", streamed: true},
+		{path: "/download", size: "100000", streamed: true},
+		{path: "/plain", size: "2", streamed: false},
+	}
+	for _, tt := range tests {
+		requestLogOut.Reset()
+		res, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		_ = res.Body.Close()
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		if httpRequestLog.HTTPRequest.ResponseSize != tt.size {
+			t.Errorf("%s: unexpected response size: %s", tt.path, httpRequestLog.HTTPRequest.ResponseSize)
+		}
+		if _, ok := httpRequestLog.AdditionalData["timeToFirstByte"]; ok != tt.streamed {
+			t.Errorf("%s: unexpected data: %+v", tt.path, httpRequestLog.AdditionalData)
+		}
+	}
+}
+
+func TestReadFromWithoutReaderFrom(t *testing.T) {
+	w := httptest.NewRecorder()
+	wrw := &wrappedResponseWriter{ResponseWriter: w}
+
+	n, err := io.Copy(wrw, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || wrw.responseSize != 5 || wrw.status != http.StatusOK || w.Body.String() != "hello" {
+		t.Errorf("unexpected result: n=%d, size=%d, status=%d", n, wrw.responseSize, wrw.status)
+	}
+}
+
+func TestLogLatencyBreakdown(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.LogLatencyBreakdown = true
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	latency, _ := httpRequestLog.AdditionalData["latency"].(map[string]interface{})
+	for _, key := range []string{"total", "timeToFirstWrite", "writeLatency"} {
+		s, _ := latency[key].(string)
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 20*time.Millisecond {
+			t.Errorf("unexpected %s: %v", key, latency[key])
+		}
+	}
+}
+
+// readerFromRecorder records the reader passed to ReadFrom
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	reader io.Reader
+}
+
+func (w *readerFromRecorder) ReadFrom(r io.Reader) (int64, error) {
+	w.reader = r
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestReadFromKeepsFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.WriteString("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	wrw := &wrappedResponseWriter{ResponseWriter: w}
+	if _, err := wrw.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+
+	// sendfile of net/http needs *os.File
+	if _, ok := w.reader.(*os.File); !ok {
+		t.Errorf("the file must be passed as is: %T", w.reader)
+	}
+	if wrw.responseSize != 5 || wrw.firstByteAt.IsZero() {
+		t.Errorf("unexpected result: size=%d, firstByteAt=%v", wrw.responseSize, wrw.firstByteAt)
+	}
+}
@@ -0,0 +1,130 @@
+package stalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	strictTracePattern    = regexp.MustCompile(`^projects/[^/]+/traces/[0-9a-f]{32}$`)
+	strictSpanIDPattern   = regexp.MustCompile(`^[0-9a-f]{16}$`)
+	strictDurationPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?s$`)
+)
+
+// strictEntry has the special fields of Cloud Logging to be validated
+type strictEntry struct {
+	Time           *string                    `json:"time"`
+	Severity       *string                    `json:"severity"`
+	Trace          *string                    `json:"logging.googleapis.com/trace"`
+	SpanID         *string                    `json:"logging.googleapis.com/spanId"`
+	TraceSampled   interface{}                `json:"logging.googleapis.com/trace_sampled"`
+	Labels         map[string]interface{}     `json:"logging.googleapis.com/labels"`
+	SourceLocation map[string]json.RawMessage `json:"logging.googleapis.com/sourceLocation"`
+	Operation      map[string]interface{}     `json:"logging.googleapis.com/operation"`
+	HTTPRequest    map[string]interface{}     `json:"httpRequest"`
+}
+
+// ValidateEntry checks that the log in JSON follows the special fields of the structured logs of Cloud Logging:
+// the formats of time, severity, trace and spanId, the labels of strings, and the types of sourceLocation and httpRequest.
+// More details: https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+func ValidateEntry(line []byte) error {
+	var e strictEntry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return fmt.Errorf("stalog: invalid entry: %w", err)
+	}
+
+	if e.Time != nil {
+		if _, err := time.Parse(time.RFC3339Nano, *e.Time); err != nil {
+			return fmt.Errorf("stalog: invalid time: %q", *e.Time)
+		}
+	}
+	if e.Severity == nil {
+		return fmt.Errorf("stalog: no severity")
+	}
+	if severity, err := ParseSeverity(*e.Severity); err != nil || severity.String() != *e.Severity {
+		return fmt.Errorf("stalog: invalid severity: %q", *e.Severity)
+	}
+	if e.Trace != nil && *e.Trace != "" && !strictTracePattern.MatchString(*e.Trace) {
+		return fmt.Errorf("stalog: invalid trace: %q", *e.Trace)
+	}
+	if e.SpanID != nil && *e.SpanID != "" && !strictSpanIDPattern.MatchString(*e.SpanID) {
+		return fmt.Errorf("stalog: invalid spanId: %q", *e.SpanID)
+	}
+	if _, ok := e.TraceSampled.(bool); e.TraceSampled != nil && !ok {
+		return fmt.Errorf("stalog: trace_sampled isn't bool: %v", e.TraceSampled)
+	}
+	for k, v := range e.Labels {
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("stalog: label %q isn't string: %v", k, v)
+		}
+	}
+	if err := validateSourceLocation(e.SourceLocation); err != nil {
+		return err
+	}
+	if _, ok := e.Operation["id"].(string); e.Operation != nil && !ok {
+		return fmt.Errorf("stalog: operation has no id")
+	}
+
+	return validateHTTPRequest(e.HTTPRequest)
+}
+
+func validateSourceLocation(location map[string]json.RawMessage) error {
+	if location == nil {
+		return nil
+	}
+
+	var line string
+	if err := json.Unmarshal(location["line"], &line); err != nil {
+		return fmt.Errorf("stalog: sourceLocation.line isn't string: %s", location["line"])
+	}
+	if _, err := strconv.ParseInt(line, 10, 64); err != nil {
+		return fmt.Errorf("stalog: invalid sourceLocation.line: %q", line)
+	}
+
+	return nil
+}
+
+func validateHTTPRequest(r map[string]interface{}) error {
+	if r == nil {
+		return nil
+	}
+
+	if status, ok := r["status"]; ok {
+		if n, ok := status.(float64); !ok || n != float64(int(n)) {
+			return fmt.Errorf("stalog: invalid httpRequest.status: %v", status)
+		}
+	}
+	// the sizes are int64 formatted as strings
+	for _, key := range []string{"requestSize", "responseSize"} {
+		if v, ok := r[key]; ok {
+			s, isString := v.(string)
+			if !isString {
+				return fmt.Errorf("stalog: httpRequest.%s isn't string: %v", key, v)
+			}
+			if _, err := strconv.ParseInt(s, 10, 64); s != "" && err != nil {
+				return fmt.Errorf("stalog: invalid httpRequest.%s: %q", key, s)
+			}
+		}
+	}
+	if v, ok := r["latency"]; ok {
+		if s, isString := v.(string); !isString || (s != "" && !strictDurationPattern.MatchString(s)) {
+			return fmt.Errorf("stalog: invalid httpRequest.latency: %v", v)
+		}
+	}
+
+	return nil
+}
+
+// validateStrict panics if the log is invalid with Strict
+func (c *Config) validateStrict(b []byte) {
+	if !c.Strict {
+		return
+	}
+
+	if err := ValidateEntry(b); err != nil {
+		panic(fmt.Errorf("%w: %s", err, b))
+	}
+}
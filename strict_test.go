@@ -0,0 +1,68 @@
+package stalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = new(bytes.Buffer)
+	config.Strict = true
+	config.Labels = map[string]string{"app": "api"}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Child("op").Warning("hello")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	r, _ := http.NewRequest("POST", "/foo", strings.NewReader("body"))
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	config.TraceExtractor = func(r *http.Request) (string, string, bool, bool) {
+		return "105445aa7843bc8bf206b12000100000", "", false, true
+	}
+	config.ProjectId = "a/b"
+	func() {
+		defer func() {
+			if v := recover(); v == nil || !strings.Contains(v.(error).Error(), "invalid trace") {
+				t.Errorf("got %v", v)
+			}
+		}()
+		newDefaultLogger(config).WithFields().Info("hello")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+}
+
+func TestValidateEntry(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		err  string
+	}{
+		{`{"severity":"INFO","message":"a"}`, ""},
+		{`{"message":"a"}`, "no severity"},
+		{`{"severity":"info"}`, "invalid severity"},
+		{`{"severity":"INFO","time":"yesterday"}`, "invalid time"},
+		{`{"severity":"INFO","logging.googleapis.com/trace":"105445aa7843bc8bf206b12000100000"}`, "invalid trace"},
+		{`{"severity":"INFO","logging.googleapis.com/spanId":"xyz"}`, "invalid spanId"},
+		{`{"severity":"INFO","logging.googleapis.com/trace_sampled":"true"}`, "trace_sampled"},
+		{`{"severity":"INFO","logging.googleapis.com/labels":{"n":1}}`, "label"},
+		{`{"severity":"INFO","logging.googleapis.com/sourceLocation":{"file":"a.go","line":12}}`, "sourceLocation.line"},
+		{`{"severity":"INFO","httpRequest":{"status":"200"}}`, "httpRequest.status"},
+		{`{"severity":"INFO","httpRequest":{"responseSize":12}}`, "httpRequest.responseSize"},
+		{`{"severity":"INFO","httpRequest":{"latency":"12ms"}}`, "httpRequest.latency"},
+		{`{"severity":"INFO","httpRequest":{"status":200,"requestSize":"4","latency":"0.001000s"}}`, ""},
+	} {
+		err := ValidateEntry([]byte(tt.line))
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: %v", tt.line, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: got %v, want %s", tt.line, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package stalog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// subrequest is an internal dispatch of the request created by Subrequest
+type subrequest struct {
+	maxSeverity int64 // accessed atomically, placed first for 64-bit alignment
+	name        string
+	operationID string
+	parent      *subrequest
+}
+
+// logged updates the max severity of the subrequest and its parents
+func (s *subrequest) logged(severity Severity) {
+	for ; s != nil; s = s.parent {
+		for {
+			current := atomic.LoadInt64(&s.maxSeverity)
+			if int64(severity) <= current || atomic.CompareAndSwapInt64(&s.maxSeverity, current, int64(severity)) {
+				break
+			}
+		}
+	}
+}
+
+// subrequestResult is the result of a finished subrequest for the request log
+type subrequestResult struct {
+	Name        string `json:"name"`
+	OperationID string `json:"operationId"`
+	Latency     string `json:"latency"`
+	Severity    string `json:"severity"`
+}
+
+// Subrequest starts a nested group of the logs for an internal dispatch of the request
+// (e.g. server-side includes and internal fan-out handlers), and returns the context with its logger
+// and the function to finish it. The logs of the subrequest have a new operation ID with name as the producer,
+// and their severities are rolled up into the request log. The latency and the max severity of each subrequest
+// are added to the "subrequests" field of the request log's data.
+// ctx is returned as it is if it doesn't have the request-context logger.
+//
+//	ctx, end := stalog.Subrequest(r.Context(), "render-header")
+//	defer end()
+func Subrequest(ctx context.Context, name string) (context.Context, func()) {
+	parent := LoggerFromContext(ctx)
+	if parent == nil {
+		return ctx, func() {}
+	}
+
+	sub := &subrequest{name: name, operationID: newOperationID(), parent: parent.subrequest}
+	child := *parent
+	child.operation = &Operation{ID: sub.operationID, Producer: name}
+	child.subrequest = sub
+
+	start := time.Now()
+	var once sync.Once
+	return ContextWithLogger(ctx, &child), func() {
+		once.Do(func() {
+			result := subrequestResult{
+				Name:        name,
+				OperationID: sub.operationID,
+				Latency:     fmt.Sprintf("%fs", time.Since(start).Seconds()),
+				Severity:    Severity(atomic.LoadInt64(&sub.maxSeverity)).String(),
+			}
+
+			parent.state.mu.Lock()
+			defer parent.state.mu.Unlock()
+			parent.state.subrequests = append(parent.state.subrequests, result)
+		})
+	}
+}
+
+// subrequestsData returns the results of the subrequests for the request log's data
+func (l *ContextLogger) subrequestsData() AdditionalData {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+
+	if len(l.state.subrequests) == 0 {
+		return nil
+	}
+
+	subrequests := make([]subrequestResult, len(l.state.subrequests))
+	copy(subrequests, l.state.subrequests)
+	return AdditionalData{"subrequests": subrequests}
+}
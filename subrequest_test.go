@@ -0,0 +1,67 @@
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSubrequest(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Info("parent")
+
+		ctx, end := Subrequest(r.Context(), "header")
+		LoggerFromContext(ctx).Warning("in header")
+		end()
+		end()
+
+		ctx, end = Subrequest(r.Context(), "footer")
+		LoggerFromContext(ctx).Info("in footer")
+		end()
+	}))
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var requestLog struct {
+		Severity string `json:"severity"`
+		Data     struct {
+			Subrequests []subrequestResult `json:"subrequests"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(requestLogOut.Bytes(), &requestLog); err != nil {
+		t.Fatal(err)
+	}
+	if requestLog.Severity != "WARNING" {
+		t.Errorf("severity isn't rolled up: %s", requestLog.Severity)
+	}
+	subs := requestLog.Data.Subrequests
+	if len(subs) != 2 || subs[0].Name != "header" || subs[0].Severity != "WARNING" || subs[1].Name != "footer" ||
+		subs[1].Severity != "INFO" || subs[0].OperationID == "" || subs[0].Latency == "" {
+		t.Fatalf("got %+v", subs)
+	}
+
+	if !bytes.Contains(contextLogOut.Bytes(), []byte(`"producer":"header"`)) {
+		t.Errorf("no operation: %s", contextLogOut.String())
+	}
+
+	entries, err := Decode(contextLogOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+
+	if ctx, end := Subrequest(context.Background(), "none"); ctx != context.Background() || end == nil {
+		t.Error("unexpected context")
+	}
+}
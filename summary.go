@@ -0,0 +1,150 @@
+package stalog
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// routeSummaryMaxSamples is the max number of the latencies kept per route in an interval for percentiles
+const routeSummaryMaxSamples = 1024
+
+// RouteSummary aggregates the requests per route in the process and periodically logs a summary per route
+// (request count, error count, p50/p95 latency and the histogram of the severities),
+// which gives cheap SLO visibility without a metrics stack.
+// Set it to Config.RouteSummary to aggregate the requests of the middlewares.
+type RouteSummary struct {
+	logger *ContextLogger
+	mu     sync.Mutex
+	routes map[string]*routeStats
+	start  time.Time
+	ticks  *periodic
+}
+
+// routeStats is the aggregation of a route in an interval
+type routeStats struct {
+	method     string
+	route      string
+	count      int
+	errors     int
+	severities map[string]int
+	latencies  []time.Duration
+}
+
+// NewRouteSummary creates RouteSummary which logs the summaries to ContextLogOut of the config at every interval
+func NewRouteSummary(config *Config, interval time.Duration) *RouteSummary {
+	s := &RouteSummary{
+		logger: newDefaultLogger(config).WithFields(String("logger", "stalog.summary")),
+		routes: make(map[string]*routeStats),
+		start:  time.Now(),
+	}
+	s.ticks = startPeriodic(interval, s.Flush, s.Flush)
+
+	return s
+}
+
+// Close logs the summaries of the current interval and stops logging
+func (s *RouteSummary) Close() error {
+	s.ticks.close()
+	return nil
+}
+
+// Flush logs the summaries of the requests since the last summaries and resets the aggregation
+func (s *RouteSummary) Flush() {
+	now := time.Now()
+
+	s.mu.Lock()
+	routes := s.routes
+	interval := now.Sub(s.start)
+	s.routes = make(map[string]*routeStats)
+	s.start = now
+	s.mu.Unlock()
+
+	keys := make([]string, 0, len(routes))
+	for key := range routes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		stats := routes[key]
+		logger := s.logger.WithFields(Any("summary", stats.summary(interval)))
+		_ = logger.output(logger.newLog(SeverityInfo, SourceLocation{}, "route summary: "+key))
+	}
+}
+
+// record adds the request to the aggregation
+func (s *RouteSummary) record(method string, route string, status int, severity Severity, elapsed time.Duration) {
+	key := method + " " + route
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.routes[key]
+	if !ok {
+		stats = &routeStats{method: method, route: route, severities: make(map[string]int)}
+		s.routes[key] = stats
+	}
+
+	stats.count++
+	if status >= 500 || severity >= SeverityError {
+		stats.errors++
+	}
+	stats.severities[severity.String()]++
+
+	// reservoir sampling keeps the memory bounded for busy routes
+	if len(stats.latencies) < routeSummaryMaxSamples {
+		stats.latencies = append(stats.latencies, elapsed)
+	} else if i := rand.Intn(stats.count); i < routeSummaryMaxSamples {
+		stats.latencies[i] = elapsed
+	}
+}
+
+// summary returns the summary for the log's data. The latencies are in seconds.
+func (s *routeStats) summary(interval time.Duration) map[string]interface{} {
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+
+	return map[string]interface{}{
+		"method":     s.method,
+		"route":      s.route,
+		"count":      s.count,
+		"errors":     s.errors,
+		"errorRate":  float64(s.errors) / float64(s.count),
+		"p50":        percentile(s.latencies, 0.50).Seconds(),
+		"p95":        percentile(s.latencies, 0.95).Seconds(),
+		"severities": s.severities,
+		"interval":   interval.Seconds(),
+	}
+}
+
+// percentile returns the percentile of the sorted durations by the nearest-rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return sorted[i]
+}
+
+// summaryRoute returns the route of the request for RouteSummary.
+// The path isn't used so as not to aggregate per ID or the like.
+func (rv *Reserve) summaryRoute() string {
+	if rv.routePattern != "" {
+		return rv.routePattern
+	}
+	if name := rv.contextLogger.handlerName(); name != "" {
+		return name
+	}
+	if rv.unmatched {
+		return "unmatched"
+	}
+
+	return "*"
+}
@@ -0,0 +1,84 @@
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRouteSummary(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = new(bytes.Buffer)
+	config.ContextLogOut = new(bytes.Buffer)
+
+	summaryConfig := NewConfig("test")
+	summaryConfig.ContextLogOut = out
+	config.RouteSummary = NewRouteSummary(summaryConfig, time.Hour)
+
+	e := echo.New()
+	e.Use(RequestLoggingWithEcho(config))
+	e.GET("/users/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/nowhere"} {
+		r, _ := http.NewRequest("GET", path, nil)
+		e.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if err := config.RouteSummary.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	summaries := map[string]map[string]interface{}{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var cLog contextLog
+		if err := json.Unmarshal(scanner.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		summary := cLog.AdditionalData["summary"].(map[string]interface{})
+		summaries[cLog.Message] = summary
+	}
+
+	users, ok := summaries["route summary: GET /users/:id"]
+	if !ok {
+		t.Fatalf("no summary of the route: %+v", summaries)
+	}
+	if users["count"] != float64(3) || users["errors"] != float64(1) {
+		t.Errorf("unexpected summary: %+v", users)
+	}
+	if severities := users["severities"].(map[string]interface{}); severities["ERROR"] != float64(1) || severities["DEFAULT"] != float64(2) {
+		t.Errorf("unexpected severities: %+v", severities)
+	}
+	if _, ok := summaries["route summary: GET unmatched"]; !ok {
+		t.Errorf("no summary of unmatched requests: %+v", summaries)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	if p := percentile(sorted, 0.50); p != 50*time.Millisecond {
+		t.Errorf("unexpected p50: %s", p)
+	}
+	if p := percentile(sorted, 0.95); p != 95*time.Millisecond {
+		t.Errorf("unexpected p95: %s", p)
+	}
+	if p := percentile(nil, 0.95); p != 0 {
+		t.Errorf("unexpected p95 of empty: %s", p)
+	}
+}
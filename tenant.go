@@ -0,0 +1,110 @@
+package stalog
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TenantUsage counts the entries and the bytes of the logs per tenant (see Config.TenantExtractor)
+// and periodically logs the usage of each tenant and/or calls the hook with it,
+// so that the cost of Cloud Logging can be attributed to the tenants.
+// Set it to Config.TenantUsage to count the logs of the middlewares.
+type TenantUsage struct {
+	logger  *ContextLogger
+	hook    func(tenant string, entries int64, bytes int64)
+	mu      sync.Mutex
+	tenants map[string]*tenantVolume
+	start   time.Time
+	ticks   *periodic
+}
+
+// tenantVolume is the volume of the logs of a tenant in an interval
+type tenantVolume struct {
+	entries int64
+	bytes   int64
+}
+
+// NewTenantUsage creates TenantUsage which logs the usage to ContextLogOut of the config at every interval.
+// The hook is called with the usage of each tenant at every interval (e.g. to export it to the metrics).
+// Either config or hook can be nil.
+func NewTenantUsage(config *Config, interval time.Duration, hook func(tenant string, entries int64, bytes int64)) *TenantUsage {
+	u := &TenantUsage{
+		hook:    hook,
+		tenants: make(map[string]*tenantVolume),
+		start:   time.Now(),
+	}
+	if config != nil {
+		u.logger = newDefaultLogger(config).WithFields(String("logger", "stalog.tenants"))
+	}
+	u.ticks = startPeriodic(interval, u.Flush, u.Flush)
+
+	return u
+}
+
+// Close reports the usage of the current interval and stops reporting
+func (u *TenantUsage) Close() error {
+	u.ticks.close()
+	return nil
+}
+
+// Flush reports the usage since the last report and resets the counters
+func (u *TenantUsage) Flush() {
+	now := time.Now()
+
+	u.mu.Lock()
+	tenants := u.tenants
+	interval := now.Sub(u.start)
+	u.tenants = make(map[string]*tenantVolume)
+	u.start = now
+	u.mu.Unlock()
+
+	keys := make([]string, 0, len(tenants))
+	for key := range tenants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, tenant := range keys {
+		volume := tenants[tenant]
+		if u.hook != nil {
+			u.hook(tenant, volume.entries, volume.bytes)
+		}
+		if u.logger != nil {
+			logger := u.logger.WithFields(Any("usage", map[string]interface{}{
+				"tenant":   tenant,
+				"entries":  volume.entries,
+				"bytes":    volume.bytes,
+				"interval": interval.Seconds(),
+			}))
+			_ = logger.output(logger.newLog(SeverityInfo, SourceLocation{}, "tenant usage: "+tenant))
+		}
+	}
+}
+
+// record adds a log of the tenant to the counters
+func (u *TenantUsage) record(tenant string, bytes int) {
+	if u == nil || tenant == "" {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	volume, ok := u.tenants[tenant]
+	if !ok {
+		volume = &tenantVolume{}
+		u.tenants[tenant] = volume
+	}
+	volume.entries++
+	volume.bytes += int64(bytes)
+}
+
+// tenant returns the tenant of the logger's request
+func (l *ContextLogger) tenant() string {
+	if l.state == nil {
+		return ""
+	}
+
+	return l.state.tenant
+}
@@ -0,0 +1,91 @@
+package stalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTenantUsage(t *testing.T) {
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+	config.TenantExtractor = func(r *http.Request) string {
+		return r.Header.Get("X-Tenant")
+	}
+
+	out := new(bytes.Buffer)
+	usageConfig := NewConfig("test")
+	usageConfig.ContextLogOut = out
+
+	var mu sync.Mutex
+	hooked := map[string][2]int64{}
+	config.TenantUsage = NewTenantUsage(usageConfig, time.Hour, func(tenant string, entries int64, bytes int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		hooked[tenant] = [2]int64{entries, bytes}
+	})
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestContextLogger(r).Info("hello")
+	}))
+	for _, tenant := range []string{"a", "a", "b", ""} {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tenant != "" {
+			r.Header.Set("X-Tenant", tenant)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if err := config.TenantUsage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hooked) != 2 {
+		t.Fatalf("unexpected tenants: %+v", hooked)
+	}
+	if hooked["a"][0] != 4 || hooked["b"][0] != 2 {
+		t.Errorf("unexpected entries: %+v", hooked)
+	}
+	// the untenanted request is not counted
+	total := hooked["a"][1] + hooked["b"][1]
+	all := int64(requestLogOut.Len() + contextLogOut.Len())
+	if total <= 0 || total >= all {
+		t.Errorf("unexpected bytes: %d of %d", total, all)
+	}
+
+	usages := map[string]map[string]interface{}{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var cLog contextLog
+		if err := json.Unmarshal(scanner.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		usages[cLog.Message] = cLog.AdditionalData["usage"].(map[string]interface{})
+	}
+	if usage := usages["tenant usage: b"]; usage == nil || usage["entries"] != float64(2) || usage["bytes"] != float64(hooked["b"][1]) {
+		t.Errorf("unexpected usage: %+v", usages)
+	}
+}
+
+func TestTenantUsageFlushResets(t *testing.T) {
+	var calls int
+	u := NewTenantUsage(nil, time.Hour, func(tenant string, entries int64, bytes int64) {
+		calls++
+	})
+	defer u.Close()
+
+	u.record("a", 10)
+	u.Flush()
+	u.Flush()
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
@@ -0,0 +1,48 @@
+package stalog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// TLSInfo is the TLS connection metadata of the request
+type TLSInfo struct {
+	Version           string `json:"version"`
+	CipherSuite       string `json:"cipherSuite"`
+	ServerName        string `json:"serverName,omitempty"`
+	ClientCertSubject string `json:"clientCertSubject,omitempty"`
+}
+
+// tlsInfo returns the TLS connection metadata of the request if LogTLS is enabled
+func (c *Config) tlsInfo(r *http.Request) *TLSInfo {
+	if !c.LogTLS || r.TLS == nil {
+		return nil
+	}
+
+	info := &TLSInfo{
+		Version:     tlsVersionName(r.TLS.Version),
+		CipherSuite: cipherSuiteName(r.TLS.CipherSuite),
+		ServerName:  r.TLS.ServerName,
+	}
+	if c.LogClientCertificate && len(r.TLS.PeerCertificates) > 0 {
+		info.ClientCertSubject = r.TLS.PeerCertificates[0].Subject.String()
+	}
+
+	return info
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", version)
+	}
+}
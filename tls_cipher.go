@@ -0,0 +1,11 @@
+//go:build go1.14
+// +build go1.14
+
+package stalog
+
+import "crypto/tls"
+
+// cipherSuiteName returns the standard name of the cipher suite (e.g. "TLS_AES_128_GCM_SHA256")
+func cipherSuiteName(id uint16) string {
+	return tls.CipherSuiteName(id)
+}
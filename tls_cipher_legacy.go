@@ -0,0 +1,11 @@
+//go:build !go1.14
+// +build !go1.14
+
+package stalog
+
+import "fmt"
+
+// cipherSuiteName returns the ID of the cipher suite in hex, because tls.CipherSuiteName is Go 1.14+
+func cipherSuiteName(id uint16) string {
+	return fmt.Sprintf("0x%04X", id)
+}
@@ -0,0 +1,55 @@
+package stalog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogTLS(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.TLS = &tls.ConnectionState{
+		Version:     tls.VersionTLS13,
+		CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+		ServerName:  "example.com",
+		PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "client"}},
+		},
+	}
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.LogTLS = true
+	config.LogClientCertificate = true
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	expected := AdditionalData{
+		"tls": map[string]interface{}{
+			"version":           "TLS 1.3",
+			"cipherSuite":       "TLS_AES_128_GCM_SHA256",
+			"serverName":        "example.com",
+			"clientCertSubject": "CN=client",
+		},
+	}
+	if diff := cmp.Diff(expected, httpRequestLog.AdditionalData); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+}
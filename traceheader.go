@@ -0,0 +1,27 @@
+package stalog
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// TraceURL formats the URL of Cloud Trace for the trace.
+// It can be used for Config.TraceResponseFormat.
+func TraceURL(projectId, traceId string) string {
+	return fmt.Sprintf("https://console.cloud.google.com/traces/list?project=%s&tid=%s", url.QueryEscape(projectId), url.QueryEscape(traceId))
+}
+
+// setTraceResponseHeader echoes the trace ID to the response header if it is configured
+func (rv *Reserve) setTraceResponseHeader(w http.ResponseWriter) {
+	if rv.config.TraceResponseHeader == "" {
+		return
+	}
+
+	value := rv.contextLogger.traceId
+	if rv.config.TraceResponseFormat != nil {
+		value = rv.config.TraceResponseFormat(rv.config.traceProject(rv.request), rv.contextLogger.traceId)
+	}
+
+	w.Header().Set(rv.config.TraceResponseHeader, value)
+}
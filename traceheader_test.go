@@ -0,0 +1,41 @@
+package stalog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceResponseHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		format func(projectId, traceId string) string
+		want   string
+	}{
+		{"trace ID", nil, "105445aa7843bc8bf206b12000100000"},
+		{"trace URL", TraceURL, "https://console.cloud.google.com/traces/list?project=test&tid=105445aa7843bc8bf206b12000100000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/foo", nil)
+			r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+			w := httptest.NewRecorder()
+
+			config := NewConfig("test")
+			config.RequestLogOut = new(bytes.Buffer)
+			config.ContextLogOut = new(bytes.Buffer)
+			config.TraceResponseHeader = "X-Trace-Id"
+			config.TraceResponseFormat = tt.format
+			handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("X-Trace-Id"); got != tt.want {
+				t.Errorf("unexpected header: %s", got)
+			}
+		})
+	}
+}
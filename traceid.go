@@ -0,0 +1,30 @@
+package stalog
+
+import (
+	"strings"
+)
+
+// validTraceId reports whether the trace ID is 32 lower-case hex digits and not all zeros,
+// which Cloud Logging can correlate with Cloud Trace
+func validTraceId(traceId string) bool {
+	return len(traceId) == 32 && isHex(traceId) && strings.Trim(traceId, "0") != ""
+}
+
+// validSpanId reports whether the span ID is 16 lower-case hex digits and not all zeros
+func validSpanId(spanId string) bool {
+	return len(spanId) == 16 && isHex(spanId) && strings.Trim(spanId, "0") != ""
+}
+
+// normalizeIncomingTrace normalizes the trace from clients. It drops the invalid span ID,
+// and ok is false if the trace ID is invalid so that a new trace ID is generated instead.
+func normalizeIncomingTrace(traceId string, spanId string) (string, string, bool) {
+	traceId, spanId = strings.ToLower(traceId), strings.ToLower(spanId)
+	if !validTraceId(traceId) {
+		return "", "", false
+	}
+	if !validSpanId(spanId) {
+		spanId = ""
+	}
+
+	return traceId, spanId, true
+}
@@ -0,0 +1,82 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIncomingTraceValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor TraceExtractor
+		header    string
+		ignore    bool
+		expected  string
+	}{
+		{
+			name:     "valid header",
+			header:   "105445aa7843bc8bf206b12000100000/1;o=1",
+			expected: "105445aa7843bc8bf206b12000100000",
+		},
+		{
+			name:   "zero trace ID",
+			header: "00000000000000000000000000000000/1;o=1",
+		},
+		{
+			name: "malformed extractor result",
+			extractor: func(r *http.Request) (string, string, bool, bool) {
+				return "not-a-trace-id", "", false, true
+			},
+		},
+		{
+			name: "upper-case extractor result",
+			extractor: func(r *http.Request) (string, string, bool, bool) {
+				return "105445AA7843BC8BF206B12000100000", "zz", false, true
+			},
+			expected: "105445aa7843bc8bf206b12000100000",
+		},
+		{
+			name:   "ignored header",
+			header: "105445aa7843bc8bf206b12000100000/1;o=1",
+			ignore: true,
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		if tt.header != "" {
+			r.Header.Set("X-Cloud-Trace-Context", tt.header)
+		}
+
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.TraceExtractor = tt.extractor
+		config.IgnoreTraceHeaders = tt.ignore
+
+		RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(httptest.NewRecorder(), r)
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		traceId := strings.TrimPrefix(httpRequestLog.Trace, "projects/test/traces/")
+		if !validTraceId(traceId) {
+			t.Errorf("%s: invalid trace ID: %s", tt.name, traceId)
+		}
+		if tt.expected != "" && traceId != tt.expected {
+			t.Errorf("%s: expected %s, but got %s", tt.name, tt.expected, traceId)
+		}
+		if tt.expected == "" && traceId == "105445aa7843bc8bf206b12000100000" {
+			t.Errorf("%s: the trace ID from the client must not be used", tt.name)
+		}
+		if tt.name == "upper-case extractor result" && httpRequestLog.SpanID != "" {
+			t.Errorf("%s: invalid span ID must be dropped: %s", tt.name, httpRequestLog.SpanID)
+		}
+	}
+}
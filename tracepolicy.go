@@ -0,0 +1,79 @@
+package stalog
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TracePolicy reports whether the trace from the request headers is continued.
+// When it returns false, a new trace is started for the request.
+type TracePolicy func(r *http.Request) bool
+
+// ContinueTrace always continues the trace from the request headers (the default)
+func ContinueTrace(r *http.Request) bool {
+	return true
+}
+
+// AlwaysNewTrace always starts a new trace, e.g. for public-facing services
+// which shouldn't adopt trace IDs controlled by clients
+func AlwaysNewTrace(r *http.Request) bool {
+	return false
+}
+
+// ContinueTraceIfTrusted continues the trace only from the trusted peers.
+// The peers are IP addresses or CIDRs matched with the remote address of the request.
+// It panics if a peer is invalid.
+func ContinueTraceIfTrusted(peers ...string) TracePolicy {
+	nets := make([]*net.IPNet, 0, len(peers))
+	for _, peer := range peers {
+		cidr := peer
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Errorf("stalog: invalid trusted peer: %s", peer))
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return func(r *http.Request) bool {
+		ip := net.ParseIP(getRemoteIP(r))
+		if ip == nil {
+			return false
+		}
+
+		for _, ipNet := range nets {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// tracePolicy returns TracePolicy, or AlwaysNewTrace if IgnoreTraceHeaders is set. nil means ContinueTrace.
+func (c *Config) tracePolicy() TracePolicy {
+	if c.IgnoreTraceHeaders {
+		return AlwaysNewTrace
+	}
+
+	return c.TracePolicy
+}
+
+// continueTrace reports whether the trace from the request headers is continued
+func (c *Config) continueTrace(r *http.Request) bool {
+	if policy := c.tracePolicy(); policy != nil {
+		return policy(r)
+	}
+
+	return true
+}
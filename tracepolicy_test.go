@@ -0,0 +1,63 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracePolicy(t *testing.T) {
+	trusted := ContinueTraceIfTrusted("10.0.0.0/8", "2001:db8::1")
+
+	tests := []struct {
+		name       string
+		policy     TracePolicy
+		ignore     bool
+		remoteAddr string
+		continued  bool
+	}{
+		{name: "continue", policy: ContinueTrace, remoteAddr: "192.0.2.1:1234", continued: true},
+		{name: "always new", policy: AlwaysNewTrace, remoteAddr: "10.0.0.1:1234", continued: false},
+		{name: "trusted IPv4", policy: trusted, remoteAddr: "10.1.2.3:1234", continued: true},
+		{name: "trusted IPv6", policy: trusted, remoteAddr: "[2001:db8::1]:1234", continued: true},
+		{name: "untrusted", policy: trusted, remoteAddr: "192.0.2.1:1234", continued: false},
+		{name: "ignore trace headers", ignore: true, remoteAddr: "192.0.2.1:1234", continued: false},
+		{name: "ignore trace headers over policy", policy: trusted, ignore: true, remoteAddr: "10.1.2.3:1234", continued: false},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		r.RemoteAddr = tt.remoteAddr
+		r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+
+		requestLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = new(bytes.Buffer)
+		config.TracePolicy = tt.policy
+		config.IgnoreTraceHeaders = tt.ignore
+
+		RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(httptest.NewRecorder(), r)
+
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		continued := httpRequestLog.Trace == "projects/test/traces/105445aa7843bc8bf206b12000100000"
+		if continued != tt.continued {
+			t.Errorf("%s: expected continued=%v, but got %s", tt.name, tt.continued, httpRequestLog.Trace)
+		}
+	}
+}
+
+func TestContinueTraceIfTrustedPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid peer must panic")
+		}
+	}()
+
+	ContinueTraceIfTrusted("not-an-ip")
+}
@@ -0,0 +1,70 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceProject(t *testing.T) {
+	tests := []struct {
+		name     string
+		traceId  string
+		resolver func(r *http.Request) string
+		header   string
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: "projects/test/traces/105445aa7843bc8bf206b12000100000",
+		},
+		{
+			name:     "TraceProjectID",
+			traceId:  "trace-project",
+			expected: "projects/trace-project/traces/105445aa7843bc8bf206b12000100000",
+		},
+		{
+			name:    "resolver",
+			traceId: "trace-project",
+			resolver: func(r *http.Request) string {
+				return r.Header.Get("X-Trace-Project")
+			},
+			header:   "frontend",
+			expected: "projects/frontend/traces/105445aa7843bc8bf206b12000100000",
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", "/foo", nil)
+		r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+		if tt.header != "" {
+			r.Header.Set("X-Trace-Project", tt.header)
+		}
+
+		requestLogOut := new(bytes.Buffer)
+		contextLogOut := new(bytes.Buffer)
+		config := NewConfig("test")
+		config.RequestLogOut = requestLogOut
+		config.ContextLogOut = contextLogOut
+		config.TraceProjectID = tt.traceId
+		config.TraceProjectResolver = tt.resolver
+
+		RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			RequestContextLogger(r).Infof("hello")
+		})).ServeHTTP(httptest.NewRecorder(), r)
+
+		var cLog contextLog
+		if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+			t.Fatal(err)
+		}
+		var httpRequestLog HTTPRequestLog
+		if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+			t.Fatal(err)
+		}
+		if cLog.Trace != tt.expected || httpRequestLog.Trace != tt.expected {
+			t.Errorf("%s: unexpected traces: %s, %s", tt.name, cLog.Trace, httpRequestLog.Trace)
+		}
+	}
+}
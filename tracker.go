@@ -0,0 +1,81 @@
+package stalog
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+)
+
+// Tracker groups the logs of a request on the servers which the middlewares don't support
+// (e.g. custom protocols). It has the same lifecycle as the middlewares:
+//
+//	ctx, tracker := stalog.StartTracker(config, r)
+//	// serve the request with ctx, e.g. RequestContextLogger(r.WithContext(ctx))
+//	tracker.Finish(status, responseSize)
+type Tracker struct {
+	reserve *Reserve
+	wrw     *wrappedResponseWriter
+	once    sync.Once
+}
+
+// StartTracker starts tracking the request. The returned context has the request-context logger
+// and the trace, so pass it to the handler of the request.
+func StartTracker(config *Config, r *http.Request) (context.Context, *Tracker) {
+	reserve := NewReserve(config, r)
+	t := &Tracker{
+		reserve: reserve,
+		wrw: &wrappedResponseWriter{
+			ResponseWriter: &trackerResponseWriter{header: http.Header{}},
+			logger:         reserve.contextLogger,
+		},
+	}
+
+	return reserve.request.Context(), t
+}
+
+// Request returns the request with the context of StartTracker
+func (t *Tracker) Request() *http.Request {
+	return t.reserve.request
+}
+
+// Logger returns the request-context logger
+func (t *Tracker) Logger() *ContextLogger {
+	return t.reserve.contextLogger
+}
+
+// Header returns the response header which is used for the request log (e.g. IncludeHeadersInSize)
+func (t *Tracker) Header() http.Header {
+	return t.wrw.Header()
+}
+
+// Finish writes the request log with the status and the response size.
+// Calls after the first one are ignored.
+func (t *Tracker) Finish(status int, responseSize int) {
+	t.once.Do(func() {
+		t.wrw.setStatus(status)
+		t.wrw.responseSize = responseSize
+		t.reserve.LastHandling(t.wrw)
+	})
+}
+
+// LogPanic logs the recovered panic value at CRITICAL severity with the stack trace
+// like the middlewares with Config.Recover
+func (t *Tracker) LogPanic(v interface{}) {
+	t.reserve.contextLogger.writePanic(v, debug.Stack())
+}
+
+// trackerResponseWriter only keeps the response header for Tracker
+type trackerResponseWriter struct {
+	header http.Header
+}
+
+func (w *trackerResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *trackerResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *trackerResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,79 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTracker(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+
+	ctx, tracker := StartTracker(config, r)
+	logger := RequestContextLogger(r.WithContext(ctx))
+	if logger == nil || logger != tracker.Logger() {
+		t.Fatal("the context must have the request-context logger")
+	}
+	logger.Warnf("warn")
+
+	tracker.Finish(http.StatusAccepted, 42)
+	tracker.Finish(http.StatusOK, 0)
+
+	var cLog contextLog
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatalf("request log must be written once: %v", err)
+	}
+	expectedTrace := "projects/test/traces/105445aa7843bc8bf206b12000100000"
+	if httpRequestLog.Trace != expectedTrace || cLog.Trace != expectedTrace {
+		t.Errorf("unexpected traces: %s, %s", httpRequestLog.Trace, cLog.Trace)
+	}
+	if httpRequestLog.Severity != "WARNING" {
+		t.Errorf("unexpected severity: %s", httpRequestLog.Severity)
+	}
+	if httpRequestLog.HTTPRequest.Status != http.StatusAccepted || httpRequestLog.HTTPRequest.ResponseSize != "42" {
+		t.Errorf("unexpected httpRequest: %+v", httpRequestLog.HTTPRequest)
+	}
+}
+
+func TestTrackerLogPanic(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+
+	requestLogOut := new(bytes.Buffer)
+	contextLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = contextLogOut
+
+	_, tracker := StartTracker(config, r)
+	tracker.LogPanic("boom")
+	tracker.Finish(http.StatusInternalServerError, 0)
+
+	var cLog contextLog
+	if err := json.Unmarshal(contextLogOut.Bytes(), &cLog); err != nil {
+		t.Fatal(err)
+	}
+	if cLog.Severity != "CRITICAL" || cLog.Message != "boom" || cLog.Type != reportedErrorEventType {
+		t.Errorf("unexpected log: %+v", cLog)
+	}
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	if httpRequestLog.Severity != "CRITICAL" {
+		t.Errorf("unexpected severity: %s", httpRequestLog.Severity)
+	}
+}
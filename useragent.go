@@ -0,0 +1,108 @@
+package stalog
+
+import (
+	"net/http"
+	"strings"
+)
+
+// UserAgent is the client information parsed from the User-Agent header
+type UserAgent struct {
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browserVersion,omitempty"`
+	OS             string `json:"os,omitempty"`
+	Bot            bool   `json:"bot,omitempty"`
+}
+
+// UserAgentParser parses the User-Agent header. It returns nil if nothing is recognized.
+type UserAgentParser func(ua string) *UserAgent
+
+// botTokens are lower-cased substrings of the User-Agent of bots and non-browser clients
+var botTokens = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "headlesschrome"}
+
+// browserTokens are checked in order because browsers contain the tokens of others (e.g. Chrome contains "Safari/")
+var browserTokens = []struct {
+	token string
+	name  string
+}{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Version/", "Safari"},
+}
+
+// osTokens are checked in order because e.g. Android contains "Linux"
+var osTokens = []struct {
+	token string
+	name  string
+}{
+	{"Windows", "Windows"},
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// DefaultUserAgentParser is the lightweight UserAgentParser which recognizes major browsers, OSes and bots
+func DefaultUserAgentParser(ua string) *UserAgent {
+	if ua == "" {
+		return nil
+	}
+
+	parsed := &UserAgent{}
+	lower := strings.ToLower(ua)
+	for _, token := range botTokens {
+		if strings.Contains(lower, token) {
+			parsed.Bot = true
+			break
+		}
+	}
+
+	for _, b := range browserTokens {
+		if i := strings.Index(ua, b.token); i >= 0 {
+			parsed.Browser = b.name
+			parsed.BrowserVersion = userAgentVersion(ua[i+len(b.token):])
+			break
+		}
+	}
+
+	for _, o := range osTokens {
+		if strings.Contains(ua, o.token) {
+			parsed.OS = o.name
+			break
+		}
+	}
+
+	if *parsed == (UserAgent{}) {
+		return nil
+	}
+
+	return parsed
+}
+
+// userAgentVersion returns the version at the beginning of s (e.g. "120.0.1 Safari/537.36" -> "120.0.1")
+func userAgentVersion(s string) string {
+	if i := strings.IndexAny(s, " ;)"); i >= 0 {
+		return s[:i]
+	}
+
+	return s
+}
+
+// userAgent parses the User-Agent of the request if ParseUserAgent is enabled
+func (c *Config) userAgent(r *http.Request) *UserAgent {
+	if !c.ParseUserAgent {
+		return nil
+	}
+
+	parser := c.UserAgentParser
+	if parser == nil {
+		parser = DefaultUserAgentParser
+	}
+
+	return parser(r.UserAgent())
+}
@@ -0,0 +1,85 @@
+package stalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDefaultUserAgentParser(t *testing.T) {
+	tests := []struct {
+		ua       string
+		expected *UserAgent
+	}{
+		{
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: &UserAgent{Browser: "Chrome", BrowserVersion: "120.0.0.0", OS: "Windows"},
+		},
+		{
+			ua:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			expected: &UserAgent{Browser: "Safari", BrowserVersion: "17.1", OS: "macOS"},
+		},
+		{
+			ua:       "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36 Edg/120.0.0.0",
+			expected: &UserAgent{Browser: "Edge", BrowserVersion: "120.0.0.0", OS: "Android"},
+		},
+		{
+			ua:       "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected: &UserAgent{Bot: true},
+		},
+		{
+			ua:       "curl/8.4.0",
+			expected: &UserAgent{Bot: true},
+		},
+		{
+			ua:       "",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.expected, DefaultUserAgentParser(tt.ua)); diff != "" {
+			t.Errorf("%q: (-expected +actual)\n%s", tt.ua, diff)
+		}
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/foo", nil)
+	r.Header.Set("User-Agent", "custom")
+	w := httptest.NewRecorder()
+
+	requestLogOut := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.RequestLogOut = requestLogOut
+	config.ContextLogOut = new(bytes.Buffer)
+	config.AdditionalData = AdditionalData{"service": "foo"}
+	config.ParseUserAgent = true
+	config.UserAgentParser = func(ua string) *UserAgent {
+		return &UserAgent{Browser: ua}
+	}
+
+	handler := RequestLogging(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	var httpRequestLog HTTPRequestLog
+	if err := json.Unmarshal(requestLogOut.Bytes(), &httpRequestLog); err != nil {
+		t.Fatal(err)
+	}
+	expected := AdditionalData{
+		"service":   "foo",
+		"userAgent": map[string]interface{}{"browser": "custom"},
+	}
+	if diff := cmp.Diff(expected, httpRequestLog.AdditionalData); diff != "" {
+		t.Errorf("(-expected +actual)\n%s", diff)
+	}
+	if len(config.AdditionalData) != 1 {
+		t.Errorf("config data must not be changed: %+v", config.AdditionalData)
+	}
+}
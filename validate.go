@@ -0,0 +1,60 @@
+package stalog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate checks the configuration and returns the first problem found
+func (c *Config) Validate() error {
+	if c.ProjectId == "" && c.Format == FormatJSON {
+		return errors.New("stalog: ProjectId is empty")
+	}
+	if c.ContextLogOut == nil {
+		return errors.New("stalog: ContextLogOut is nil")
+	}
+	if c.RequestLogOut == nil && !c.SingleStream && !c.DisableRequestLog {
+		return errors.New("stalog: RequestLogOut is nil")
+	}
+	if c.Skip < 0 {
+		return fmt.Errorf("stalog: invalid Skip: %d", c.Skip)
+	}
+	if c.MaxEntriesPerRequest < 0 {
+		return fmt.Errorf("stalog: invalid MaxEntriesPerRequest: %d", c.MaxEntriesPerRequest)
+	}
+	if c.RateLimit < 0 || c.RateLimitBurst < 0 {
+		return fmt.Errorf("stalog: invalid RateLimit: %f (burst: %d)", c.RateLimit, c.RateLimitBurst)
+	}
+	if c.ByteBudget < 0 || c.ByteBudgetPeriod < 0 {
+		return fmt.Errorf("stalog: invalid ByteBudget: %d (period: %s)", c.ByteBudget, c.ByteBudgetPeriod)
+	}
+	if c.CaptureRequestBody < 0 {
+		return fmt.Errorf("stalog: invalid CaptureRequestBody: %d", c.CaptureRequestBody)
+	}
+	if c.CaptureErrorResponse < 0 {
+		return fmt.Errorf("stalog: invalid CaptureErrorResponse: %d", c.CaptureErrorResponse)
+	}
+	if c.DebugTailSize < 0 {
+		return fmt.Errorf("stalog: invalid DebugTailSize: %d", c.DebugTailSize)
+	}
+	if c.Format != FormatJSON && c.Format != FormatConsole {
+		return fmt.Errorf("stalog: unknown Format: %d", c.Format)
+	}
+	if err := c.validateDataLayout(); err != nil {
+		return err
+	}
+	for severity, rate := range c.SamplingBySeverity {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("stalog: invalid sampling rate for %s: %f", severity, rate)
+		}
+	}
+
+	return nil
+}
+
+// mustValidate panics if the configuration is invalid, so that middlewares fail fast
+func (c *Config) mustValidate() {
+	if err := c.Validate(); err != nil {
+		panic(err)
+	}
+}
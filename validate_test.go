@@ -0,0 +1,54 @@
+package stalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		valid  bool
+	}{
+		{"default", func(c *Config) {}, true},
+		{"empty project", func(c *Config) { c.ProjectId = "" }, false},
+		{"nil context log out", func(c *Config) { c.ContextLogOut = nil }, false},
+		{"nil request log out", func(c *Config) { c.RequestLogOut = nil }, false},
+		{"nil request log out for single stream", func(c *Config) { c.RequestLogOut = nil; c.SingleStream = true }, true},
+		{"negative skip", func(c *Config) { c.Skip = -1 }, false},
+		{"invalid sampling rate", func(c *Config) { c.SamplingBySeverity = map[Severity]float64{SeverityDebug: 1.5} }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewConfig("test")
+			tt.modify(config)
+			if err := config.Validate(); (err == nil) != tt.valid {
+				t.Errorf("unexpected result: %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestLoggingPanicsForInvalidConfig(t *testing.T) {
+	for name, fn := range map[string]func(config *Config){
+		"RequestLogging":         func(config *Config) { RequestLogging(config) },
+		"RequestLoggingWithEcho": func(config *Config) { RequestLoggingWithEcho(config) },
+		"RequestLoggingWithFunc": func(config *Config) {
+			r := httptest.NewRequest("GET", "/", nil)
+			RequestLoggingWithFunc(config, httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {})
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s must panic for the invalid config", name)
+				}
+			}()
+
+			fn(NewConfig(""))
+		}()
+	}
+}
@@ -0,0 +1,231 @@
+package stalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// defaultWatchInterval is the interval of WatchConfig to load the settings from the source
+const defaultWatchInterval = 10 * time.Second
+
+// DynamicSettings is the part of the config which can be updated without restart by WatchConfig.
+// The nil fields don't override the config.
+type DynamicSettings struct {
+	Severity           *Severity
+	SamplingBySeverity map[Severity]float64
+	SkipPaths          []string
+}
+
+// validate returns an error if the settings are invalid
+func (s *DynamicSettings) validate() error {
+	if s.Severity != nil && (*s.Severity < SeverityDefault || *s.Severity > SeverityEmergency) {
+		return fmt.Errorf("stalog: invalid severity: %d", *s.Severity)
+	}
+	for severity, rate := range s.SamplingBySeverity {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("stalog: invalid sampling rate for %s: %f", severity, rate)
+		}
+	}
+
+	return nil
+}
+
+// ConfigSource provides the settings for WatchConfig, e.g. FileConfigSource,
+// or a document of Firestore or Runtime Configurator with ConfigSourceFunc
+type ConfigSource interface {
+	// Load returns the current settings
+	Load(ctx context.Context) (*DynamicSettings, error)
+}
+
+// ConfigSourceFunc is an adapter to use a function as ConfigSource
+type ConfigSourceFunc func(ctx context.Context) (*DynamicSettings, error)
+
+// Load calls f(ctx)
+func (f ConfigSourceFunc) Load(ctx context.Context) (*DynamicSettings, error) {
+	return f(ctx)
+}
+
+// dynamicFileConfig is the format of the file of FileConfigSource, which has the same keys as LoadConfig
+type dynamicFileConfig struct {
+	Severity  string             `json:"severity" yaml:"severity"`
+	Sampling  map[string]float64 `json:"sampling" yaml:"sampling"`
+	SkipPaths []string           `json:"skipPaths" yaml:"skipPaths"`
+}
+
+// FileConfigSource reads the settings from the YAML (.yaml, .yml) or JSON file
+// with the keys "severity", "sampling" and "skipPaths" of LoadConfig. Other keys are ignored.
+func FileConfigSource(path string) ConfigSource {
+	return ConfigSourceFunc(func(ctx context.Context) (*DynamicSettings, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var fc dynamicFileConfig
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(b, &fc)
+		default:
+			err = json.Unmarshal(b, &fc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stalog: failed to parse %s: %w", path, err)
+		}
+
+		return fc.settings()
+	})
+}
+
+func (fc *dynamicFileConfig) settings() (*DynamicSettings, error) {
+	s := &DynamicSettings{SkipPaths: fc.SkipPaths}
+
+	if fc.Severity != "" {
+		severity, err := ParseSeverity(fc.Severity)
+		if err != nil {
+			return nil, err
+		}
+		s.Severity = &severity
+	}
+
+	if fc.Sampling != nil {
+		s.SamplingBySeverity = make(map[Severity]float64, len(fc.Sampling))
+		for name, rate := range fc.Sampling {
+			severity, err := ParseSeverity(name)
+			if err != nil {
+				return nil, err
+			}
+			s.SamplingBySeverity[severity] = rate
+		}
+	}
+
+	return s, nil
+}
+
+// ConfigWatcher keeps the latest valid settings of the source.
+// Set it to Config.Dynamic to override Severity, SamplingBySeverity and SkipPaths of the config.
+type ConfigWatcher struct {
+	source   ConfigSource
+	settings atomic.Value // *DynamicSettings
+	mu       sync.Mutex
+	err      error
+	done     chan struct{}
+}
+
+// WatchConfig loads the settings from the source and reloads them every 10 seconds until ctx is done.
+// It returns an error if the first settings can't be loaded or are invalid.
+func WatchConfig(ctx context.Context, source ConfigSource) (*ConfigWatcher, error) {
+	return WatchConfigWithInterval(ctx, source, defaultWatchInterval)
+}
+
+// WatchConfigWithInterval is WatchConfig which reloads the settings at every interval
+func WatchConfigWithInterval(ctx context.Context, source ConfigSource, interval time.Duration) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{source: source, done: make(chan struct{})}
+	if err := w.Reload(ctx); err != nil {
+		return nil, err
+	}
+	go w.run(ctx, interval)
+
+	return w, nil
+}
+
+func (w *ConfigWatcher) run(ctx context.Context, interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.Reload(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Reload loads the settings from the source now. The settings are swapped atomically only if they are valid,
+// otherwise the previous settings are kept and the error is returned (see Err).
+func (w *ConfigWatcher) Reload(ctx context.Context) error {
+	s, err := w.source.Load(ctx)
+	if err == nil && s == nil {
+		err = fmt.Errorf("stalog: no settings from the source")
+	}
+	if err == nil {
+		err = s.validate()
+	}
+
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	w.settings.Store(s)
+	return nil
+}
+
+// Settings returns the current settings
+func (w *ConfigWatcher) Settings() *DynamicSettings {
+	s, _ := w.settings.Load().(*DynamicSettings)
+	return s
+}
+
+// Err returns the error of the last reload, or nil if it succeeded
+func (w *ConfigWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+// Done returns a channel which is closed when the watcher stops after ctx is done
+func (w *ConfigWatcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// dynamic returns the current settings of Config.Dynamic, or nil
+func (c *Config) dynamic() *DynamicSettings {
+	if c.Dynamic == nil {
+		return nil
+	}
+
+	return c.Dynamic.Settings()
+}
+
+// severity returns the minimum severity of context logs
+func (c *Config) severity() Severity {
+	if s := c.dynamic(); s != nil && s.Severity != nil {
+		return *s.Severity
+	}
+
+	return c.Severity
+}
+
+// samplingBySeverity returns the sampling rates of context logs
+func (c *Config) samplingBySeverity() map[Severity]float64 {
+	if s := c.dynamic(); s != nil && s.SamplingBySeverity != nil {
+		return s.SamplingBySeverity
+	}
+
+	return c.SamplingBySeverity
+}
+
+// skipPaths returns the paths of the requests which don't need request logs
+func (c *Config) skipPaths() []string {
+	if s := c.dynamic(); s != nil && s.SkipPaths != nil {
+		return s.SkipPaths
+	}
+
+	return c.SkipPaths
+}
@@ -0,0 +1,146 @@
+package stalog
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("severity: warning\nskipPaths:\n  - /healthz\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher, err := WatchConfigWithInterval(ctx, FileConfigSource(path), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewConfig("test")
+	config.Dynamic = watcher
+
+	if config.severity() != SeverityWarning || newContextLogger(config, "", "").Severity != SeverityWarning {
+		t.Errorf("unexpected severity: %s", config.severity())
+	}
+	if !config.skipPath("/healthz") {
+		t.Error("/healthz must be skipped")
+	}
+	if config.samplingBySeverity() != nil {
+		t.Errorf("sampling must not be overridden: %v", config.samplingBySeverity())
+	}
+
+	// invalid settings keep the previous ones
+	write("severity: warning\nsampling:\n  DEBUG: 2\n")
+	if err := watcher.Reload(ctx); err == nil || watcher.Err() == nil {
+		t.Error("invalid sampling rate must be an error")
+	}
+	if !config.skipPath("/healthz") {
+		t.Error("previous settings must be kept")
+	}
+
+	write("severity: error\nsampling:\n  DEBUG: 0.5\n")
+	if err := watcher.Reload(ctx); err != nil || watcher.Err() != nil {
+		t.Fatal(err)
+	}
+	if config.severity() != SeverityError || config.samplingBySeverity()[SeverityDebug] != 0.5 || config.skipPath("/healthz") {
+		t.Errorf("unexpected settings: %+v", watcher.Settings())
+	}
+
+	cancel()
+	select {
+	case <-watcher.Done():
+	case <-time.After(time.Second):
+		t.Error("watcher must stop when ctx is done")
+	}
+}
+
+func TestWatchConfigInvalidSource(t *testing.T) {
+	source := ConfigSourceFunc(func(ctx context.Context) (*DynamicSettings, error) {
+		severity := Severity(1000)
+		return &DynamicSettings{Severity: &severity}, nil
+	})
+
+	if _, err := WatchConfig(context.Background(), source); err == nil {
+		t.Error("invalid first settings must be an error")
+	}
+}
+
+func TestWatchConfigPolling(t *testing.T) {
+	severity := SeverityInfo
+	loaded := make(chan struct{}, 10)
+	source := ConfigSourceFunc(func(ctx context.Context) (*DynamicSettings, error) {
+		select {
+		case loaded <- struct{}{}:
+		default:
+		}
+		return &DynamicSettings{Severity: &severity}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := WatchConfigWithInterval(ctx, source, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-loaded:
+		case <-time.After(time.Second):
+			t.Fatal("settings must be reloaded at every interval")
+		}
+	}
+}
+
+func TestWatchConfigDefaultLogger(t *testing.T) {
+	severity := SeverityInfo
+	source := ConfigSourceFunc(func(ctx context.Context) (*DynamicSettings, error) {
+		s := severity
+		return &DynamicSettings{Severity: &s}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher, err := WatchConfigWithInterval(ctx, source, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	config := NewConfig("test")
+	config.ContextLogOut = out
+	config.Dynamic = watcher
+	logger := newDefaultLogger(config)
+
+	logger.Info("before")
+	severity = SeverityWarning
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("after")
+
+	if !strings.Contains(out.String(), "before") || strings.Contains(out.String(), "after") {
+		t.Errorf("the logger must follow the severity of the config: %s", out.String())
+	}
+	if logger.Enabled(SeverityInfo) {
+		t.Error("INFO must be disabled")
+	}
+}